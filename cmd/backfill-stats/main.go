@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func main() {
+	// Load .env
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Connect DB
+	database.Connect()
+
+	log.Println("Computing excerpts, word counts and reading times...")
+	count, err := services.NewStatsService().Backfill()
+	if err != nil {
+		log.Fatalf("Backfill failed: %v", err)
+	}
+
+	log.Printf("Backfill completed successfully: %d notes updated.", count)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func main() {
+	out := flag.String("out", "site", "directory to write the site to")
+	views := flag.String("views", "views/static", "directory of the page templates")
+	siteURL := flag.String("url", "", "URL the site will be served from (default: the site_url setting or APP_URL)")
+	full := flag.Bool("full", false, "re-render every note, not only the ones updated since the last build")
+	flag.Parse()
+
+	// Load .env
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Connect DB
+	database.Connect()
+
+	builder := services.NewStaticSiteBuilder(*views, services.UploadsDir, *out)
+	report, err := builder.Build(services.StaticBuildOptions{Full: *full, SiteURL: *siteURL})
+	if err != nil {
+		log.Fatalf("Build failed: %v", err)
+	}
+
+	mode := "Incremental build"
+	if report.Full {
+		mode = "Full build"
+	}
+	log.Printf("%s of %d published notes into %s: rendered %d, removed %d, %d notebooks, copied %d uploads.",
+		mode, report.Notes, *out, report.Rendered, report.Removed, report.Notebooks, report.Uploads)
+}
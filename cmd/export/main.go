@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func main() {
+	email := flag.String("user", "", "email of the account to export (required)")
+	notebookID := flag.Uint("notebook", 0, "export only this notebook ID")
+	out := flag.String("out", "taranote-export.zip", "path of the ZIP archive to write")
+	flag.Parse()
+
+	if *email == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load .env
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Connect DB
+	database.Connect()
+
+	var user models.User
+	if err := database.DB.Where("email = ?", *email).First(&user).Error; err != nil {
+		log.Fatalf("User %s not found", *email)
+	}
+
+	var notebook *uint
+	if *notebookID != 0 {
+		id := *notebookID
+		notebook = &id
+	}
+
+	service := services.NewExportService(services.UploadsDir)
+	notes, err := service.Notes(user.ID, notebook)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	if err := service.WriteZip(file, notes); err != nil {
+		file.Close()
+		os.Remove(*out)
+		log.Fatalf("Export failed: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	log.Printf("Exported %d notes to %s", len(notes), *out)
+}
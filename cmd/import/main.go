@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func main() {
+	email := flag.String("user", "", "email of the account to import into (required)")
+	dryRun := flag.Bool("dry-run", false, "report what would be created or skipped without writing (Markdown only)")
+	notebook := flag.String("notebook", "", "notebook for the notes of an ENEX export (default: the file name)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: import -user EMAIL [-dry-run] <vault.zip | directory>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       import -user EMAIL [-notebook NAME] <export.enex>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *email == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load .env
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Connect DB
+	database.Connect()
+	services.EnsureSearchIndex(database.DB)
+
+	var user models.User
+	if err := database.DB.Where("email = ?", *email).First(&user).Error; err != nil {
+		log.Fatalf("User %s not found", *email)
+	}
+
+	if strings.EqualFold(filepath.Ext(flag.Arg(0)), ".enex") {
+		if *dryRun {
+			log.Fatal("-dry-run is not supported for ENEX exports")
+		}
+		importEnex(user.ID, flag.Arg(0), *notebook)
+		return
+	}
+
+	source, closer, err := services.OpenImportSource(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	defer closer.Close()
+
+	report, err := services.NewImportService(services.UploadsDir).Import(source, services.ImportOptions{UserID: user.ID, DryRun: *dryRun})
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	for _, item := range report.Notes {
+		line := fmt.Sprintf("%-6s %s -> %q", item.Action, item.Path, item.Title)
+		if item.Notebook != "" {
+			line += " in " + item.Notebook
+		}
+		if item.Reason != "" {
+			line += " (" + item.Reason + ")"
+		}
+		log.Println(line)
+		for _, warning := range item.Warnings {
+			log.Printf("       warning: %s", warning)
+		}
+	}
+
+	verb := "Created"
+	if *dryRun {
+		verb = "Would create"
+	}
+	log.Printf("%s %d notes, %d notebooks (%v) and %d images; skipped %d.", verb, report.Created, len(report.Notebooks), report.Notebooks, report.Images, report.Skipped)
+}
+
+func importEnex(userID uint, path, notebook string) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	if notebook == "" {
+		notebook = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	reported := 0
+	progress, err := services.NewEnexService(services.UploadsDir).Import(file, services.EnexOptions{
+		UserID:   userID,
+		Notebook: notebook,
+		Progress: func(p services.EnexProgress) {
+			if p.Notes-reported >= 100 {
+				reported = p.Notes
+				log.Printf("%3d%%  %d notes read, %d created, %d skipped", p.Read*100/max(info.Size(), 1), p.Notes, p.Created, p.Skipped)
+			}
+		},
+	})
+	if progress != nil {
+		for _, failure := range progress.Errors {
+			log.Printf("failed: %s", failure)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	log.Printf("Created %d notes in %q with %d attachments; skipped %d, failed %d.", progress.Created, notebook, progress.Resources, progress.Skipped, len(progress.Errors))
+}
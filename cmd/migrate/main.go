@@ -6,6 +6,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
 )
 
 func main() {
@@ -24,10 +25,19 @@ func main() {
 		&models.Notebook{},
 		&models.Note{},
 		&models.Setting{},
+		&models.NoteRevision{},
+		&models.Tag{},
+		&models.NoteLink{},
+		&models.SlugHistory{},
+		&models.NoteDailyViews{},
+		&models.Mark{},
 	)
 	if err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 
+	// Full-text index (requires -tags sqlite_fts5); run cmd/reindex to populate it for existing notes
+	services.EnsureSearchIndex(database.DB)
+
 	log.Println("Migration completed successfully.")
 }
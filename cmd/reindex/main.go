@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func main() {
+	// Load .env
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Connect DB
+	database.Connect()
+
+	services.EnsureSearchIndex(database.DB)
+	if !services.SearchAvailable() {
+		log.Fatal("FTS5 is not available; rebuild with -tags sqlite_fts5")
+	}
+
+	// Rebuild
+	log.Println("Rebuilding full-text search index...")
+	count, err := services.NewSearchService().Reindex()
+	if err != nil {
+		log.Fatalf("Reindex failed: %v", err)
+	}
+
+	log.Printf("Reindex completed successfully: %d notes indexed.", count)
+}
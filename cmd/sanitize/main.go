@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report notes that would change without writing")
+	revisions := flag.Bool("revisions", false, "also clean stored revisions")
+	flag.Parse()
+
+	// Load .env
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Connect DB
+	database.Connect()
+	services.EnsureSearchIndex(database.DB)
+
+	log.Println("Sanitizing stored note content...")
+	report, err := services.NewSanitizeService().CleanExisting(*dryRun, *revisions)
+	if err != nil {
+		log.Fatalf("Sanitize failed: %v", err)
+	}
+
+	verb := "cleaned"
+	if *dryRun {
+		verb = "would change"
+	}
+	log.Printf("Scanned %d notes, %s %d: %v", report.Scanned, verb, len(report.Notes), report.Notes)
+	if *revisions {
+		log.Printf("Revisions %s: %d", verb, report.Revisions)
+	}
+}
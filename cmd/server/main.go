@@ -3,10 +3,13 @@ package main
 import (
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/tarakreasi/taraNote_go/internal/config"
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/routes"
+	"github.com/tarakreasi/taraNote_go/internal/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -22,10 +25,16 @@ func main() {
 
 	// Connect to Database
 	database.Connect()
+	services.EnsureSearchIndex(database.DB)
 
 	// Initialize Session
 	config.InitSession()
 
+	// Background Jobs (run for the lifetime of the process)
+	services.NewTrashService().StartSweeper(services.TrashSweepIntervalFromEnv(), services.TrashRetentionFromEnv(), nil)
+	services.NewPublishScheduler(services.SystemClock, services.SchedulerIntervalFromEnv()).Start(nil)
+	services.Views.Start(services.ViewFlushIntervalFromEnv(), nil)
+
 	// Initialize View Engine
 	engine := html.New("./views", ".html")
 
@@ -33,6 +42,9 @@ func main() {
 	app := fiber.New(fiber.Config{
 		AppName: "TaraNote Go v1.0",
 		Views:   engine,
+		// Bodies are streamed so uploads go to disk; routes.SetupLimits enforces the body limits
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -54,6 +66,7 @@ func main() {
 	})
 
 	// Middleware
+	routes.SetupLimits(app)
 	app.Use(cors.New())
 	// Custom Method Override Middleware
 	app.Use(func(c *fiber.Ctx) error {
@@ -84,8 +97,22 @@ func main() {
 		port = "3000"
 	}
 
+	// Stop gracefully on SIGINT/SIGTERM so buffered views are not lost
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		<-quit
+		if err := app.Shutdown(); err != nil {
+			log.Printf("Server shutdown failed: %v", err)
+		}
+	}()
+
 	log.Printf("Server starting on port %s", port)
 	if err := app.Listen(":" + port); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
+
+	if _, err := services.Views.Flush(); err != nil {
+		log.Printf("View flush failed: %v", err)
+	}
 }
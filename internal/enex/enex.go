@@ -0,0 +1,265 @@
+// Package enex reads Evernote ENEX exports one note at a time and converts their ENML to HTML
+package enex
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// timeLayout is how ENEX writes <created> and <updated>
+const timeLayout = "20060102T150405Z"
+
+// ErrResourceTooLarge is returned by Resource.Decode when the data was cut off at the reader's limit
+var ErrResourceTooLarge = errors.New("resource is too large")
+
+// Note is one <note> of an export
+type Note struct {
+	Title     string     `xml:"title"`
+	Content   string     `xml:"content"` // ENML document
+	Created   string     `xml:"created"`
+	Updated   string     `xml:"updated"`
+	Tags      []string   `xml:"tag"`
+	Resources []Resource `xml:"resource"`
+}
+
+// Resource is a file attached to a note, referenced from the ENML by the MD5 of its data
+type Resource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+	TooLarge bool   `xml:"-"` // The data ran past the reader's limit and was dropped
+}
+
+// CreatedAt returns the creation time, or the zero time when missing or malformed
+func (n *Note) CreatedAt() time.Time {
+	return parseTime(n.Created)
+}
+
+// UpdatedAt returns the last update time, or the zero time when missing or malformed
+func (n *Note) UpdatedAt() time.Time {
+	return parseTime(n.Updated)
+}
+
+func parseTime(value string) time.Time {
+	t, err := time.Parse(timeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Decode returns the resource's bytes and their MD5, the hash <en-media> refers to
+func (r *Resource) Decode() ([]byte, string, error) {
+	if r.TooLarge {
+		return nil, "", ErrResourceTooLarge
+	}
+	if enc := strings.TrimSpace(r.Data.Encoding); enc != "" && enc != "base64" {
+		return nil, "", errors.New("unsupported resource encoding " + enc)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, r.Data.Value))
+	if err != nil {
+		return nil, "", err
+	}
+	sum := md5.Sum(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+// Reader streams the notes of an export, holding only the current note in memory.
+// Resource data is read through a limiter so an oversized attachment is dropped as it streams
+// past instead of being buffered whole.
+type Reader struct {
+	dec             *xml.Decoder
+	src             *dataLimiter
+	maxResourceSize int
+}
+
+// NewReader reads an export whose attachments decode to at most maxResourceSize bytes each
+func NewReader(r io.Reader, maxResourceSize int) *Reader {
+	src := &dataLimiter{r: bufio.NewReader(r), limit: -1}
+	dec := xml.NewDecoder(src)
+	dec.Entity = xml.HTMLEntity
+	return &Reader{dec: dec, src: src, maxResourceSize: maxResourceSize}
+}
+
+// Next returns the next note, or io.EOF after the last one
+func (r *Reader) Next() (*Note, error) {
+	for {
+		token, err := r.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+		return r.readNote()
+	}
+}
+
+func (r *Reader) readNote() (*Note, error) {
+	var note Note
+	for {
+		token, err := r.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "title":
+				err = r.dec.DecodeElement(&note.Title, &t)
+			case "content":
+				err = r.dec.DecodeElement(&note.Content, &t)
+			case "created":
+				err = r.dec.DecodeElement(&note.Created, &t)
+			case "updated":
+				err = r.dec.DecodeElement(&note.Updated, &t)
+			case "tag":
+				var tag string
+				err = r.dec.DecodeElement(&tag, &t)
+				note.Tags = append(note.Tags, tag)
+			case "resource":
+				var resource Resource
+				resource, err = r.readResource()
+				note.Resources = append(note.Resources, resource)
+			default:
+				err = r.dec.Skip()
+			}
+			if err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			return &note, nil
+		}
+	}
+}
+
+func (r *Reader) readResource() (Resource, error) {
+	var resource Resource
+	for {
+		token, err := r.dec.Token()
+		if err != nil {
+			return resource, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "data":
+				err = r.readData(&resource, t)
+			case "mime":
+				err = r.dec.DecodeElement(&resource.Mime, &t)
+			case "resource-attributes":
+				var attrs struct {
+					FileName string `xml:"file-name"`
+				}
+				err = r.dec.DecodeElement(&attrs, &t)
+				resource.FileName = attrs.FileName
+			default:
+				err = r.dec.Skip()
+			}
+			if err != nil {
+				return resource, err
+			}
+		case xml.EndElement:
+			return resource, nil
+		}
+	}
+}
+
+// readData reads the base64 text of a <data> with the limiter armed, so the decoder never holds
+// more than the encoded size of maxResourceSize
+func (r *Reader) readData(resource *Resource, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "encoding" {
+			resource.Data.Encoding = attr.Value
+		}
+	}
+
+	r.src.limit, r.src.overflow = base64.StdEncoding.EncodedLen(r.maxResourceSize), false
+	defer func() { r.src.limit = -1 }()
+
+	var value strings.Builder
+	for {
+		token, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			value.Write(t)
+		case xml.StartElement:
+			if err := r.dec.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			resource.TooLarge = r.src.overflow
+			if !resource.TooLarge {
+				resource.Data.Value = value.String()
+			}
+			return nil
+		}
+	}
+}
+
+// dataLimiter feeds the decoder one byte at a time. While armed with a limit it counts the
+// non-whitespace bytes of the text being read; past the limit it drops the rest of the text up
+// to the next tag. Base64 never contains '<', so the first one ends the text and disarms it.
+type dataLimiter struct {
+	r        *bufio.Reader
+	limit    int // Bytes left in the current text, or -1 when disarmed
+	overflow bool
+}
+
+func (l *dataLimiter) ReadByte() (byte, error) {
+	b, err := l.r.ReadByte()
+	if err != nil || l.limit < 0 || b == ' ' || b == '\n' || b == '\r' || b == '\t' {
+		return b, err
+	}
+	if b == '<' {
+		l.limit = -1
+		return b, nil
+	}
+	if l.limit == 0 {
+		l.overflow = true
+		for b != '<' {
+			if b, err = l.r.ReadByte(); err != nil {
+				return b, err
+			}
+		}
+		l.limit = -1
+		return b, nil
+	}
+	l.limit--
+	return b, nil
+}
+
+func (l *dataLimiter) Read(p []byte) (int, error) {
+	for i := range p {
+		b, err := l.ReadByte()
+		if err != nil {
+			return i, err
+		}
+		p[i] = b
+	}
+	return len(p), nil
+}
+
+// InputOffset is how many bytes of the export have been read, for progress reporting
+func (r *Reader) InputOffset() int64 {
+	return r.dec.InputOffset()
+}
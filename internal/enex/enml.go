@@ -0,0 +1,423 @@
+package enex
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Media is a stored resource that <en-media hash="..."> points at
+type Media struct {
+	URL      string
+	Mime     string
+	FileName string
+}
+
+// Attributes worth keeping; the sanitizer decides on the rest when the note is saved
+var keptAttrs = map[string]bool{"href": true, "src": true, "alt": true, "title": true, "colspan": true, "rowspan": true, "start": true}
+
+var renamed = map[string]string{"b": "strong", "i": "em", "strike": "s", "del": "s"}
+
+var blockTags = map[string]bool{
+	"p": true, "div": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true, "hr": true, "figure": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+}
+
+// ToHTML converts an ENML document to the HTML the editor produces: <div> lines become
+// paragraphs, checklists become Tiptap task lists, code blocks become <pre><code>, and
+// <en-media> becomes an image or a link to the stored file (media is keyed by lower-case hash).
+// Encrypted sections can't be decrypted and are replaced by a placeholder.
+func ToHTML(enml string, media map[string]Media) (string, error) {
+	root, err := parseENML(enml)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for _, node := range normalizeFlow(convertChildren(root, media)) {
+		if err := html.Render(&out, node); err != nil {
+			return "", err
+		}
+	}
+	return out.String(), nil
+}
+
+// parseENML builds a node tree from the ENML XML, falling back to the HTML parser for
+// malformed documents. It returns the <en-note> element.
+func parseENML(enml string) (*html.Node, error) {
+	root := &html.Node{Type: html.ElementNode, Data: "en-note"}
+
+	dec := xml.NewDecoder(strings.NewReader(enml))
+	dec.Entity = xml.HTMLEntity
+	stack := []*html.Node{root}
+	var xmlErr error
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			xmlErr = err
+			break
+		}
+		top := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "en-note" && len(stack) == 1 {
+				stack = append(stack, root)
+				continue
+			}
+			node := &html.Node{Type: html.ElementNode, Data: strings.ToLower(t.Name.Local)}
+			for _, attr := range t.Attr {
+				node.Attr = append(node.Attr, html.Attribute{Key: strings.ToLower(attr.Name.Local), Val: attr.Value})
+			}
+			top.AppendChild(node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top.AppendChild(&html.Node{Type: html.TextNode, Data: string(t)})
+		}
+	}
+	if xmlErr == nil {
+		return root, nil
+	}
+
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return nil, err
+	}
+	if note := findElement(doc, "en-note"); note != nil {
+		return note, nil
+	}
+	if body := findElement(doc, "body"); body != nil {
+		return body, nil
+	}
+	return doc, nil
+}
+
+func findElement(n *html.Node, name string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == name {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func element(tag string, attrs []html.Attribute, children ...*html.Node) *html.Node {
+	n := &html.Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag)), Attr: attrs}
+	for _, c := range children {
+		n.AppendChild(c)
+	}
+	return n
+}
+
+func text(data string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: data}
+}
+
+// detach removes and returns the children of n
+func detach(n *html.Node) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		n.RemoveChild(c)
+		children = append(children, c)
+		c = next
+	}
+	return children
+}
+
+func convertChildren(n *html.Node, media map[string]Media) []*html.Node {
+	var out []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		out = append(out, convert(c, media)...)
+	}
+	return out
+}
+
+// convert maps one ENML node to HTML nodes, leaving paragraph structure to normalizeFlow
+func convert(n *html.Node, media map[string]Media) []*html.Node {
+	if n.Type == html.TextNode {
+		return []*html.Node{text(n.Data)}
+	}
+	if n.Type != html.ElementNode {
+		return nil
+	}
+
+	style := strings.ReplaceAll(attr(n, "style"), " ", "")
+	switch n.Data {
+	case "en-media":
+		// The HTML parser nests whatever follows a self-closed <en-media/> inside it
+		out := mediaNodes(attr(n, "hash"), attr(n, "type"), media)
+		return append(out, convertChildren(n, media)...)
+	case "en-todo":
+		var attrs []html.Attribute
+		if attr(n, "checked") == "true" {
+			attrs = append(attrs, html.Attribute{Key: "checked", Val: "checked"})
+		}
+		return append([]*html.Node{element("en-todo", attrs)}, convertChildren(n, media)...)
+	case "en-crypt":
+		return []*html.Node{element("p", nil, element("em", nil, text("[Encrypted content not imported]")))}
+	case "script", "style", "title", "head", "object", "embed", "iframe":
+		return nil
+	case "span", "font", "en-note", "html", "body", "center":
+		return convertChildren(n, media)
+	case "div":
+		if strings.Contains(style, "-en-codeblock:true") {
+			return []*html.Node{codeBlock(n)}
+		}
+	}
+
+	tag := n.Data
+	if name, ok := renamed[tag]; ok {
+		tag = name
+	}
+	var attrs []html.Attribute
+	for _, a := range n.Attr {
+		if keptAttrs[a.Key] {
+			attrs = append(attrs, a)
+		}
+	}
+	// Newer Evernote checklists are lists styled with --en-todo / --en-checked
+	if tag == "ul" && strings.Contains(style, "--en-todo:true") {
+		attrs = append(attrs, html.Attribute{Key: "data-type", Val: "taskList"})
+	}
+	if tag == "li" && strings.Contains(style, "--en-checked:true") {
+		attrs = append(attrs, html.Attribute{Key: "data-checked", Val: "true"})
+	}
+	return []*html.Node{element(tag, attrs, convertChildren(n, media)...)}
+}
+
+func mediaNodes(hash, mime string, media map[string]Media) []*html.Node {
+	m, ok := media[strings.ToLower(hash)]
+	if !ok {
+		return nil
+	}
+	if mime == "" {
+		mime = m.Mime
+	}
+	// SVGs are stored as plain files, which browsers won't render as images
+	if strings.HasPrefix(mime, "image/") && mime != "image/svg+xml" {
+		return []*html.Node{element("img", []html.Attribute{{Key: "src", Val: m.URL}, {Key: "alt", Val: m.FileName}})}
+	}
+	name := m.FileName
+	if name == "" {
+		name = "Attachment"
+	}
+	return []*html.Node{element("a", []html.Attribute{{Key: "href", Val: m.URL}}, text(name))}
+}
+
+// codeBlock turns Evernote's code block (a div of line divs) into <pre><code>
+func codeBlock(n *html.Node) *html.Node {
+	var lines []string
+	var line strings.Builder
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		switch {
+		case c.Type == html.TextNode:
+			line.WriteString(c.Data)
+		case c.Type == html.ElementNode && c.Data == "br":
+			lines = append(lines, line.String())
+			line.Reset()
+		case c.Type == html.ElementNode && c.Data == "div":
+			for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+				walk(cc)
+			}
+			if line.Len() > 0 {
+				lines = append(lines, line.String())
+				line.Reset()
+			}
+		default:
+			for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+				walk(cc)
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return element("pre", nil, element("code", nil, text(strings.Join(lines, "\n"))))
+}
+
+func isBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && blockTags[n.Data]
+}
+
+func isTodo(n *html.Node) bool {
+	return n != nil && n.Type == html.ElementNode && n.Data == "en-todo"
+}
+
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isBlock(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFlow turns a mix of inline nodes and blocks into a list of blocks the way the editor
+// structures documents: runs of inline content and line <div>s become paragraphs, wrapper divs
+// are unwrapped, and paragraphs starting with a checkbox are grouped into task lists
+func normalizeFlow(nodes []*html.Node) []*html.Node {
+	var out, inline []*html.Node
+	flush := func() {
+		blank := true
+		for _, n := range inline {
+			if n.Type != html.TextNode || strings.TrimSpace(n.Data) != "" {
+				blank = false
+			}
+		}
+		if !blank {
+			out = append(out, paragraph(inline))
+		}
+		inline = nil
+	}
+
+	for _, n := range nodes {
+		switch {
+		case n.Type == html.ElementNode && (n.Data == "div" || n.Data == "p"):
+			flush()
+			if hasBlockChild(n) {
+				out = append(out, normalizeFlow(detach(n))...)
+			} else {
+				out = append(out, paragraph(detach(n)))
+			}
+		case isBlock(n):
+			flush()
+			out = append(out, normalizeBlock(n))
+		default:
+			inline = append(inline, n)
+		}
+	}
+	flush()
+	return groupTasks(out)
+}
+
+// normalizeBlock normalizes the content of containers that hold paragraphs in the editor
+func normalizeBlock(n *html.Node) *html.Node {
+	switch n.Data {
+	case "li", "blockquote", "td", "th":
+		for _, c := range normalizeFlow(detach(n)) {
+			n.AppendChild(c)
+		}
+	case "ul", "ol", "table", "thead", "tbody", "tfoot", "tr":
+		for _, c := range detach(n) {
+			if c.Type == html.ElementNode {
+				n.AppendChild(normalizeBlock(c))
+			}
+		}
+		if n.Data == "ul" && attr(n, "data-type") == "taskList" {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				taskItem(c, attr(c, "data-checked") == "true")
+			}
+		}
+	}
+	return n
+}
+
+// paragraph wraps inline nodes in <p>, dropping a trailing <br> (Evernote's empty line is <div><br/></div>)
+func paragraph(inline []*html.Node) *html.Node {
+	for len(inline) > 0 {
+		last := inline[len(inline)-1]
+		if (last.Type == html.ElementNode && last.Data == "br") || (last.Type == html.TextNode && strings.TrimSpace(last.Data) == "") {
+			inline = inline[:len(inline)-1]
+			continue
+		}
+		break
+	}
+	return element("p", nil, inline...)
+}
+
+// groupTasks turns runs of paragraphs that start with <en-todo> into a task list
+func groupTasks(blocks []*html.Node) []*html.Node {
+	var out []*html.Node
+	var list *html.Node
+	for _, block := range blocks {
+		if block.Data != "p" || !isTodo(firstContent(block)) {
+			list = nil
+			out = append(out, cleanTodos(block))
+			continue
+		}
+		todo := firstContent(block)
+		checked := attr(todo, "checked") != ""
+		block.RemoveChild(todo)
+		if first := block.FirstChild; first != nil && first.Type == html.TextNode {
+			first.Data = strings.TrimLeft(first.Data, " \u00a0")
+		}
+
+		if list == nil {
+			list = element("ul", []html.Attribute{{Key: "data-type", Val: "taskList"}})
+			out = append(out, list)
+		}
+		item := element("li", nil, cleanTodos(block))
+		list.AppendChild(taskItem(item, checked))
+	}
+	return out
+}
+
+// taskItem gives a list item Tiptap's task item markup
+func taskItem(li *html.Node, checked bool) *html.Node {
+	content := detach(li)
+	li.Attr = []html.Attribute{{Key: "data-type", Val: "taskItem"}, {Key: "data-checked", Val: "false"}}
+	input := element("input", []html.Attribute{{Key: "type", Val: "checkbox"}})
+	if checked {
+		li.Attr[1].Val = "true"
+		input.Attr = append(input.Attr, html.Attribute{Key: "checked", Val: "checked"})
+	}
+	li.AppendChild(element("label", nil, input, element("span", nil)))
+	li.AppendChild(element("div", nil, normalizeFlow(content)...))
+	return li
+}
+
+// firstContent is the first child that isn't blank text
+func firstContent(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode || strings.TrimSpace(c.Data) != "" {
+			return c
+		}
+	}
+	return nil
+}
+
+// cleanTodos replaces checkboxes that don't start a line with a text marker
+func cleanTodos(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if isTodo(c) {
+			marker := "☐ "
+			if attr(c, "checked") != "" {
+				marker = "☑ "
+			}
+			n.InsertBefore(text(marker), c)
+			n.RemoveChild(c)
+		} else if c.Type == html.ElementNode {
+			cleanTodos(c)
+		}
+		c = next
+	}
+	return n
+}
@@ -0,0 +1,134 @@
+package enex
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestToHTML_EditorMarkup(t *testing.T) {
+	enml := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>Hello <b>world</b>&nbsp;<span style="color:red">again</span></div><div><br/></div>` +
+		`<div><en-todo checked="true"/>Done</div><div><en-todo/>Open</div>` +
+		`<div><en-media hash="ABC" type="image/png"/></div><en-media hash="def" type="application/pdf"/>` +
+		`<div style="-en-codeblock: true"><div>a &lt; b</div><div>c</div></div>` +
+		`<ul style="--en-todo:true;"><li style="--en-checked:true;"><div>one</div></li><li style="--en-checked:false;"><div>two</div></li></ul>` +
+		`<en-crypt cipher="AES">secret</en-crypt></en-note>`
+
+	out, err := ToHTML(enml, map[string]Media{
+		"abc": {URL: "/public/uploads/a.png", Mime: "image/png", FileName: "a.png"},
+		"def": {URL: "/public/uploads/b.pdf", Mime: "application/pdf", FileName: "doc.pdf"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`<p>Hello <strong>world</strong>`,
+		`again</p>`,
+		`<ul data-type="taskList"><li data-type="taskItem" data-checked="true"><label><input type="checkbox" checked="checked"/><span></span></label><div><p>Done</p></div></li>`,
+		`<li data-type="taskItem" data-checked="false"><label><input type="checkbox"/><span></span></label><div><p>Open</p></div></li></ul>`,
+		`<img src="/public/uploads/a.png" alt="a.png"/>`,
+		`<a href="/public/uploads/b.pdf">doc.pdf</a>`,
+		"<pre><code>a &lt; b\nc</code></pre>",
+		`<li data-type="taskItem" data-checked="true"><label><input type="checkbox" checked="checked"/><span></span></label><div><p>one</p></div></li>`,
+		`[Encrypted content not imported]`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"en-", "<span style", "<div><br", "secret"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestToHTML_UnknownMediaDropped(t *testing.T) {
+	out, err := ToHTML(`<en-note><div>Before<en-media hash="missing" type="image/png"/> after</div></en-note>`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "<p>Before after</p>" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestReader_StreamsNotes(t *testing.T) {
+	export := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240102T030405Z">
+<note><title>First &amp; best</title><content><![CDATA[<en-note><div>One</div></en-note>]]></content>
+<created>20230105T101500Z</created><updated>20230106T120000Z</updated><tag>work</tag><tag>ideas</tag>
+<resource><data encoding="base64">aGVs
+bG8=</data><mime>text/plain</mime><resource-attributes><file-name>hello.txt</file-name></resource-attributes></resource>
+</note>
+<note><title>Second</title><content><![CDATA[<en-note/>]]></content><created>bogus</created></note>
+</en-export>`
+
+	reader := NewReader(strings.NewReader(export), 1<<20)
+	first, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Title != "First & best" || len(first.Tags) != 2 || first.Tags[1] != "ideas" {
+		t.Errorf("unexpected note %+v", first)
+	}
+	if got := first.CreatedAt().Format("2006-01-02 15:04"); got != "2023-01-05 10:15" {
+		t.Errorf("created = %s", got)
+	}
+	if len(first.Resources) != 1 || first.Resources[0].FileName != "hello.txt" {
+		t.Fatalf("unexpected resources %+v", first.Resources)
+	}
+	data, hash, err := first.Resources[0].Decode()
+	if err != nil || string(data) != "hello" || hash != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("decode = %q %s %v", data, hash, err)
+	}
+
+	second, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.CreatedAt().IsZero() {
+		t.Errorf("malformed date parsed as %v", second.CreatedAt())
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestReader_OversizedResource(t *testing.T) {
+	export := `<en-export>
+<note><title>Big</title><content><![CDATA[<en-note/>]]></content>
+<resource><data encoding="base64">` + strings.Repeat("QUJD\n", 10) + `</data><mime>text/plain</mime></resource>
+<resource><data encoding="base64">aGVsbG8=</data><mime>text/plain</mime></resource>
+</note>
+<note><title>After</title><content><![CDATA[<en-note/>]]></content></note>
+</en-export>`
+
+	reader := NewReader(strings.NewReader(export), 12)
+	note, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(note.Resources) != 2 {
+		t.Fatalf("unexpected resources %+v", note.Resources)
+	}
+	if _, _, err := note.Resources[0].Decode(); err != ErrResourceTooLarge {
+		t.Errorf("expected ErrResourceTooLarge, got %v", err)
+	}
+	if note.Resources[0].Data.Value != "" || note.Resources[0].Mime != "text/plain" {
+		t.Errorf("oversized resource kept %+v", note.Resources[0])
+	}
+	if data, _, err := note.Resources[1].Decode(); err != nil || string(data) != "hello" {
+		t.Errorf("decode = %q %v", data, err)
+	}
+
+	// The rest of the export is still read
+	after, err := reader.Next()
+	if err != nil || after.Title != "After" {
+		t.Errorf("next = %+v %v", after, err)
+	}
+}
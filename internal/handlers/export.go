@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var exportService = services.NewExportService(services.UploadsDir)
+
+// ExportNotes downloads the user's notes, or one ?notebook_id, as a Markdown ZIP archive
+func ExportNotes(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	var notebookID *uint
+	if id := c.QueryInt("notebook_id", 0); id > 0 {
+		value := uint(id)
+		notebookID = &value
+	}
+
+	notes, err := exportService.Notes(userID, notebookID)
+	if errors.Is(err, services.ErrNotebookNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to export notes"})
+	}
+
+	name := "taranote-export"
+	if notebookID != nil && len(notes) > 0 && notes[0].Notebook != nil {
+		name += "-" + notes[0].Notebook.Slug
+	}
+	filename := fmt.Sprintf("%s-%s.zip", name, time.Now().Format("2006-01-02"))
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Attachment(filename)
+	// The archive is streamed; once it has started an error can only cut it short
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := exportService.WriteZip(w, notes); err != nil {
+			log.Printf("Export failed: %v", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
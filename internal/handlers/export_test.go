@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func zipNames(t *testing.T, body string) []string {
+	reader, err := zip.NewReader(bytes.NewReader([]byte(body)), int64(len(body)))
+	assert.NoError(t, err)
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	return names
+}
+
+func TestExport_MarkdownZip(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "export@test.com")
+	notebook := models.Notebook{UserID: user.ID, Name: "Recipes", Slug: "recipes"}
+	database.DB.Create(&notebook)
+
+	createNoteWithContent(app, cookie, "Loose Note", "<p>hello</p>")
+	id := createNoteWithContent(app, cookie, "Pancakes", "<p>flour</p>")
+	testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{"notebook_id": notebook.ID}, cookie)
+
+	// 1. Whole account
+	resp, body, err := testutils.MakeRequest(app, "GET", "/api/v1/admin/export", nil, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), `attachment; filename="taranote-export-`)
+	assert.ElementsMatch(t, []string{"loose-note.md", "recipes/pancakes.md"}, zipNames(t, body))
+
+	// 2. One notebook
+	resp, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/export?notebook_id=%d", notebook.ID), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "taranote-export-recipes-")
+	assert.Equal(t, []string{"recipes/pancakes.md"}, zipNames(t, body))
+
+	// 3. Someone else's notebook
+	_, stranger := seedUserAndLogin(app, "stranger-export@test.com")
+	resp, _, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/export?notebook_id=%d", notebook.ID), nil, stranger)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
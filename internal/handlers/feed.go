@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var feedService = services.NewFeedService()
+
+// RSSFeed serves the latest published notes as RSS 2.0, for the whole site, a notebook (:slug) or an author (:username)
+func RSSFeed(c *fiber.Ctx) error {
+	return serveFeed(c, "application/rss+xml; charset=utf-8", services.WriteRSS)
+}
+
+// AtomFeed serves the same feeds as Atom 1.0
+func AtomFeed(c *fiber.Ctx) error {
+	return serveFeed(c, "application/atom+xml; charset=utf-8", services.WriteAtom)
+}
+
+func serveFeed(c *fiber.Ctx, contentType string, write func(io.Writer, *services.Feed) error) error {
+	site := services.LoadSiteInfo()
+	feed, err := feedService.Feed(site, services.FeedScope{
+		NotebookSlug: c.Params("slug"),
+		Author:       c.Params("username"),
+	})
+	if errors.Is(err, services.ErrNotebookNotFound) || errors.Is(err, services.ErrAuthorNotFound) {
+		return c.Status(404).SendString("Feed not found")
+	}
+	if err != nil {
+		return c.Status(500).SendString("Error building feed")
+	}
+	feed.Self = site.AbsURL(c.Path())
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	if notModified(c, feed.ETag(), feed.Modified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, feed); err != nil {
+		return c.Status(500).SendString("Error building feed")
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestFeeds_ServeAndRevalidate(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	author := models.User{Name: "Writer", Username: "writer", Email: "writer@test.com"}
+	database.DB.Create(&author)
+	notebook := models.Notebook{UserID: author.ID, Name: "Essays", Slug: "essays"}
+	database.DB.Create(&notebook)
+	published := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	note := models.Note{UserID: author.ID, NotebookID: &notebook.ID, Title: "On feeds", Slug: "on-feeds", Status: models.NoteStatusPublished,
+		PublishedAt: &published, Excerpt: "Subscribe", Content: "<p>Full text</p>"}
+	database.DB.Create(&note)
+	database.DB.Create(&models.Note{UserID: author.ID, Title: "Hidden", Slug: "hidden", Status: models.NoteStatusDraft})
+	database.DB.Create(&models.Setting{Key: "site_url", Value: "https://blog.example.com/"})
+
+	// 1. Site feeds
+	resp, body, _ := testutils.MakeRequest(app, "GET", "/feed.xml", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, "<title>On feeds</title>")
+	assert.Contains(t, body, `<atom:link href="https://blog.example.com/feed.xml" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.NotContains(t, body, "Hidden")
+	assert.NotContains(t, body, "Full text")
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, lastModified)
+
+	resp, body, _ = testutils.MakeRequest(app, "GET", "/atom.xml", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"), "formats are different representations")
+
+	// 2. Notebook and author feeds
+	for _, path := range []string{"/notebooks/essays/feed.xml", "/notebooks/essays/atom.xml", "/authors/writer/feed.xml", "/authors/writer/atom.xml"} {
+		resp, body, _ = testutils.MakeRequest(app, "GET", path, nil, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, body, "On feeds", path)
+	}
+	for _, path := range []string{"/notebooks/missing/feed.xml", "/authors/nobody/atom.xml"} {
+		resp, _, _ = testutils.MakeRequest(app, "GET", path, nil, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+
+	// 3. Conditional requests
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Empty(t, body)
+
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-None-Match": `"stale", W/` + etag})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// If-None-Match wins over If-Modified-Since
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// 4. Switching to full content changes the representation
+	database.DB.Create(&models.Setting{Key: "feed_content", Value: "full"})
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "<content:encoded><![CDATA[<p>Full text</p>]]></content:encoded>")
+
+	// 5. Editing a note invalidates the cached feed
+	etag = resp.Header.Get("ETag")
+	database.DB.Model(&note).Updates(map[string]interface{}{"title": "On feeds, revised", "updated_at": time.Now().Add(time.Hour)})
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "On feeds, revised")
+
+	// 6. Settings changes and removals move Last-Modified on, though no note left in the feed changed
+	lastModified = resp.Header.Get("Last-Modified")
+	database.DB.Model(&models.Setting{}).Where("key = ?", "feed_content").
+		Updates(map[string]interface{}{"value": "excerpt", "updated_at": time.Now().Add(2 * time.Hour)})
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lastModified = resp.Header.Get("Last-Modified")
+	database.DB.Model(&note).Update("deleted_at", time.Now().Add(3*time.Hour))
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "GET", "/feed.xml", nil, "", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotContains(t, body, "On feeds")
+}
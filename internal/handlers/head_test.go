@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+var jsonLDPattern = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+func TestHeadMeta_Article(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	database.DB.Create(&models.Setting{Key: "site_url", Value: "https://blog.example.com"})
+	database.DB.Create(&models.Setting{Key: "site_name", Value: "Field Notes"})
+	database.DB.Create(&models.Setting{Key: "twitter_site", Value: "fieldnotes"})
+	user := models.User{Name: "Tara", Email: "tara@example.com", Username: "tara", Password: "x"}
+	database.DB.Create(&user)
+	database.DB.Create(&models.Note{
+		UserID:     user.ID,
+		Title:      "Tea & <Biscuits>",
+		Slug:       "tea",
+		Excerpt:    `A "short" guide </script>`,
+		CoverImage: "/public/uploads/tea.png",
+		Status:     models.NoteStatusPublished,
+	})
+
+	resp, body, _ := testutils.MakeRequest(app, "GET", "/articles/tea", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "<title>Tea &amp; &lt;Biscuits&gt; · Field Notes</title>")
+	assert.Contains(t, body, `<meta name="description" content="A &#34;short&#34; guide &lt;/script&gt;">`)
+	assert.Contains(t, body, `<link rel="canonical" href="https://blog.example.com/articles/tea">`)
+	assert.Contains(t, body, `<meta property="og:type" content="article">`)
+	assert.Contains(t, body, `<meta property="og:image" content="https://blog.example.com/public/uploads/tea.png">`)
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+	assert.Contains(t, body, `<meta name="twitter:site" content="@fieldnotes">`)
+	assert.Contains(t, body, `<meta property="article:author" content="Tara">`)
+
+	match := jsonLDPattern.FindStringSubmatch(body)
+	if !assert.Len(t, match, 2) {
+		return
+	}
+	var article map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal([]byte(match[1]), &article)) {
+		return
+	}
+	assert.Equal(t, "Article", article["@type"])
+	assert.Equal(t, "Tea & <Biscuits>", article["headline"])
+	assert.Equal(t, `A "short" guide </script>`, article["description"])
+	assert.Equal(t, []interface{}{"https://blog.example.com/public/uploads/tea.png"}, article["image"])
+	assert.Equal(t, map[string]interface{}{"@type": "Person", "name": "Tara"}, article["author"])
+	assert.Equal(t, "https://blog.example.com/articles/tea", article["mainEntityOfPage"])
+}
+
+func TestHeadMeta_SiteDefaults(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	database.DB.Create(&models.Setting{Key: "site_url", Value: "https://blog.example.com/"})
+	database.DB.Create(&models.Setting{Key: "site_name", Value: "Field Notes"})
+	database.DB.Create(&models.Setting{Key: "site_description", Value: "Notes from the field"})
+	database.DB.Create(&models.Setting{Key: "site_image", Value: "/images/share.png"})
+	database.DB.Create(&models.Notebook{UserID: 1, Name: "Journal", Slug: "journal"})
+
+	_, body, _ := testutils.MakeRequest(app, "GET", "/", nil, "")
+	assert.Contains(t, body, "<title>Field Notes</title>")
+	assert.Contains(t, body, `<meta name="description" content="Notes from the field">`)
+	assert.Contains(t, body, `<meta property="og:type" content="website">`)
+	assert.Contains(t, body, `<meta property="og:image" content="https://blog.example.com/images/share.png">`)
+	assert.NotContains(t, body, "application/ld+json")
+
+	_, body, _ = testutils.MakeRequest(app, "GET", "/notebooks/journal", nil, "")
+	assert.Contains(t, body, "<title>Journal · Field Notes</title>")
+	assert.Contains(t, body, `<link rel="canonical" href="https://blog.example.com/notebooks/journal">`)
+
+	// Pages rendered without metadata keep the plain shell
+	_, body, _ = testutils.MakeRequest(app, "GET", "/login", nil, "")
+	assert.Contains(t, body, "<title>TaraNote Go</title>")
+	assert.NotContains(t, body, "og:title")
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var importService = services.NewImportService(services.UploadsDir)
+
+// ImportNotes creates notes from an uploaded ZIP of Markdown files (field "file");
+// ?dry_run=true only reports what would be created or skipped
+func ImportNotes(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "No file uploaded"})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "No file uploaded"})
+	}
+	defer file.Close()
+
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "File is not a ZIP archive"})
+	}
+
+	report, err := importService.Import(archive, services.ImportOptions{
+		UserID: userID,
+		DryRun: c.QueryBool("dry_run"),
+	})
+	if errors.Is(err, services.ErrNoMarkdownFiles) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to import notes"})
+	}
+
+	status := 201
+	if report.DryRun {
+		status = 200
+	}
+	return c.Status(status).JSON(fiber.Map{"data": report})
+}
+
+var enexService = services.NewEnexService(services.UploadsDir)
+
+// ImportEnex starts importing an uploaded Evernote export (field "file") in the background into the
+// notebook named by the "notebook" field, or after the file. Poll ImportJob for its progress.
+func ImportEnex(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "No file uploaded"})
+	}
+	if !strings.EqualFold(filepath.Ext(header.Filename), ".enex") {
+		return c.Status(400).JSON(fiber.Map{"error": "File is not an ENEX export"})
+	}
+
+	notebook := strings.TrimSpace(c.FormValue("notebook"))
+	if notebook == "" {
+		notebook = strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	}
+
+	// The server streams the body, so the upload has already been spooled to disk; the job gets
+	// its own copy (a rename) because the spooled file only lives as long as the request
+	tmp, err := os.CreateTemp("", "import-*.enex")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to import notes"})
+	}
+	tmp.Close()
+	if err := c.SaveFile(header, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to import notes"})
+	}
+
+	job := services.Jobs.Start(userID, header.Size, func(update func(services.EnexProgress)) (*services.EnexProgress, error) {
+		defer os.Remove(tmp.Name())
+		file, err := os.Open(tmp.Name())
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return enexService.Import(file, services.EnexOptions{UserID: userID, Notebook: notebook, Progress: update})
+	})
+	return c.Status(202).JSON(fiber.Map{"data": job})
+}
+
+// ImportJob reports the progress of one of the user's background imports
+func ImportJob(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	job, ok := services.Jobs.Get(c.Params("id"), userID)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Import job not found"})
+	}
+	return c.JSON(fiber.Map{"data": job})
+}
@@ -0,0 +1,204 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+// uploadFile posts content as the multipart field "file"
+func uploadFile(t *testing.T, app *fiber.App, url, cookie, filename string, content []byte) (*http.Response, string) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	assert.NoError(t, err)
+	part.Write(content)
+	form.Close()
+
+	req := httptest.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Cookie", cookie)
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestImport_ZipUpload(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "import@test.com")
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"Cooking/Pancakes.md": "---\ntags: [breakfast]\n---\nSee [[Waffles]].\n",
+		"Cooking/Waffles.md":  "Crispy.\n",
+		"Loose.md":            "# Loose\n",
+		"readme.txt":          "ignored",
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	// 1. Dry run
+	resp, body := uploadFile(t, app, "/api/v1/admin/import?dry_run=true", cookie, "vault.zip", archive.Bytes())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var result struct {
+		Data services.ImportReport `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.True(t, result.Data.DryRun)
+	assert.Equal(t, 3, result.Data.Created)
+	assert.Equal(t, []string{"Cooking"}, result.Data.Notebooks)
+
+	var count int64
+	database.DB.Model(&models.Note{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 2. Import, then the same archive again
+	resp, body = uploadFile(t, app, "/api/v1/admin/import", cookie, "vault.zip", archive.Bytes())
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, 3, result.Data.Created)
+
+	database.DB.Model(&models.Note{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(3), count)
+	var notebook models.Notebook
+	assert.NoError(t, database.DB.Where("user_id = ? AND name = ?", user.ID, "Cooking").First(&notebook).Error)
+
+	_, body = uploadFile(t, app, "/api/v1/admin/import", cookie, "vault.zip", archive.Bytes())
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, 0, result.Data.Created)
+	assert.Equal(t, 3, result.Data.Skipped)
+
+	// 3. Bad uploads
+	resp, _ = uploadFile(t, app, "/api/v1/admin/import", cookie, "notes.md", []byte("# not a zip"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestImport_BodyLimit(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "limit@test.com")
+	padding := bytes.Repeat([]byte("x"), fiber.DefaultBodyLimit+1)
+
+	// 1. Imports accept archives over the default limit
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, _ := zw.Create("Big.md")
+	w.Write([]byte("# Big\n"))
+	w, _ = zw.CreateHeader(&zip.FileHeader{Name: "attachments/padding.bin", Method: zip.Store})
+	w.Write(padding)
+	zw.Close()
+
+	resp, body := uploadFile(t, app, "/api/v1/admin/import", cookie, "vault.zip", archive.Bytes())
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, body)
+	var count int64
+	database.DB.Model(&models.Note{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 2. Other routes keep the default limit
+	resp, _, err := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]string{"title": "Big", "content": string(padding)}, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestImport_EnexJob(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "enex@test.com")
+
+	export := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<en-export><note><title>Groceries</title><content><![CDATA[<en-note><div><en-todo/>Milk</div></en-note>]]></content>
+<created>20220301T090000Z</created><updated>20220302T090000Z</updated><tag>home</tag></note></en-export>`)
+
+	resp, body := uploadFile(t, app, "/api/v1/admin/import/enex", cookie, "My Notes.enex", export)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var result struct {
+		Data services.ImportJob `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.NotEmpty(t, result.Data.ID)
+	assert.Equal(t, int64(len(export)), result.Data.Size)
+
+	// Poll until the background job finishes
+	jobURL := "/api/v1/admin/import/jobs/" + result.Data.ID
+	for i := 0; i < 100 && result.Data.Status == services.JobRunning; i++ {
+		time.Sleep(10 * time.Millisecond)
+		resp, body, err := testutils.MakeRequest(app, "GET", jobURL, nil, cookie)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	}
+	assert.Equal(t, services.JobDone, result.Data.Status)
+	assert.Equal(t, 1, result.Data.Progress.Created)
+	assert.NotNil(t, result.Data.FinishedAt)
+
+	var note models.Note
+	assert.NoError(t, database.DB.Preload("Notebook").Where("user_id = ? AND title = ?", user.ID, "Groceries").First(&note).Error)
+	assert.Equal(t, "My Notes", note.Notebook.Name)
+	assert.Contains(t, note.Content, `data-type="taskItem"`)
+
+	// Jobs are private to their user
+	_, otherCookie := seedUserAndLogin(app, "other-enex@test.com")
+	resp, _, _ = testutils.MakeRequest(app, "GET", jobURL, nil, otherCookie)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = uploadFile(t, app, "/api/v1/admin/import/enex", cookie, "notes.zip", export)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestImport_EnexLargeUpload(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "large-enex@test.com")
+
+	// Past the default body limit the upload is streamed to disk rather than read into memory
+	padding := strings.Repeat("x", fiber.DefaultBodyLimit)
+	export := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<en-export><note><title>Long read</title><content><![CDATA[<en-note><div>` + padding + `</div></en-note>]]></content>
+<created>20220301T090000Z</created></note></en-export>`)
+
+	resp, body := uploadFile(t, app, "/api/v1/admin/import/enex", cookie, "Archive.enex", export)
+	if !assert.Equal(t, http.StatusAccepted, resp.StatusCode, body) {
+		return
+	}
+	var result struct {
+		Data services.ImportJob `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, int64(len(export)), result.Data.Size)
+
+	for i := 0; i < 200 && result.Data.Status == services.JobRunning; i++ {
+		time.Sleep(10 * time.Millisecond)
+		_, body, err := testutils.MakeRequest(app, "GET", "/api/v1/admin/import/jobs/"+result.Data.ID, nil, cookie)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	}
+	assert.Equal(t, services.JobDone, result.Data.Status)
+	assert.Equal(t, 1, result.Data.Progress.Created)
+
+	var note models.Note
+	assert.NoError(t, database.DB.Where("user_id = ? AND title = ?", user.ID, "Long read").First(&note).Error)
+	assert.Contains(t, note.Content, padding)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var linkService = services.NewLinkService()
+
+// ListNoteLinks returns the [[wiki links]] a note makes, flagging broken ones
+func ListNoteLinks(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	links, err := linkService.OutgoingLinks(c.Params("id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoteNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch links"})
+	}
+
+	return c.JSON(fiber.Map{"data": links})
+}
+
+// ListBacklinks returns the notes linking to a note
+func ListBacklinks(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	notes, err := linkService.Backlinks(c.Params("id"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoteNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch backlinks"})
+	}
+
+	return c.JSON(fiber.Map{"data": notes})
+}
+
+// ListBrokenLinks reports links across all notes that don't resolve
+func ListBrokenLinks(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	links, err := linkService.BrokenLinks(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch broken links"})
+	}
+
+	return c.JSON(fiber.Map{"data": links})
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+// createNoteWithContent creates a note through the API and saves its content
+func createNoteWithContent(app *fiber.App, cookie, title, content string) uint {
+	_, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{"title": title}, cookie)
+	var created map[string]interface{}
+	json.Unmarshal([]byte(body), &created)
+	id := uint(created["data"].(map[string]interface{})["id"].(float64))
+
+	testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{
+		"title": title, "content": content, "status": "DRAFT",
+	}, cookie)
+	return id
+}
+
+func TestLinks_BacklinksBrokenAndRename(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "links@test.com")
+
+	// 1. Hub links to a note that exists, one by slug, and one that doesn't exist yet
+	target := createNoteWithContent(app, cookie, "Compost", "<p>Brown and green.</p>")
+	database.DB.Model(&models.Note{}).Where("id = ?", target).Update("slug", "brown-and-green")
+	var targetNote models.Note
+	database.DB.First(&targetNote, target)
+
+	hub := createNoteWithContent(app, cookie, "Garden Hub",
+		fmt.Sprintf("<p>See [[compost|the heap]], [[%s]] and [[Seed Library]].</p>", targetNote.Slug))
+
+	// 2. Outgoing links
+	resp, body, err := testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes/%d/links", hub), nil, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var links struct {
+		Data []models.NoteLink `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &links)
+	assert.Len(t, links.Data, 3)
+	assert.Equal(t, "the heap", links.Data[0].Label)
+	assert.Equal(t, target, *links.Data[0].TargetNoteID)
+	assert.Equal(t, target, *links.Data[1].TargetNoteID)
+	assert.True(t, links.Data[2].Broken)
+
+	// 3. Backlinks
+	_, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes/%d/backlinks", target), nil, cookie)
+	var backlinks struct {
+		Data []models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &backlinks)
+	assert.Len(t, backlinks.Data, 1)
+	assert.Equal(t, hub, backlinks.Data[0].ID)
+
+	// 4. Broken link report, resolved once the missing note is created
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/links/broken", nil, cookie)
+	var broken struct {
+		Data []models.NoteLink `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &broken)
+	assert.Len(t, broken.Data, 1)
+	assert.Equal(t, "Seed Library", broken.Data[0].Target)
+	assert.Equal(t, "Garden Hub", broken.Data[0].SourceNote.Title)
+
+	createNoteWithContent(app, cookie, "Seed Library", "")
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/links/broken", nil, cookie)
+	json.Unmarshal([]byte(body), &broken)
+	assert.Len(t, broken.Data, 0)
+
+	// 5. Renaming with rewrite_links updates title references but keeps labels and slug links
+	testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/notes/%d", target), map[string]interface{}{
+		"title": "Hot Compost", "content": "<p>Brown and green.</p>", "status": "DRAFT", "rewrite_links": true,
+	}, cookie)
+
+	var hubNote models.Note
+	database.DB.First(&hubNote, hub)
+	assert.Contains(t, hubNote.Content, "[[Hot Compost|the heap]]")
+	assert.Contains(t, hubNote.Content, fmt.Sprintf("[[%s]]", targetNote.Slug))
+
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/links/broken", nil, cookie)
+	json.Unmarshal([]byte(body), &broken)
+	assert.Len(t, broken.Data, 0)
+
+	// 6. Renaming without rewriting breaks title references
+	testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/notes/%d", target), map[string]interface{}{
+		"title": "Cold Compost", "content": "<p>Brown and green.</p>", "status": "DRAFT",
+	}, cookie)
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/links/broken", nil, cookie)
+	json.Unmarshal([]byte(body), &broken)
+	assert.Len(t, broken.Data, 1)
+	assert.Equal(t, "Hot Compost", broken.Data[0].Target)
+}
+
+func TestLinks_BacklinksOfNewNote(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "new-links@test.com")
+
+	hub := createNoteWithContent(app, cookie, "Wildlife", "<p>Frogs live in the [[Pond]].</p>")
+
+	// The note is linked as soon as it is created, without a first edit
+	resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{"title": "Pond"}, cookie)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Data models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &created)
+
+	_, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes/%d/backlinks", created.Data.ID), nil, cookie)
+	var backlinks struct {
+		Data []models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &backlinks)
+	if assert.Len(t, backlinks.Data, 1) {
+		assert.Equal(t, hub, backlinks.Data[0].ID)
+	}
+
+	// Links in the content sent on create are recorded right away
+	_, body, _ = testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{
+		"title": "Frog", "content": "<p>Found in the [[Pond]].</p>",
+	}, cookie)
+	var frog struct {
+		Data models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &frog)
+	_, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes/%d/backlinks", created.Data.ID), nil, cookie)
+	json.Unmarshal([]byte(body), &backlinks)
+	assert.Len(t, backlinks.Data, 2)
+}
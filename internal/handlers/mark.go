@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var markService = services.NewMarkService()
+
+// ToggleNotePin pins a note, or unpins it
+func ToggleNotePin(c *fiber.Ctx) error {
+	return toggleMark(c, markService.ToggleNote, models.MarkPin)
+}
+
+// ToggleNoteFavorite adds a note to the favorites, or removes it
+func ToggleNoteFavorite(c *fiber.Ctx) error {
+	return toggleMark(c, markService.ToggleNote, models.MarkFavorite)
+}
+
+// ToggleNotebookPin pins a notebook, or unpins it
+func ToggleNotebookPin(c *fiber.Ctx) error {
+	return toggleMark(c, markService.ToggleNotebook, models.MarkPin)
+}
+
+// ToggleNotebookFavorite adds a notebook to the favorites, or removes it
+func ToggleNotebookFavorite(c *fiber.Ctx) error {
+	return toggleMark(c, markService.ToggleNotebook, models.MarkFavorite)
+}
+
+func toggleMark(c *fiber.Ctx, toggle func(userID uint, id string, kind string) (*services.MarkState, error), kind string) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	state, err := toggle(userID, c.Params("id"), kind)
+	if errors.Is(err, services.ErrNoteNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Note not found"})
+	}
+	if errors.Is(err, services.ErrNotebookNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Notebook not found"})
+	}
+	if errors.Is(err, services.ErrInvalidMark) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update mark"})
+	}
+
+	return c.JSON(fiber.Map{"data": state})
+}
+
+// ListFavorites returns the user's favorite notes and notebooks
+func ListFavorites(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	favorites, err := markService.Favorites(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch favorites"})
+	}
+
+	for i := range favorites.Notes {
+		if err := convertContent(&favorites.Notes[i], format); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
+		}
+	}
+	return c.JSON(fiber.Map{"data": favorites})
+}
@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestMarks_PinsAndFavorites(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "marks@test.com")
+	other, otherCookie := seedUserAndLogin(app, "marks-other@test.com")
+
+	base := time.Now().Add(-time.Hour)
+	notes := map[string]*models.Note{}
+	for i, title := range []string{"Alpha", "Bravo", "Charlie", "Delta"} {
+		note := &models.Note{UserID: user.ID, Title: title, Slug: fmt.Sprintf("marks-%d", i)}
+		database.DB.Create(note)
+		database.DB.Model(note).UpdateColumn("updated_at", base.Add(time.Duration(i)*time.Minute))
+		notes[title] = note
+	}
+	notebook := models.Notebook{UserID: user.ID, Name: "Reference", Slug: "reference"}
+	database.DB.Create(&notebook)
+	foreign := models.Note{UserID: other.ID, Title: "Foreign", Slug: "foreign"}
+	database.DB.Create(&foreign)
+
+	toggle := func(url, sessionCookie string) (int, services.MarkState) {
+		resp, body, _ := testutils.MakeRequest(app, "POST", url, nil, sessionCookie)
+		var result struct {
+			Data services.MarkState `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &result)
+		return resp.StatusCode, result.Data
+	}
+	titles := func(url string) []string {
+		_, body, _ := testutils.MakeRequest(app, "GET", url, nil, cookie)
+		var page struct {
+			Data []models.Note `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &page)
+		result := []string{}
+		for _, note := range page.Data {
+			result = append(result, note.Title)
+		}
+		return result
+	}
+
+	// Toggling flips the mark and reports both
+	status, state := toggle(fmt.Sprintf("/api/v1/admin/notes/%d/pin", notes["Alpha"].ID), cookie)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, services.MarkState{Pinned: true}, state)
+	_, state = toggle(fmt.Sprintf("/api/v1/admin/notes/%d/favorite", notes["Alpha"].ID), cookie)
+	assert.Equal(t, services.MarkState{Pinned: true, Favorite: true}, state)
+	toggle(fmt.Sprintf("/api/v1/admin/notes/%d/pin", notes["Bravo"].ID), cookie)
+	toggle(fmt.Sprintf("/api/v1/admin/notes/%d/pin", notes["Charlie"].ID), cookie)
+	_, state = toggle(fmt.Sprintf("/api/v1/admin/notes/%d/pin", notes["Charlie"].ID), cookie)
+	assert.Equal(t, services.MarkState{}, state)
+
+	// Marks are private to their owner
+	status, _ = toggle(fmt.Sprintf("/api/v1/admin/notes/%d/pin", foreign.ID), cookie)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = toggle(fmt.Sprintf("/api/v1/admin/notebooks/%d/favorite", notebook.ID), otherCookie)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// Pinned notes lead the listing, in the requested order
+	assert.Equal(t, []string{"Delta", "Charlie", "Bravo", "Alpha"}, titles("/api/v1/admin/notes"))
+	assert.Equal(t, []string{"Bravo", "Alpha", "Delta", "Charlie"}, titles("/api/v1/admin/notes?pinned_first=true"))
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie", "Delta"}, titles("/api/v1/admin/notes?pinned_first=true&sort=title&direction=asc"))
+
+	// ... counting against the page limit, with the rest following through the cursor
+	pages := func(limit int) [][]string {
+		var result [][]string
+		cursor := ""
+		for i := 0; i < 10; i++ {
+			_, body, _ := testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes?pinned_first=true&limit=%d&total=true&cursor=%s", limit, cursor), nil, cookie)
+			var page struct {
+				Data       []models.Note `json:"data"`
+				NextCursor string        `json:"next_cursor"`
+				Total      int64         `json:"total"`
+			}
+			json.Unmarshal([]byte(body), &page)
+			assert.Equal(t, int64(4), page.Total)
+			titles := []string{}
+			for _, note := range page.Data {
+				titles = append(titles, note.Title)
+			}
+			result = append(result, titles)
+			if cursor = page.NextCursor; cursor == "" {
+				break
+			}
+		}
+		return result
+	}
+	assert.Equal(t, [][]string{{"Bravo"}, {"Alpha"}, {"Delta"}, {"Charlie"}}, pages(1))
+	assert.Equal(t, [][]string{{"Bravo", "Alpha"}, {"Delta", "Charlie"}}, pages(2))
+	assert.Equal(t, [][]string{{"Bravo", "Alpha", "Delta"}, {"Charlie"}}, pages(3))
+
+	_, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?pinned_first=true&limit=3", nil, cookie)
+	var page struct {
+		Data []models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &page)
+	assert.True(t, page.Data[0].Pinned)
+	assert.True(t, page.Data[1].Favorite)
+	assert.False(t, page.Data[2].Pinned)
+
+	// Favorites list notes and notebooks, most recent first, without trashed ones
+	status, state = toggle(fmt.Sprintf("/api/v1/admin/notebooks/%d/favorite", notebook.ID), cookie)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, state.Favorite)
+	toggle(fmt.Sprintf("/api/v1/admin/notes/%d/favorite", notes["Delta"].ID), cookie)
+	database.DB.Delete(notes["Delta"])
+
+	resp, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/favorites", nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var favorites struct {
+		Data struct {
+			Notes     []models.Note     `json:"notes"`
+			Notebooks []models.Notebook `json:"notebooks"`
+		} `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &favorites)
+	if assert.Len(t, favorites.Data.Notes, 1) {
+		assert.Equal(t, "Alpha", favorites.Data.Notes[0].Title)
+		assert.True(t, favorites.Data.Notes[0].Pinned)
+	}
+	if assert.Len(t, favorites.Data.Notebooks, 1) {
+		assert.True(t, favorites.Data.Notebooks[0].Favorite)
+	}
+
+	// The other user sees no favorites, and purging drops the marks
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/favorites", nil, otherCookie)
+	assert.Contains(t, body, `"notes":[]`)
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/trash/notes/%d", notes["Delta"].ID), nil, cookie)
+	var marks int64
+	database.DB.Model(&models.Mark{}).Where("item_type = ? AND item_id = ?", models.MarkItemNote, notes["Delta"].ID).Count(&marks)
+	assert.Equal(t, int64(0), marks)
+}
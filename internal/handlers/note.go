@@ -1,18 +1,21 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/models"
 	"github.com/tarakreasi/taraNote_go/internal/services"
 )
 
 var noteService = services.NewNoteService()
 
-// ListNotes returns all notes
+// ListNotes returns a page of notes (see pageRequestFromQuery for paging and sorting)
 func ListNotes(c *fiber.Ctx) error {
 	sess, _ := config.Store.Get(c)
 	userID := sess.Get("user_id")
@@ -20,7 +23,9 @@ func ListNotes(c *fiber.Ctx) error {
 	// Parse Query Params
 	query := c.Query("search")
 	status := c.Query("status")
+	tag := c.Query("tag")
 	notebookIDStr := c.Query("notebook_id")
+	templateStr := c.Query("template")
 
 	var notebookID *uint
 	if notebookIDStr != "" {
@@ -31,18 +36,81 @@ func ListNotes(c *fiber.Ctx) error {
 		}
 	}
 
+	var template *bool
+	if templateStr != "" {
+		value := c.QueryBool("template")
+		template = &value
+	}
+
 	filter := services.NoteFilter{
-		Search:     query,
-		Status:     status,
-		NotebookID: notebookID,
+		Search:      query,
+		Status:      status,
+		NotebookID:  notebookID,
+		Tag:         tag,
+		Template:    template,
+		PinnedFirst: c.QueryBool("pinned_first"),
 	}
 
-	notes, err := noteService.ListNotes(userID.(uint), filter)
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	notes, page, err := noteService.ListNotes(userID.(uint), filter, pageRequestFromQuery(c))
+	if isPageError(err) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch notes"})
 	}
 
-	return c.JSON(fiber.Map{"data": notes})
+	for i := range notes {
+		if err := convertContent(&notes[i], format); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
+		}
+	}
+	return pageResponse(c, notes, page)
+}
+
+// ShowNote returns a single note; ?format=markdown returns its content as Markdown
+func ShowNote(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	note, err := noteService.GetNote(c.Params("id"), userID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := convertContent(note, format); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
+	}
+
+	setVersionETag(c, note.Version)
+	return c.JSON(fiber.Map{"data": note})
+}
+
+// contentFormat reads ?format=html|markdown, the format of the content sent and returned
+func contentFormat(c *fiber.Ctx) (string, error) {
+	format := c.Query("format", models.ContentFormatHTML)
+	if !services.ValidContentFormat(format) {
+		return "", services.ErrInvalidFormat
+	}
+	return format, nil
+}
+
+// convertContent replaces a note's content with the requested format for the response
+func convertContent(note *models.Note, format string) error {
+	content, err := services.NoteContentAs(note, format)
+	if err != nil {
+		return err
+	}
+	note.Content = content
+	return nil
 }
 
 // CreateNote creates a new draft note
@@ -51,8 +119,12 @@ func CreateNote(c *fiber.Ctx) error {
 	userID := sess.Get("user_id").(uint)
 
 	type Request struct {
-		Title      string `json:"title"`
-		NotebookID *uint  `json:"notebook_id"`
+		Title      string   `json:"title"`
+		Slug       string   `json:"slug"`
+		Content    string   `json:"content"`
+		NotebookID *uint    `json:"notebook_id"`
+		Tags       []string `json:"tags"`
+		IsTemplate bool     `json:"is_template"`
 	}
 
 	req := new(Request)
@@ -60,32 +132,52 @@ func CreateNote(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
 	}
 
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	note, err := noteService.CreateNote(services.CreateNoteRequest{
-		UserID:     userID,
-		Title:      req.Title,
-		NotebookID: req.NotebookID,
+		UserID:        userID,
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Content:       req.Content,
+		ContentFormat: format,
+		NotebookID:    req.NotebookID,
+		Tags:          req.Tags,
+		IsTemplate:    req.IsTemplate,
 	})
 
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
+	if err := convertContent(note, format); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
+	}
 
+	setVersionETag(c, note.Version)
 	return c.Status(201).JSON(fiber.Map{"data": note})
 }
 
-// UpdateNote updates a note content
+// UpdateNote replaces a note's editable fields (send If-Match to guard against stale writes)
 func UpdateNote(c *fiber.Ctx) error {
 	sess, _ := config.Store.Get(c)
 	userID := sess.Get("user_id").(uint)
 	id := c.Params("id")
 
 	type Request struct {
-		Title      string `json:"title"`
-		Content    string `json:"content"`
-		Excerpt    string `json:"excerpt"`
-		NotebookID *uint  `json:"notebook_id"`
-		Status     string `json:"status"`
-		IsFeatured bool   `json:"is_featured"`
+		Title        string     `json:"title"`
+		Slug         string     `json:"slug"`
+		Content      string     `json:"content"`
+		Excerpt      string     `json:"excerpt"`
+		NotebookID   *uint      `json:"notebook_id"`
+		Status       string     `json:"status"`
+		IsFeatured   bool       `json:"is_featured"`
+		IsTemplate   *bool      `json:"is_template"`
+		Tags         []string   `json:"tags"`
+		RewriteLinks bool       `json:"rewrite_links"`
+		PublishAt    *time.Time `json:"publish_at"`
+		UnpublishAt  *time.Time `json:"unpublish_at"`
 	}
 
 	req := new(Request)
@@ -93,28 +185,114 @@ func UpdateNote(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	note, err := noteService.UpdateNote(services.UpdateNoteRequest{
-		ID:         id,
-		UserID:     userID,
-		Title:      req.Title,
-		Content:    req.Content,
-		Excerpt:    req.Excerpt,
-		NotebookID: req.NotebookID,
-		Status:     req.Status,
-		IsFeatured: req.IsFeatured,
+		ID:            id,
+		UserID:        userID,
+		Version:       version,
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Content:       req.Content,
+		Excerpt:       req.Excerpt,
+		NotebookID:    req.NotebookID,
+		Status:        req.Status,
+		IsFeatured:    req.IsFeatured,
+		IsTemplate:    req.IsTemplate,
+		Tags:          req.Tags,
+		RewriteLinks:  req.RewriteLinks,
+		PublishAt:     req.PublishAt,
+		UnpublishAt:   req.UnpublishAt,
+		ContentFormat: format,
 	})
+	return noteSaveResponse(c, note, format, err)
+}
+
+// PatchNote updates only the fields present in the body, e.g. {"content": "..."} from autosave
+func PatchNote(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+	id := c.Params("id")
 
+	type Request struct {
+		Title        *string                      `json:"title"`
+		Slug         *string                      `json:"slug"`
+		Content      *string                      `json:"content"`
+		Excerpt      *string                      `json:"excerpt"`
+		NotebookID   services.Optional[uint]      `json:"notebook_id"`
+		Status       *string                      `json:"status"`
+		IsFeatured   *bool                        `json:"is_featured"`
+		IsTemplate   *bool                        `json:"is_template"`
+		Tags         []string                     `json:"tags"`
+		RewriteLinks bool                         `json:"rewrite_links"`
+		PublishAt    services.Optional[time.Time] `json:"publish_at"`
+		UnpublishAt  services.Optional[time.Time] `json:"unpublish_at"`
+	}
+
+	req := new(Request)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	format, err := contentFormat(c)
 	if err != nil {
-		status := 500
-		if err.Error() == "note not found or unauthorized" {
-			status = 404
-		} else {
-			// Validation errors, etc
-			status = 400
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	note, err := noteService.PatchNote(services.PatchNoteRequest{
+		ID:            id,
+		UserID:        userID,
+		Version:       version,
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Content:       req.Content,
+		Excerpt:       req.Excerpt,
+		NotebookID:    req.NotebookID,
+		Status:        req.Status,
+		IsFeatured:    req.IsFeatured,
+		IsTemplate:    req.IsTemplate,
+		Tags:          req.Tags,
+		RewriteLinks:  req.RewriteLinks,
+		PublishAt:     req.PublishAt,
+		UnpublishAt:   req.UnpublishAt,
+		ContentFormat: &format,
+	})
+	return noteSaveResponse(c, note, format, err)
+}
+
+// noteSaveResponse maps update results; a stale If-Match gets 409 with the server copy
+func noteSaveResponse(c *fiber.Ctx, note *models.Note, format string, err error) error {
+	if errors.Is(err, services.ErrVersionConflict) {
+		setVersionETag(c, note.Version)
+		if err := convertContent(note, format); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
 		}
-		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(409).JSON(fiber.Map{"error": err.Error(), "data": note})
+	}
+	if errors.Is(err, services.ErrNoteNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		// Validation errors, etc
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := convertContent(note, format); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
 	}
 
+	setVersionETag(c, note.Version)
 	return c.JSON(fiber.Map{"data": note})
 }
 
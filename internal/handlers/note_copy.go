@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+// noteCopyRequest is the optional body of the duplicate and instantiate endpoints
+type noteCopyRequest struct {
+	Title      string                  `json:"title"`
+	NotebookID services.Optional[uint] `json:"notebook_id"`
+}
+
+func parseNoteCopyRequest(c *fiber.Ctx) (*noteCopyRequest, error) {
+	req := new(noteCopyRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// DuplicateNote copies a note into a new draft, optionally into another notebook
+func DuplicateNote(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	req, err := parseNoteCopyRequest(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	note, err := noteService.DuplicateNote(services.DuplicateNoteRequest{
+		ID:         c.Params("id"),
+		UserID:     userID,
+		Title:      req.Title,
+		NotebookID: req.NotebookID,
+	})
+	return noteCopyResponse(c, note, format, err)
+}
+
+// InstantiateTemplate creates a new draft from a template note with its placeholders expanded
+func InstantiateTemplate(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	req, err := parseNoteCopyRequest(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+	format, err := contentFormat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	note, err := noteService.InstantiateTemplate(services.InstantiateTemplateRequest{
+		ID:         c.Params("id"),
+		UserID:     userID,
+		Title:      req.Title,
+		NotebookID: req.NotebookID,
+	})
+	return noteCopyResponse(c, note, format, err)
+}
+
+func noteCopyResponse(c *fiber.Ctx, note *models.Note, format string, err error) error {
+	if errors.Is(err, services.ErrNoteNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Note not found"})
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.Is(err, services.ErrNotATemplate) || errors.Is(err, services.ErrInvalidNoteNotebook) || errors.As(err, &validationErrors) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to copy note"})
+	}
+	if err := convertContent(note, format); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to convert note content"})
+	}
+
+	setVersionETag(c, note.Version)
+	return c.Status(201).JSON(fiber.Map{"data": note})
+}
@@ -0,0 +1,184 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestNoteCopy_Duplicate(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "copy@test.com")
+	_, otherCookie := seedUserAndLogin(app, "copy-other@test.com")
+	work := models.Notebook{UserID: user.ID, Name: "Work", Slug: "work"}
+	archive := models.Notebook{UserID: user.ID, Name: "Archive", Slug: "archive"}
+	database.DB.Create(&work)
+	database.DB.Create(&archive)
+
+	_, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{
+		"title":       "Recipe",
+		"content":     "<p>Flour and {{date}}</p>",
+		"notebook_id": work.ID,
+		"tags":        []string{"Baking"},
+	}, cookie)
+	var created struct {
+		Data models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &created)
+	original := created.Data
+	database.DB.Model(&models.Note{}).Where("id = ?", original.ID).Updates(map[string]interface{}{"status": models.NoteStatusPublished, "views": 12})
+
+	duplicate := func(id uint, payload interface{}, sessionCookie string) (*http.Response, models.Note) {
+		resp, body, _ := testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/notes/%d/duplicate", id), payload, sessionCookie)
+		var result struct {
+			Data models.Note `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &result)
+		return resp, result.Data
+	}
+
+	// The copy is a new draft in the same notebook; placeholders are copied as they are
+	resp, copied := duplicate(original.ID, nil, cookie)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NotEqual(t, original.ID, copied.ID)
+	assert.Equal(t, "Recipe (copy)", copied.Title)
+	assert.Equal(t, "recipe-copy", copied.Slug)
+	assert.Equal(t, models.NoteStatusDraft, copied.Status)
+	assert.Equal(t, 0, copied.Views)
+	assert.Equal(t, "<p>Flour and {{date}}</p>", copied.Content)
+	assert.Equal(t, uint(1), copied.Version)
+	if assert.NotNil(t, copied.NotebookID) {
+		assert.Equal(t, work.ID, *copied.NotebookID)
+	}
+	if assert.Len(t, copied.Tags, 1) {
+		assert.Equal(t, "Baking", copied.Tags[0].Name)
+	}
+
+	// Another title and notebook; the slug stays unique
+	resp, copied = duplicate(original.ID, map[string]interface{}{"title": "Recipe", "notebook_id": archive.ID}, cookie)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "recipe-1", copied.Slug)
+	if assert.NotNil(t, copied.NotebookID) {
+		assert.Equal(t, archive.ID, *copied.NotebookID)
+	}
+
+	// null takes the copy out of notebooks
+	_, copied = duplicate(original.ID, map[string]interface{}{"notebook_id": nil}, cookie)
+	assert.Nil(t, copied.NotebookID)
+
+	// Other users' notes and notebooks are off limits
+	resp, _ = duplicate(original.ID, nil, otherCookie)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	foreign := models.Notebook{UserID: user.ID + 1, Name: "Theirs", Slug: "theirs"}
+	database.DB.Create(&foreign)
+	resp, _ = duplicate(original.ID, map[string]interface{}{"notebook_id": foreign.ID}, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// The original is untouched
+	var stored models.Note
+	database.DB.First(&stored, original.ID)
+	assert.Equal(t, models.NoteStatusPublished, stored.Status)
+	assert.Equal(t, "recipe", stored.Slug)
+}
+
+func TestNoteCopy_Templates(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "templates@test.com")
+	database.DB.Model(&user).Updates(map[string]interface{}{"name": "Tara & Co", "username": "tara"})
+	meetings := models.Notebook{UserID: user.ID, Name: "Meetings", Slug: "meetings"}
+	database.DB.Create(&meetings)
+
+	create := func(payload map[string]interface{}, format string) models.Note {
+		_, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes?format="+format, payload, cookie)
+		var result struct {
+			Data models.Note `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &result)
+		return result.Data
+	}
+	instantiate := func(id uint, payload interface{}) (*http.Response, models.Note) {
+		resp, body, _ := testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/notes/%d/instantiate", id), payload, cookie)
+		var result struct {
+			Data models.Note `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &result)
+		return resp, result.Data
+	}
+
+	template := create(map[string]interface{}{
+		"title":       "Standup {{date}}",
+		"content":     "<h1>{{ title }}</h1><p>By {{user.name}} (@{{user.username}}) in {{notebook}}, {{unknown}}</p>",
+		"notebook_id": meetings.ID,
+		"is_template": true,
+	}, "html")
+	assert.True(t, template.IsTemplate)
+	plain := create(map[string]interface{}{"title": "Plain"}, "html")
+
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	// Placeholders expand in the title and content; values are escaped in HTML
+	resp, note := instantiate(template.ID, nil)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "Standup "+today, note.Title)
+	assert.Equal(t, "standup-"+today, note.Slug)
+	assert.Equal(t, "<h1>Standup "+today+"</h1><p>By Tara &amp; Co (@tara) in Meetings, {{unknown}}</p>", note.Content)
+	assert.Equal(t, models.NoteStatusDraft, note.Status)
+	assert.False(t, note.IsTemplate)
+	if assert.NotNil(t, note.NotebookID) {
+		assert.Equal(t, meetings.ID, *note.NotebookID)
+	}
+
+	// A given title is used as {{title}}, and the notebook can be changed
+	resp, note = instantiate(template.ID, map[string]interface{}{"title": "Retro", "notebook_id": nil})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "Retro", note.Title)
+	assert.Equal(t, "<h1>Retro</h1><p>By Tara &amp; Co (@tara) in , {{unknown}}</p>", note.Content)
+	assert.Nil(t, note.NotebookID)
+
+	// Markdown templates expand in their source
+	markdown := create(map[string]interface{}{
+		"title":       "Journal",
+		"content":     "# {{title}}\n\nWritten on {{date}} by {{user.name}}\n",
+		"is_template": true,
+	}, "markdown")
+	_, body, _ := testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/notes/%d/instantiate?format=markdown", markdown.ID), nil, cookie)
+	var result struct {
+		Data models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &result)
+	assert.Equal(t, models.ContentFormatMarkdown, result.Data.ContentFormat)
+	assert.Equal(t, "# Journal\n\nWritten on "+today+" by Tara & Co\n", result.Data.Content)
+
+	// Only templates can be instantiated, into the user's own notebooks
+	resp, _ = instantiate(plain.ID, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = instantiate(template.ID, map[string]interface{}{"notebook_id": 9999})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Templates can be listed on their own, and the flag survives a PUT that leaves it out
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?template=true", nil, cookie)
+	var page struct {
+		Data []models.Note `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &page)
+	assert.Len(t, page.Data, 2)
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?template=false", nil, cookie)
+	json.Unmarshal([]byte(body), &page)
+	assert.Len(t, page.Data, 4)
+
+	testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/notes/%d", template.ID), map[string]interface{}{"title": "Standup {{date}}"}, cookie)
+	var stored models.Note
+	database.DB.First(&stored, template.ID)
+	assert.True(t, stored.IsTemplate)
+}
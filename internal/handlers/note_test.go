@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
 	"github.com/tarakreasi/taraNote_go/internal/testutils"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -103,3 +108,425 @@ func TestNote_CRUD(t *testing.T) {
 	listData3 := listResult["data"].([]interface{})
 	assert.Len(t, listData3, 0)
 }
+
+func TestNote_SchedulePublishing(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "schedule@test.com")
+	note := models.Note{UserID: user.ID, Title: "Later", Slug: "later"}
+	database.DB.Create(&note)
+	notePath := fmt.Sprintf("/api/v1/admin/notes/%d", note.ID)
+
+	// 1. A future publish_at schedules the note and hides it from the public
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	resp, _, err := testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+		"title": "Later", "status": "PUBLISHED", "publish_at": publishAt,
+	}, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	database.DB.First(&note, note.ID)
+	assert.Equal(t, models.NoteStatusScheduled, note.Status)
+	assert.True(t, note.PublishAt.Equal(publishAt))
+	assert.Nil(t, note.PublishedAt)
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/articles/later", nil, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// 2. SCHEDULED without a publish time is rejected
+	resp, _, _ = testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{"title": "Later", "status": "SCHEDULED"}, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// 3. unpublish_at must come after the publish time
+	resp, _, _ = testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+		"title": "Later", "status": "SCHEDULED", "publish_at": publishAt, "unpublish_at": publishAt.Add(-time.Minute),
+	}, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// 4. Publishing immediately stamps published_at
+	resp, _, _ = testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{"title": "Later", "status": "PUBLISHED"}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var published models.Note
+	database.DB.First(&published, note.ID)
+	assert.Equal(t, models.NoteStatusPublished, published.Status)
+	assert.NotNil(t, published.PublishedAt)
+	assert.Nil(t, published.PublishAt)
+}
+
+func TestNote_SlugsAndRedirects(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "slugs@test.com")
+
+	// 1. Slugs come from the title and collisions get a numeric suffix
+	first := createNoteWithContent(app, cookie, "Hello World!", "<p>one</p>")
+	second := createNoteWithContent(app, cookie, "Hello World", "<p>two</p>")
+	var firstNote, secondNote models.Note
+	database.DB.First(&firstNote, first)
+	database.DB.First(&secondNote, second)
+	assert.Equal(t, "hello-world", firstNote.Slug)
+	assert.Equal(t, "hello-world-1", secondNote.Slug)
+
+	// 2. Editing the slug keeps the old one for redirects
+	notePath := fmt.Sprintf("/api/v1/admin/notes/%d", first)
+	resp, _, err := testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+		"title": "Hello World!", "slug": "Greetings Everyone", "status": "PUBLISHED",
+	}, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var renamed models.Note
+	database.DB.First(&renamed, first)
+	assert.Equal(t, "greetings-everyone", renamed.Slug)
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/articles/hello-world", nil, "")
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "/articles/greetings-everyone", resp.Header.Get("Location"))
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/articles/greetings-everyone", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// 3. A freed slug can be taken by another note, which ends the redirect
+	testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/notes/%d", second), map[string]interface{}{
+		"title": "Hello World", "slug": "hello-world", "status": "PUBLISHED",
+	}, cookie)
+	var count int64
+	database.DB.Model(&models.SlugHistory{}).Where("slug = ?", "hello-world").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/articles/hello-world", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/articles/hello-world-1", nil, "")
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "/articles/hello-world", resp.Header.Get("Location"))
+}
+
+func TestNote_PatchAndOptimisticConcurrency(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "patch@test.com")
+	notebook := models.Notebook{UserID: user.ID, Name: "Inbox", Slug: "inbox"}
+	database.DB.Create(&notebook)
+
+	id := createNoteWithContent(app, cookie, "Keep Me", "<p>v1</p>")
+	notePath := fmt.Sprintf("/api/v1/admin/notes/%d", id)
+	testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+		"title": "Keep Me", "content": "<p>v1</p>", "status": "PUBLISHED", "is_featured": true, "notebook_id": notebook.ID,
+	}, cookie)
+
+	// 1. PATCH only touches the fields it sends
+	resp, body, err := testutils.MakeRequest(app, "PATCH", notePath, map[string]interface{}{"content": "<p>v2</p>"}, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result map[string]interface{}
+	json.Unmarshal([]byte(body), &result)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, "Keep Me", data["title"])
+	assert.Equal(t, "<p>v2</p>", data["content"])
+	assert.Equal(t, "PUBLISHED", data["status"])
+	assert.Equal(t, true, data["is_featured"])
+	assert.Equal(t, float64(notebook.ID), data["notebook_id"])
+
+	version := uint(data["version"].(float64))
+	assert.Equal(t, fmt.Sprintf(`"%d"`, version), resp.Header.Get("ETag"))
+
+	// 2. An explicit null clears a nullable field
+	resp, body, _ = testutils.MakeRequest(app, "PATCH", notePath, map[string]interface{}{"notebook_id": nil}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	json.Unmarshal([]byte(body), &result)
+	assert.Nil(t, result["data"].(map[string]interface{})["notebook_id"])
+
+	// 3. A write carrying the current version succeeds and bumps it
+	current := fmt.Sprintf(`"%d"`, version+1)
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "PATCH", notePath, map[string]interface{}{"title": "Tab A"}, cookie,
+		map[string]string{"If-Match": current})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf(`"%d"`, version+2), resp.Header.Get("ETag"))
+
+	// 4. The other tab still holds the old version and gets 409 with the server copy
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "PUT", notePath, map[string]interface{}{
+		"title": "Tab B", "status": "DRAFT",
+	}, cookie, map[string]string{"If-Match": current})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	json.Unmarshal([]byte(body), &result)
+	assert.Equal(t, "Tab A", result["data"].(map[string]interface{})["title"])
+
+	var stored models.Note
+	database.DB.First(&stored, id)
+	assert.Equal(t, "Tab A", stored.Title)
+	assert.Equal(t, version+2, stored.Version)
+
+	// 5. Malformed If-Match and invalid values are rejected
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "PATCH", notePath, map[string]interface{}{"title": "x"}, cookie,
+		map[string]string{"If-Match": "not-a-version"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "PATCH", notePath, map[string]interface{}{"title": ""}, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// listAllPages follows next_cursor until the last page and returns the titles in order
+func listAllPages(t *testing.T, app *fiber.App, cookie, query string) ([]string, int) {
+	titles := []string{}
+	cursor := ""
+	pages := 0
+	for {
+		path := "/api/v1/admin/notes?" + query
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		resp, body, _ := testutils.MakeRequest(app, "GET", path, nil, cookie)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var page struct {
+			Data       []models.Note `json:"data"`
+			NextCursor *string       `json:"next_cursor"`
+		}
+		json.Unmarshal([]byte(body), &page)
+		for _, note := range page.Data {
+			titles = append(titles, note.Title)
+		}
+		pages++
+		if page.NextCursor == nil || pages > 20 {
+			return titles, pages
+		}
+		cursor = *page.NextCursor
+	}
+}
+
+func TestNote_ListPaginationAndSorting(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "pages@test.com")
+
+	// Views and publish dates vary; two notes are never published and two share a view count
+	published := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	seed := []struct {
+		title     string
+		views     int
+		published *time.Time
+	}{
+		{"delta", 5, nil}, {"Alpha", 40, &published}, {"echo", 5, nil},
+		{"Charlie", 10, nil}, {"bravo", 20, nil}, {"golf", 1, nil}, {"Foxtrot", 0, nil},
+	}
+	for i, s := range seed {
+		note := models.Note{UserID: user.ID, Title: s.title, Slug: s.title, Views: s.views, PublishedAt: s.published}
+		if s.published == nil && i%2 == 1 {
+			at := published.Add(time.Duration(i) * time.Hour)
+			note.PublishedAt = &at
+		}
+		database.DB.Create(&note)
+	}
+
+	// 1. Titles sort case-insensitively across pages
+	titles, pages := listAllPages(t, app, cookie, "limit=3&sort=title&direction=asc")
+	assert.Equal(t, []string{"Alpha", "bravo", "Charlie", "delta", "echo", "Foxtrot", "golf"}, titles)
+	assert.Equal(t, 3, pages)
+
+	// 2. Ties on the sort key are broken by id so no row is skipped or repeated
+	titles, _ = listAllPages(t, app, cookie, "limit=2&sort=views&direction=desc")
+	assert.Equal(t, []string{"Alpha", "bravo", "Charlie", "echo", "delta", "golf", "Foxtrot"}, titles)
+
+	// 3. Unpublished notes come last when sorting by published_at desc
+	titles, _ = listAllPages(t, app, cookie, "limit=2&sort=published_at")
+	assert.Equal(t, []string{"golf", "Charlie", "Alpha", "Foxtrot", "bravo", "echo", "delta"}, titles)
+
+	// 4. Default order is updated_at desc, total is opt-in
+	resp, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?limit=4&total=true", nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var result map[string]interface{}
+	json.Unmarshal([]byte(body), &result)
+	assert.Len(t, result["data"], 4)
+	assert.Equal(t, float64(7), result["total"])
+	assert.Equal(t, "Foxtrot", result["data"].([]interface{})[0].(map[string]interface{})["title"])
+
+	// 5. Cursors are tied to their ordering; bad input is a 400
+	next := result["next_cursor"].(string)
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?sort=title&cursor="+url.QueryEscape(next), nil, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?cursor=garbage", nil, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?sort=content", nil, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestNote_ContentIsSanitized(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "xss@test.com")
+
+	// 1. Script and event handlers are stripped on write, Tiptap markup survives
+	id := createNoteWithContent(app, cookie, "Unsafe", "")
+	resp, _, _ := testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{
+		"status":  "PUBLISHED",
+		"content": `<h2>Hi</h2><p onclick="steal()">text<script>steal()</script></p><img src=x onerror="steal()"><a href="javascript:steal()">link</a>`,
+	}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stored models.Note
+	database.DB.First(&stored, id)
+	assert.Equal(t, `<h2>Hi</h2><p>text</p><img src="x">link`, stored.Content)
+
+	// 2. The clean-up command fixes content stored before sanitization existed
+	legacy := models.Note{UserID: user.ID, Title: "Legacy", Slug: "legacy", Status: "PUBLISHED", Content: `<p>old<script>steal()</script></p>`}
+	database.DB.Create(&legacy)
+
+	report, err := services.NewSanitizeService().CleanExisting(true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{legacy.ID}, report.Notes)
+
+	_, body, _ := testutils.MakeRequest(app, "GET", "/articles/legacy", nil, "")
+	assert.Contains(t, body, "steal()")
+
+	_, err = services.NewSanitizeService().CleanExisting(false, false)
+	assert.NoError(t, err)
+	var cleaned models.Note
+	database.DB.First(&cleaned, legacy.ID)
+	assert.Equal(t, "<p>old</p>", cleaned.Content)
+
+	_, body, _ = testutils.MakeRequest(app, "GET", "/articles/legacy", nil, "")
+	assert.NotContains(t, body, "steal()")
+}
+
+func TestNote_MarkdownFormat(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "md@test.com")
+
+	// 1. Create in Markdown: the source is kept and Content holds the rendered HTML
+	source := "# Plan\n\n- [x] Buy seeds\n- [ ] Plant them\n\n| Bed | Crop |\n| --- | --- |\n| North | Beans |\n\n![Bed](/public/uploads/bed.jpg)\n"
+	resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes?format=markdown", map[string]interface{}{
+		"title":   "Garden",
+		"content": source,
+	}, cookie)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		Data struct {
+			ID            uint   `json:"id"`
+			Content       string `json:"content"`
+			ContentFormat string `json:"content_format"`
+		} `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &created)
+	assert.Equal(t, source, created.Data.Content)
+	assert.Equal(t, "markdown", created.Data.ContentFormat)
+
+	var stored models.Note
+	database.DB.First(&stored, created.Data.ID)
+	assert.Equal(t, source, stored.Markdown)
+	assert.Contains(t, stored.Content, `<li data-type="taskItem" data-checked="true">`)
+	assert.Contains(t, stored.Content, `<label><input type="checkbox" checked="checked"><span></span></label>Buy seeds`)
+	assert.Contains(t, stored.Content, `<td>Beans</td>`)
+
+	// 2. Without ?format the API serves HTML
+	var shown struct {
+		Data models.Note `json:"data"`
+	}
+	_, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes/%d", created.Data.ID), nil, cookie)
+	json.Unmarshal([]byte(body), &shown)
+	assert.Equal(t, stored.Content, shown.Data.Content)
+
+	// 3. HTML notes are converted when Markdown is requested
+	id := createNoteWithContent(app, cookie, "Html note", "<h2>Steps</h2><pre><code class=\"language-go\">go run .</code></pre>")
+	_, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes/%d?format=markdown", id), nil, cookie)
+	shown.Data = models.Note{}
+	json.Unmarshal([]byte(body), &shown)
+	assert.Equal(t, "## Steps\n\n```go\ngo run .\n```\n", shown.Data.Content)
+	assert.Equal(t, "html", shown.Data.ContentFormat)
+
+	// 4. Saving HTML (the editor) switches the note back to HTML
+	resp, _, _ = testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", created.Data.ID), map[string]interface{}{
+		"content": "<p>Edited in the editor</p>",
+	}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var edited models.Note
+	database.DB.First(&edited, created.Data.ID)
+	assert.Equal(t, "html", edited.ContentFormat)
+	assert.Empty(t, edited.Markdown)
+
+	// 5. Unknown formats are rejected
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?format=rtf", nil, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestNote_ExcerptWordCountAndReadingTime(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "stats@test.com")
+
+	// 1. Without an excerpt one is derived from the content
+	long := strings.Repeat("word ", 450)
+	id := createNoteWithContent(app, cookie, "Long read", "<h2>Intro</h2><p>"+long+"</p>")
+
+	var note models.Note
+	database.DB.First(&note, id)
+	assert.True(t, note.AutoExcerpt)
+	assert.True(t, strings.HasPrefix(note.Excerpt, "Intro word word"))
+	assert.True(t, strings.HasSuffix(note.Excerpt, "…"))
+	assert.LessOrEqual(t, len([]rune(note.Excerpt)), services.ExcerptLength+1)
+	assert.Equal(t, 451, note.WordCount)
+	assert.Equal(t, 3, note.ReadingTime)
+
+	// 2. The derived excerpt follows content edits
+	testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{
+		"content": "<p>Short and sweet.</p>",
+	}, cookie)
+	note = models.Note{}
+	database.DB.First(&note, id)
+	assert.Equal(t, "Short and sweet.", note.Excerpt)
+	assert.Equal(t, 3, note.WordCount)
+	assert.Equal(t, 1, note.ReadingTime)
+
+	// 3. A written excerpt is kept until it is cleared
+	testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{
+		"excerpt": "Hand written",
+		"content": "<p>Different body</p>",
+		"status":  "PUBLISHED",
+	}, cookie)
+	note = models.Note{}
+	database.DB.First(&note, id)
+	assert.Equal(t, "Hand written", note.Excerpt)
+	assert.False(t, note.AutoExcerpt)
+
+	_, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/notes", nil, cookie)
+	assert.Contains(t, body, `"word_count":2`)
+	assert.Contains(t, body, `"reading_time":1`)
+
+	_, body, _ = testutils.MakeRequest(app, "GET", "/articles/"+note.Slug, nil, "")
+	assert.Contains(t, body, "reading_time")
+
+	testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{"excerpt": ""}, cookie)
+	note = models.Note{}
+	database.DB.First(&note, id)
+	assert.Equal(t, "Different body", note.Excerpt)
+
+	// 4. The backfill fills in notes saved before the fields existed, keeping written excerpts
+	legacy := models.Note{UserID: user.ID, Title: "Legacy", Slug: "legacy", Content: "<p>Old notes have words too</p>"}
+	kept := models.Note{UserID: user.ID, Title: "Kept", Slug: "kept", Excerpt: "Mine", Content: "<p>Body</p>"}
+	database.DB.Create(&legacy)
+	database.DB.Create(&kept)
+
+	updated, err := services.NewStatsService().Backfill()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated)
+
+	var filled, mine models.Note
+	database.DB.First(&filled, legacy.ID)
+	database.DB.First(&mine, kept.ID)
+	assert.Equal(t, "Old notes have words too", filled.Excerpt)
+	assert.Equal(t, 5, filled.WordCount)
+	assert.Equal(t, 1, filled.ReadingTime)
+	assert.Equal(t, "Mine", mine.Excerpt)
+	assert.Equal(t, 1, mine.WordCount)
+
+	updated, _ = services.NewStatsService().Backfill()
+	assert.Equal(t, 0, updated)
+}
@@ -1,30 +1,39 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
+	"strconv"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/tarakreasi/taraNote_go/internal/config"
-	"github.com/tarakreasi/taraNote_go/internal/database"
-	"github.com/tarakreasi/taraNote_go/internal/models"
-	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"github.com/tarakreasi/taraNote_go/internal/services"
 )
 
-// ListNotebooks returns all notebooks for the authenticated user
+var notebookService = services.NewNotebookService()
+
+// ListNotebooks returns a page of notebooks for the authenticated user, or with ?tree=true all of them nested
 func ListNotebooks(c *fiber.Ctx) error {
 	sess, _ := config.Store.Get(c)
-	userID := sess.Get("user_id")
-
-	var notebooks []models.Notebook
-	if err := database.DB.Select("notebooks.*, count(notes.id) as notes_count").
-		Joins("LEFT JOIN notes ON notes.notebook_id = notebooks.id AND notes.deleted_at IS NULL").
-		Where("notebooks.user_id = ?", userID).
-		Group("notebooks.id").
-		Find(&notebooks).Error; err != nil {
+	userID := sess.Get("user_id").(uint)
+
+	if c.QueryBool("tree") {
+		tree, err := notebookService.NotebookTree(userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch notebooks"})
+		}
+		return c.JSON(fiber.Map{"data": tree})
+	}
+
+	notebooks, page, err := notebookService.ListNotebooks(userID, pageRequestFromQuery(c))
+	if isPageError(err) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch notebooks"})
 	}
 
-	return c.JSON(fiber.Map{"data": notebooks})
+	return pageResponse(c, notebooks, page)
 }
 
 // CreateNotebook creates a new notebook
@@ -33,6 +42,7 @@ func CreateNotebook(c *fiber.Ctx) error {
 	userID := sess.Get("user_id").(uint)
 
 	type CreateRequest struct {
+		ParentID    *uint  `json:"parent_id"`
 		Name        string `json:"name"`
 		Slug        string `json:"slug"`
 		Description string `json:"description"`
@@ -43,49 +53,30 @@ func CreateNotebook(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
 	}
 
-	notebook := models.Notebook{
+	notebook, err := notebookService.CreateNotebook(services.CreateNotebookRequest{
 		UserID:      userID,
+		ParentID:    req.ParentID,
 		Name:        req.Name,
 		Slug:        req.Slug,
 		Description: req.Description,
+	})
+	var validationErrors validator.ValidationErrors
+	if errors.Is(err, services.ErrParentNotebookNotFound) || errors.As(err, &validationErrors) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
-
-	// Generate slug if empty
-	if notebook.Slug == "" {
-		notebook.Slug = utils.GenerateSlug(notebook.Name)
-	}
-
-	// Ensure uniqueness
-	originalSlug := notebook.Slug
-	counter := 1
-	for {
-		var count int64
-		database.DB.Model(&models.Notebook{}).Where("slug = ?", notebook.Slug).Count(&count)
-		if count == 0 {
-			break
-		}
-		notebook.Slug = fmt.Sprintf("%s-%d", originalSlug, counter)
-		counter++
+	if errors.Is(err, services.ErrNotebookExists) {
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
 	}
-
-	if err := database.DB.Create(&notebook).Error; err != nil {
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create notebook"})
 	}
 
+	setVersionETag(c, notebook.Version)
 	return c.Status(201).JSON(fiber.Map{"data": notebook})
 }
 
-// UpdateNotebook updates an existing notebook
+// UpdateNotebook replaces an existing notebook (send If-Match to guard against stale writes)
 func UpdateNotebook(c *fiber.Ctx) error {
-	sess, _ := config.Store.Get(c)
-	userID := sess.Get("user_id")
-	id := c.Params("id")
-
-	var notebook models.Notebook
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&notebook).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Notebook not found"})
-	}
-
 	type UpdateRequest struct {
 		Name        string `json:"name"`
 		Slug        string `json:"slug"`
@@ -97,49 +88,144 @@ func UpdateNotebook(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
 	}
 
-	notebook.Name = req.Name
-	notebook.Slug = req.Slug
-	notebook.Description = req.Description
+	return saveNotebook(c, services.PatchNotebookRequest{
+		Name:        &req.Name,
+		Slug:        &req.Slug,
+		Description: &req.Description,
+	})
+}
 
-	// Generate slug if empty
-	if notebook.Slug == "" {
-		notebook.Slug = utils.GenerateSlug(notebook.Name)
+// PatchNotebook updates only the fields present in the body
+func PatchNotebook(c *fiber.Ctx) error {
+	type PatchRequest struct {
+		Name        *string `json:"name"`
+		Slug        *string `json:"slug"`
+		Description *string `json:"description"`
 	}
 
-	// Ensure uniqueness
-	originalSlug := notebook.Slug
-	counter := 1
-	for {
-		var count int64
-		database.DB.Model(&models.Notebook{}).Where("slug = ? AND id != ?", notebook.Slug, notebook.ID).Count(&count)
-		if count == 0 {
-			break
-		}
-		notebook.Slug = fmt.Sprintf("%s-%d", originalSlug, counter)
-		counter++
+	req := new(PatchRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+
+	return saveNotebook(c, services.PatchNotebookRequest{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+	})
+}
+
+// saveNotebook fills in the owner and If-Match version, then maps the result;
+// a stale version gets 409 with the server copy
+func saveNotebook(c *fiber.Ctx, req services.PatchNotebookRequest) error {
+	sess, _ := config.Store.Get(c)
+	req.UserID = sess.Get("user_id").(uint)
+	req.ID = c.Params("id")
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	req.Version = version
+
+	notebook, err := notebookService.PatchNotebook(req)
+	if errors.Is(err, services.ErrVersionConflict) {
+		setVersionETag(c, notebook.Version)
+		return c.Status(409).JSON(fiber.Map{"error": err.Error(), "data": notebook})
+	}
+	if errors.Is(err, services.ErrNotebookNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Notebook not found"})
+	}
+	if errors.Is(err, services.ErrNotebookExists) {
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	setVersionETag(c, notebook.Version)
+	return c.JSON(fiber.Map{"data": notebook})
+}
+
+// MoveNotebook puts a notebook under parent_id, or at the top level when it is null
+func MoveNotebook(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	type MoveRequest struct {
+		ParentID *uint `json:"parent_id"`
 	}
 
-	if err := database.DB.Save(&notebook).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update notebook"})
+	req := new(MoveRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	notebook, err := notebookService.MoveNotebook(services.MoveNotebookRequest{
+		ID:       c.Params("id"),
+		UserID:   userID,
+		Version:  version,
+		ParentID: req.ParentID,
+	})
+	if errors.Is(err, services.ErrVersionConflict) {
+		setVersionETag(c, notebook.Version)
+		return c.Status(409).JSON(fiber.Map{"error": err.Error(), "data": notebook})
+	}
+	if errors.Is(err, services.ErrNotebookNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Notebook not found"})
+	}
+	if errors.Is(err, services.ErrParentNotebookNotFound) || errors.Is(err, services.ErrNotebookCycle) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrNotebookExists) {
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to move notebook"})
+	}
+
+	setVersionETag(c, notebook.Version)
 	return c.JSON(fiber.Map{"data": notebook})
 }
 
-// DeleteNotebook deletes a notebook
+// DeleteNotebook moves a notebook to the trash and reports how many notebooks and notes it changed.
+// ?children=cascade trashes the notebooks below it too, otherwise they move up to its parent.
+// ?notes= is detach (default), move (to ?target_id=) or trash.
 func DeleteNotebook(c *fiber.Ctx) error {
 	sess, _ := config.Store.Get(c)
-	userID := sess.Get("user_id")
-	id := c.Params("id")
+	userID := sess.Get("user_id").(uint)
 
-	result := database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Notebook{})
-	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete notebook"})
+	req := services.DeleteNotebookRequest{
+		ID:       c.Params("id"),
+		UserID:   userID,
+		Children: c.Query("children"),
+		Notes:    c.Query("notes"),
+	}
+	if target := c.Query("target_id"); target != "" {
+		id, err := strconv.ParseUint(target, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": services.ErrInvalidTargetNotebook.Error()})
+		}
+		targetID := uint(id)
+		req.TargetID = &targetID
 	}
 
-	if result.RowsAffected == 0 {
+	result, err := notebookService.DeleteNotebook(req)
+	if errors.Is(err, services.ErrInvalidChildrenMode) || errors.Is(err, services.ErrInvalidNotesMode) ||
+		errors.Is(err, services.ErrInvalidTargetNotebook) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrNotebookNotFound) {
 		return c.Status(404).JSON(fiber.Map{"error": "Notebook not found"})
 	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete notebook"})
+	}
 
-	return c.SendStatus(204)
+	return c.JSON(fiber.Map{"data": result})
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,6 +12,7 @@ import (
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/models"
 	"github.com/tarakreasi/taraNote_go/internal/testutils"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -79,9 +81,12 @@ func TestNotebook_CRUD(t *testing.T) {
 	assert.Equal(t, "updated-name", updateData["slug"]) // Auto-generated slug update
 
 	// 5. Delete Notebook
-	respDel, _, err := testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d", notebookID), nil, cookie)
+	respDel, bodyDel, err := testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d", notebookID), nil, cookie)
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusNoContent, respDel.StatusCode)
+	assert.Equal(t, http.StatusOK, respDel.StatusCode)
+	var delResult map[string]interface{}
+	json.Unmarshal([]byte(bodyDel), &delResult)
+	assert.Equal(t, float64(1), delResult["data"].(map[string]interface{})["deleted_notebooks"])
 
 	// Verify Deletion
 	respList2, bodyList2, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/notebooks", nil, cookie)
@@ -90,3 +95,309 @@ func TestNotebook_CRUD(t *testing.T) {
 	listData2 := listResult["data"].([]interface{})
 	assert.Len(t, listData2, 0)
 }
+
+func TestNotebook_CreateErrors(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "nb-errors@test.com")
+	create := func(payload map[string]interface{}) (int, uint) {
+		resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notebooks", payload, cookie)
+		var result struct {
+			Data models.Notebook `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &result)
+		return resp.StatusCode, result.Data.ID
+	}
+
+	status, recipes := create(map[string]interface{}{"name": "Recipes"})
+	assert.Equal(t, http.StatusCreated, status)
+
+	// A sibling with the same name conflicts, whatever its case
+	status, _ = create(map[string]interface{}{"name": "recipes"})
+	assert.Equal(t, http.StatusConflict, status)
+
+	// The same name is fine under another parent
+	status, _ = create(map[string]interface{}{"name": "Recipes", "parent_id": recipes})
+	assert.Equal(t, http.StatusCreated, status)
+
+	// Validation errors and unknown parents are the client's fault
+	status, _ = create(map[string]interface{}{"name": strings.Repeat("x", 256)})
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = create(map[string]interface{}{"name": "Orphan", "parent_id": 9999})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Renaming, moving and restoring can't produce a duplicate either
+	status, drafts := create(map[string]interface{}{"name": "Drafts"})
+	assert.Equal(t, http.StatusCreated, status)
+	resp, _, _ := testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notebooks/%d", drafts), map[string]string{"name": "RECIPES"}, cookie)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notebooks/%d", drafts), map[string]string{"name": "drafts"}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	status, nested := create(map[string]interface{}{"name": "Recipes", "parent_id": drafts})
+	assert.Equal(t, http.StatusCreated, status)
+	resp, _, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/notebooks/%d/move", nested), map[string]interface{}{"parent_id": nil}, cookie)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d", drafts), nil, cookie)
+	status, _ = create(map[string]interface{}{"name": "Drafts"})
+	assert.Equal(t, http.StatusCreated, status)
+	resp, _, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/trash/notebooks/%d/restore", drafts), nil, cookie)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestNotebook_PatchAndOptimisticConcurrency(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "nbpatch@test.com")
+
+	resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notebooks", map[string]string{
+		"name": "Recipes", "description": "Family favourites",
+	}, cookie)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	var result map[string]interface{}
+	json.Unmarshal([]byte(body), &result)
+	path := fmt.Sprintf("/api/v1/admin/notebooks/%d", uint(result["data"].(map[string]interface{})["id"].(float64)))
+
+	// 1. PATCH keeps the fields it doesn't send
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "PATCH", path, map[string]string{"name": "Cooking"}, cookie,
+		map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	json.Unmarshal([]byte(body), &result)
+	data := result["data"].(map[string]interface{})
+	assert.Equal(t, "Cooking", data["name"])
+	assert.Equal(t, "recipes", data["slug"])
+	assert.Equal(t, "Family favourites", data["description"])
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	// 2. A stale version is rejected with the server copy
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "PATCH", path, map[string]string{"description": "Stale"}, cookie,
+		map[string]string{"If-Match": `W/"1"`})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	json.Unmarshal([]byte(body), &result)
+	assert.Equal(t, "Family favourites", result["data"].(map[string]interface{})["description"])
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+}
+
+func TestNotebook_Hierarchy(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "nbtree@test.com")
+
+	create := func(name string, parentID interface{}) uint {
+		resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notebooks", map[string]interface{}{
+			"name": name, "parent_id": parentID,
+		}, cookie)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var result map[string]interface{}
+		json.Unmarshal([]byte(body), &result)
+		return uint(result["data"].(map[string]interface{})["id"].(float64))
+	}
+	move := func(id uint, parentID interface{}) (*http.Response, string) {
+		resp, body, _ := testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/notebooks/%d/move", id),
+			map[string]interface{}{"parent_id": parentID}, cookie)
+		return resp, body
+	}
+
+	work := create("Work", nil)
+	projects := create("Projects", work)
+	archive := create("Archive", projects)
+	personal := create("Personal", nil)
+	database.DB.Create(&models.Note{UserID: user.ID, NotebookID: &projects, Title: "Plan", Slug: "plan"})
+	database.DB.Create(&models.Note{UserID: user.ID, NotebookID: &archive, Title: "Old", Slug: "old"})
+
+	// Unknown parents and cycles are refused
+	resp, _, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notebooks", map[string]interface{}{"name": "X", "parent_id": 9999}, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, body := move(work, archive)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, body, "cannot be moved into itself")
+	resp, _ = move(work, work)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Tree with recursive counts
+	resp, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notebooks?tree=true", nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var tree struct {
+		Data []models.Notebook `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &tree)
+	if !assert.Len(t, tree.Data, 2) {
+		return
+	}
+	assert.Equal(t, "Work", tree.Data[0].Name)
+	assert.Equal(t, "Personal", tree.Data[1].Name)
+	assert.Equal(t, int64(0), tree.Data[0].NotesCount)
+	assert.Equal(t, int64(2), tree.Data[0].TotalNotesCount)
+	if assert.Len(t, tree.Data[0].Children, 1) {
+		assert.Equal(t, int64(1), tree.Data[0].Children[0].NotesCount)
+		assert.Equal(t, "Archive", tree.Data[0].Children[0].Children[0].Name)
+	}
+
+	// Moving bumps the version and honours If-Match
+	resp, _ = move(projects, personal)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "POST", fmt.Sprintf("/api/v1/admin/notebooks/%d/move", projects),
+		map[string]interface{}{"parent_id": nil}, cookie, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Deleting reparents the children by default
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d", projects), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var moved models.Notebook
+	database.DB.First(&moved, archive)
+	if assert.NotNil(t, moved.ParentID) {
+		assert.Equal(t, personal, *moved.ParentID)
+	}
+
+	// Cascade trashes the subtree, and restoring brings it back
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d?children=nope", personal), nil, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d?children=cascade", personal), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var live int64
+	database.DB.Model(&models.Notebook{}).Where("id = ?", archive).Count(&live)
+	assert.Equal(t, int64(0), live)
+
+	resp, _, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/trash/notebooks/%d/restore", personal), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	database.DB.Model(&models.Notebook{}).Where("id = ?", archive).Count(&live)
+	assert.Equal(t, int64(1), live)
+
+	// Restoring a child whose parent is still trashed puts it at the top level
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d?children=cascade", personal), nil, cookie)
+	resp, _, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/trash/notebooks/%d/restore", archive), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	database.DB.First(&moved, archive)
+	assert.Nil(t, moved.ParentID)
+}
+
+func TestNotebook_BrowserBreadcrumbs(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	parent := models.Notebook{UserID: 1, Name: "Travel", Slug: "travel"}
+	database.DB.Create(&parent)
+	child := models.Notebook{UserID: 1, ParentID: &parent.ID, Name: "Japan", Slug: "japan"}
+	database.DB.Create(&child)
+	database.DB.Create(&models.Note{UserID: 1, NotebookID: &child.ID, Title: "Kyoto", Slug: "kyoto", Status: models.NoteStatusPublished})
+
+	resp, body, _ := testutils.MakeRequestWithHeaders(app, "GET", "/notebooks/japan", nil, "", map[string]string{"X-Inertia": "true"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page struct {
+		Props struct {
+			Breadcrumbs []models.Notebook `json:"breadcrumbs"`
+			Notebooks   []models.Notebook `json:"notebooks"`
+		} `json:"props"`
+	}
+	json.Unmarshal([]byte(body), &page)
+	if assert.Len(t, page.Props.Breadcrumbs, 2) {
+		assert.Equal(t, "Travel", page.Props.Breadcrumbs[0].Name)
+		assert.Equal(t, "Japan", page.Props.Breadcrumbs[1].Name)
+	}
+	for _, notebook := range page.Props.Notebooks {
+		if notebook.ID == parent.ID {
+			assert.Equal(t, int64(0), notebook.NotesCount)
+			assert.Equal(t, int64(1), notebook.TotalNotesCount)
+		}
+	}
+}
+
+func TestNotebook_DeleteNotesModes(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "nbdelete@test.com")
+	other, _ := seedUserAndLogin(app, "nbother@test.com")
+
+	newNotebook := func(name string, owner uint, parentID *uint) models.Notebook {
+		notebook := models.Notebook{UserID: owner, ParentID: parentID, Name: name, Slug: utils.GenerateSlug(name)}
+		database.DB.Create(&notebook)
+		return notebook
+	}
+	newNote := func(title string, notebookID uint) models.Note {
+		note := models.Note{UserID: user.ID, NotebookID: &notebookID, Title: title, Slug: utils.GenerateSlug(title), Version: 1}
+		database.DB.Create(&note)
+		return note
+	}
+	deleteNotebook := func(id uint, query string) (int, map[string]interface{}) {
+		resp, body, _ := testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d%s", id, query), nil, cookie)
+		var result struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &result)
+		return resp.StatusCode, result.Data
+	}
+
+	inbox := newNotebook("Inbox", user.ID, nil)
+	foreign := newNotebook("Foreign", other.ID, nil)
+
+	// Detach is the default: notes stay live without a notebook
+	drafts := newNotebook("Drafts", user.ID, nil)
+	detached := newNote("Detached", drafts.ID)
+	status, result := deleteNotebook(drafts.ID, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), result["affected_notes"])
+	assert.Equal(t, float64(1), result["deleted_notebooks"])
+	database.DB.First(&detached, detached.ID)
+	assert.Nil(t, detached.NotebookID)
+	assert.Equal(t, uint(2), detached.Version)
+
+	// Move needs another live notebook of the user
+	ideas := newNotebook("Ideas", user.ID, nil)
+	sub := newNotebook("Sub Ideas", user.ID, &ideas.ID)
+	first := newNote("First", ideas.ID)
+	second := newNote("Second", sub.ID)
+	status, _ = deleteNotebook(ideas.ID, "?notes=move")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = deleteNotebook(ideas.ID, fmt.Sprintf("?notes=move&target_id=%d", foreign.ID))
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = deleteNotebook(ideas.ID, fmt.Sprintf("?notes=move&children=cascade&target_id=%d", sub.ID))
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = deleteNotebook(ideas.ID, "?notes=shred")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Failed requests changed nothing
+	var live int64
+	database.DB.Model(&models.Notebook{}).Where("id IN ?", []uint{ideas.ID, sub.ID}).Count(&live)
+	assert.Equal(t, int64(2), live)
+
+	status, result = deleteNotebook(ideas.ID, fmt.Sprintf("?notes=move&children=cascade&target_id=%d", inbox.ID))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(2), result["affected_notes"])
+	assert.Equal(t, float64(2), result["deleted_notebooks"])
+	for _, note := range []models.Note{first, second} {
+		database.DB.First(&note, note.ID)
+		if assert.NotNil(t, note.NotebookID) {
+			assert.Equal(t, inbox.ID, *note.NotebookID)
+		}
+	}
+
+	// Trash takes the notes along, and restoring the notebook brings them back
+	journal := newNotebook("Journal", user.ID, nil)
+	entry := newNote("Entry", journal.ID)
+	status, result = deleteNotebook(journal.ID, "?notes=trash")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), result["affected_notes"])
+	database.DB.Model(&models.Note{}).Where("id = ?", entry.ID).Count(&live)
+	assert.Equal(t, int64(0), live)
+
+	resp, body, _ := testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/trash/notebooks/%d/restore", journal.ID), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	json.Unmarshal([]byte(body), &result)
+	assert.Equal(t, float64(1), result["restored_notes"])
+
+	// No note is left pointing at a deleted notebook
+	status, _ = deleteNotebook(inbox.ID, "")
+	assert.Equal(t, http.StatusOK, status)
+	var dangling int64
+	database.DB.Model(&models.Note{}).Where("notebook_id = ?", inbox.ID).Count(&dangling)
+	assert.Equal(t, int64(0), dangling)
+}
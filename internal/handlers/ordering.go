@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var orderingService = services.NewOrderingService()
+
+// reorderBody is either {"ids": [...]} or {"id": 1, "before": 2} / {"id": 1, "after": 2}
+type reorderBody struct {
+	IDs    []uint `json:"ids"`
+	ID     uint   `json:"id"`
+	Before *uint  `json:"before"`
+	After  *uint  `json:"after"`
+}
+
+// ReorderNotebooks sets the manual order of sibling notebooks
+func ReorderNotebooks(c *fiber.Ctx) error {
+	return reorder(c, orderingService.ReorderNotebooks)
+}
+
+// ReorderNotes sets the manual order of notes within a notebook
+func ReorderNotes(c *fiber.Ctx) error {
+	return reorder(c, orderingService.ReorderNotes)
+}
+
+func reorder(c *fiber.Ctx, apply func(services.ReorderRequest) error) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	body := new(reorderBody)
+	if err := c.BodyParser(body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+
+	err := apply(services.ReorderRequest{
+		UserID: userID,
+		IDs:    body.IDs,
+		ID:     body.ID,
+		Before: body.Before,
+		After:  body.After,
+	})
+	if errors.Is(err, services.ErrReorderItemNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrInvalidReorder) || errors.Is(err, services.ErrReorderContainer) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to reorder"})
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,172 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestOrdering_Notes(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "order@test.com")
+	notebook := models.Notebook{UserID: user.ID, Name: "Recipes", Slug: "recipes"}
+	database.DB.Create(&notebook)
+
+	ids := map[string]uint{}
+	for _, title := range []string{"Soup", "Bread", "Cake"} {
+		resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{
+			"title": title, "notebook_id": notebook.ID,
+		}, cookie)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var result map[string]interface{}
+		json.Unmarshal([]byte(body), &result)
+		ids[title] = uint(result["data"].(map[string]interface{})["id"].(float64))
+	}
+
+	manual := func() []string {
+		resp, body, _ := testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/notes?sort=manual&notebook_id=%d", notebook.ID), nil, cookie)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var page struct {
+			Data []models.Note `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &page)
+		titles := []string{}
+		for _, note := range page.Data {
+			titles = append(titles, note.Title)
+		}
+		return titles
+	}
+	reorder := func(body interface{}) int {
+		resp, _, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes/reorder", body, cookie)
+		return resp.StatusCode
+	}
+
+	// New notes come first
+	assert.Equal(t, []string{"Cake", "Bread", "Soup"}, manual())
+
+	// A full list renumbers
+	assert.Equal(t, http.StatusNoContent, reorder(map[string]interface{}{"ids": []uint{ids["Soup"], ids["Bread"], ids["Cake"]}}))
+	assert.Equal(t, []string{"Soup", "Bread", "Cake"}, manual())
+
+	// Moving next to a sibling only rewrites the moved note, and is not an edit
+	var before models.Note
+	database.DB.First(&before, ids["Cake"])
+	assert.Equal(t, http.StatusNoContent, reorder(map[string]interface{}{"id": ids["Cake"], "after": ids["Soup"]}))
+	assert.Equal(t, []string{"Soup", "Cake", "Bread"}, manual())
+	var after models.Note
+	database.DB.First(&after, ids["Cake"])
+	assert.Equal(t, 1.5, after.Position)
+	assert.Equal(t, before.Version, after.Version)
+	assert.True(t, before.UpdatedAt.Equal(after.UpdatedAt))
+
+	assert.Equal(t, http.StatusNoContent, reorder(map[string]interface{}{"id": ids["Bread"], "before": ids["Soup"]}))
+	assert.Equal(t, []string{"Bread", "Soup", "Cake"}, manual())
+
+	// Notes sharing a position (by ID: Soup, Bread, Cake) are renumbered around the move
+	database.DB.Model(&models.Note{}).Where("notebook_id = ?", notebook.ID).UpdateColumn("position", 0)
+	assert.Equal(t, http.StatusNoContent, reorder(map[string]interface{}{"id": ids["Cake"], "before": ids["Bread"]}))
+	assert.Equal(t, []string{"Soup", "Cake", "Bread"}, manual())
+
+	// Invalid requests
+	loose := models.Note{UserID: user.ID, Title: "Loose", Slug: "loose"}
+	database.DB.Create(&loose)
+	assert.Equal(t, http.StatusBadRequest, reorder(map[string]interface{}{"id": ids["Cake"], "before": loose.ID}))
+	assert.Equal(t, http.StatusBadRequest, reorder(map[string]interface{}{"id": ids["Cake"]}))
+	assert.Equal(t, http.StatusBadRequest, reorder(map[string]interface{}{"id": ids["Cake"], "before": ids["Soup"], "after": ids["Bread"]}))
+	assert.Equal(t, http.StatusBadRequest, reorder(map[string]interface{}{"ids": []uint{ids["Cake"], ids["Cake"]}}))
+	assert.Equal(t, http.StatusNotFound, reorder(map[string]interface{}{"ids": []uint{ids["Cake"], 9999}}))
+	assert.Equal(t, []string{"Soup", "Cake", "Bread"}, manual())
+
+	// A partial list goes first; the siblings left out follow in their current order
+	assert.Equal(t, http.StatusNoContent, reorder(map[string]interface{}{"ids": []uint{ids["Bread"]}}))
+	assert.Equal(t, []string{"Bread", "Soup", "Cake"}, manual())
+	database.DB.First(&after, ids["Cake"])
+	assert.Equal(t, 3.0, after.Position)
+
+	// A note moved into the notebook goes first, like a new note
+	resp, _, _ := testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", loose.ID), map[string]interface{}{
+		"notebook_id": notebook.ID,
+	}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Loose", "Bread", "Soup", "Cake"}, manual())
+
+	// So do the notes of a deleted notebook moved here, keeping their order
+	other := models.Notebook{UserID: user.ID, Name: "Drafts", Slug: "drafts"}
+	database.DB.Create(&other)
+	database.DB.Create(&models.Note{UserID: user.ID, NotebookID: &other.ID, Title: "Second", Slug: "second", Position: 2})
+	database.DB.Create(&models.Note{UserID: user.ID, NotebookID: &other.ID, Title: "First", Slug: "first", Position: 1})
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d?notes=move&target_id=%d", other.ID, notebook.ID), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"First", "Second", "Loose", "Bread", "Soup", "Cake"}, manual())
+}
+
+func TestOrdering_Notebooks(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "nborder@test.com")
+
+	ids := map[string]uint{}
+	for _, name := range []string{"Zebra", "Apple", "Mango"} {
+		resp, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notebooks", map[string]string{"name": name}, cookie)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var result map[string]interface{}
+		json.Unmarshal([]byte(body), &result)
+		ids[name] = uint(result["data"].(map[string]interface{})["id"].(float64))
+	}
+
+	names := func(url string) []string {
+		_, body, _ := testutils.MakeRequest(app, "GET", url, nil, cookie)
+		var page struct {
+			Data []models.Notebook `json:"data"`
+		}
+		json.Unmarshal([]byte(body), &page)
+		result := []string{}
+		for _, notebook := range page.Data {
+			result = append(result, notebook.Name)
+		}
+		return result
+	}
+
+	// New notebooks come last; the default listing is the manual order
+	assert.Equal(t, []string{"Zebra", "Apple", "Mango"}, names("/api/v1/admin/notebooks?sort=manual"))
+	assert.Equal(t, []string{"Zebra", "Apple", "Mango"}, names("/api/v1/admin/notebooks"))
+	assert.Equal(t, []string{"Apple", "Mango", "Zebra"}, names("/api/v1/admin/notebooks?sort=name"))
+
+	resp, _, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notebooks/reorder", map[string]interface{}{
+		"id": ids["Zebra"], "after": ids["Mango"],
+	}, cookie)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, []string{"Apple", "Mango", "Zebra"}, names("/api/v1/admin/notebooks?sort=manual"))
+	assert.Equal(t, []string{"Apple", "Mango", "Zebra"}, names("/api/v1/admin/notebooks?tree=true"))
+
+	// Paging keeps the manual order
+	_, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/notebooks?sort=manual&limit=2", nil, cookie)
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	json.Unmarshal([]byte(body), &page)
+	assert.Equal(t, []string{"Zebra"}, names("/api/v1/admin/notebooks?sort=manual&limit=2&cursor="+page.NextCursor))
+
+	// The public browser lists notebooks in manual order
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "GET", "/taranote", nil, "", map[string]string{"X-Inertia": "true"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var browser struct {
+		Props struct {
+			Notebooks []models.Notebook `json:"notebooks"`
+		} `json:"props"`
+	}
+	json.Unmarshal([]byte(body), &browser)
+	if assert.Len(t, browser.Props.Notebooks, 3) {
+		assert.Equal(t, "Apple", browser.Props.Notebooks[0].Name)
+		assert.Equal(t, "Zebra", browser.Props.Notebooks[2].Name)
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+// pageRequestFromQuery reads ?limit=&cursor=&sort=&direction=&total=true
+func pageRequestFromQuery(c *fiber.Ctx) services.PageRequest {
+	return services.PageRequest{
+		Limit:     c.QueryInt("limit", services.DefaultPageLimit),
+		Cursor:    c.Query("cursor"),
+		Sort:      c.Query("sort"),
+		Direction: c.Query("direction"),
+		WithTotal: c.QueryBool("total", false),
+	}
+}
+
+// pageResponse writes a page of results with its next_cursor (and total when requested)
+func pageResponse(c *fiber.Ctx, data interface{}, info services.PageInfo) error {
+	body := fiber.Map{"data": data, "next_cursor": info.NextCursor}
+	if info.Total != nil {
+		body["total"] = *info.Total
+	}
+	return c.JSON(body)
+}
+
+// isPageError reports whether err came from bad pagination parameters (400 rather than 500)
+func isPageError(err error) bool {
+	return errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidSort)
+}
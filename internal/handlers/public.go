@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/tarakreasi/taraNote_go/internal/config"
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
 	"github.com/tarakreasi/taraNote_go/internal/utils"
 )
 
+// visitorCookie identifies a browser for view deduplication
+const visitorCookie = "tn_vid"
+
 // Helper to get authenticated user
 func getAuthUser(c *fiber.Ctx) *models.User {
 	sess, _ := config.Store.Get(c)
@@ -29,7 +38,7 @@ func PublicList(c *fiber.Ctx) error {
 
 	// Fetch published notes, latest first
 	if err := database.DB.Where("status = ?", "PUBLISHED").
-		Preload("User").Preload("Notebook").
+		Preload("User").Preload("Notebook").Preload("Tags").
 		Order("published_at desc").
 		Limit(9).
 		Find(&notes).Error; err != nil {
@@ -50,7 +59,7 @@ func PublicList(c *fiber.Ctx) error {
 		props["auth"] = fiber.Map{"user": user}
 	}
 
-	return utils.RenderInertia(c, "TaraNote", props)
+	return utils.RenderInertiaWithHead(c, "TaraNote", props, services.PageHead(services.LoadSiteInfo(), "", "", "/"))
 }
 
 // PublicShow renders a single article by slug
@@ -60,8 +69,17 @@ func PublicShow(c *fiber.Ctx) error {
 
 	// Find note by slug
 	if err := database.DB.Where("slug = ?", slug).
-		Preload("User").Preload("Notebook").
+		Preload("User").Preload("Notebook").Preload("Tags").
 		First(&note).Error; err != nil {
+		// Old slugs redirect permanently to the current one
+		var history models.SlugHistory
+		if database.DB.Where("slug = ?", slug).First(&history).Error == nil {
+			var current models.Note
+			if database.DB.Select("id, slug").Where("status = ?", "PUBLISHED").First(&current, history.NoteID).Error == nil {
+				return c.Redirect("/articles/"+current.Slug, fiber.StatusMovedPermanently)
+			}
+		}
+
 		// If not found, return 404 Inertia page or simple 404
 		return c.Status(404).SendString("Article not found")
 	}
@@ -71,6 +89,8 @@ func PublicShow(c *fiber.Ctx) error {
 		return c.Status(404).SendString("Article not found")
 	}
 
+	recordView(c, &note)
+
 	props := fiber.Map{
 		"article":  note,
 		"settings": fiber.Map{},
@@ -81,27 +101,106 @@ func PublicShow(c *fiber.Ctx) error {
 		props["auth"] = fiber.Map{"user": user}
 	}
 
-	return utils.RenderInertia(c, "Docs", props)
+	return utils.RenderInertiaWithHead(c, "Docs", props, services.ArticleHead(services.LoadSiteInfo(), &note))
 }
 
 // TaraNoteBrowser renders the 3-column internal browser
 func TaraNoteBrowser(c *fiber.Ctx) error {
+	props, err := browserProps()
+	if err != nil {
+		return c.Status(500).SendString("Error fetching notes")
+	}
+
+	// Add Auth User
+	if user := getAuthUser(c); user != nil {
+		props["auth"] = fiber.Map{"user": user}
+	}
+
+	return utils.RenderInertiaWithHead(c, "TaraNote", props, services.PageHead(services.LoadSiteInfo(), "", "", "/taranote"))
+}
+
+// PublicNotebook renders the browser with a notebook selected
+func PublicNotebook(c *fiber.Ctx) error {
+	var notebook models.Notebook
+	if err := database.DB.Where("slug = ?", c.Params("slug")).First(&notebook).Error; err != nil {
+		return c.Status(404).SendString("Notebook not found")
+	}
+
+	props, err := browserProps()
+	if err != nil {
+		return c.Status(500).SendString("Error fetching notes")
+	}
+	props["notebook"] = notebook
+	props["breadcrumbs"] = notebookService.NotebookBreadcrumbs(&notebook)
+
+	// Add Auth User
+	if user := getAuthUser(c); user != nil {
+		props["auth"] = fiber.Map{"user": user}
+	}
+
+	head := services.PageHead(services.LoadSiteInfo(), notebook.Name, notebook.Description, services.NotebookPath(&notebook))
+	return utils.RenderInertiaWithHead(c, "TaraNote", props, head)
+}
+
+// browserProps loads the published notes and the notebooks with their published note counts,
+// including the notes of the notebooks below them in total_notes_count
+func browserProps() (fiber.Map, error) {
 	var notes []models.Note
 	if err := database.DB.Where("status = ?", "PUBLISHED").
-		Preload("User").Preload("Notebook").
+		Preload("User").Preload("Notebook").Preload("Tags").
 		Order("published_at desc").
 		Find(&notes).Error; err != nil {
-		return c.Status(500).SendString("Error fetching notes")
+		return nil, err
 	}
 
 	// Fetch notebooks with note counts
 	var notebooks []models.Notebook
-	database.DB.Select("notebooks.*, count(notes.id) as notes_count").
-		Joins("LEFT JOIN notes ON notes.notebook_id = notebooks.id AND notes.deleted_at IS NULL AND notes.status = 'PUBLISHED'").
-		Group("notebooks.id").
-		Find(&notebooks)
+	if err := database.DB.Order("position asc").Order("name COLLATE NOCASE asc").
+		Find(&notebooks).Error; err != nil {
+		return nil, err
+	}
+	if err := services.FillPublishedNotesCounts(notebooks); err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"notes":     notes,
+		"notebooks": notebooks,
+	}, nil
+}
+
+// PublicTag renders the published notes carrying a tag
+func PublicTag(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	// Several users can have a tag with this slug; the page lists the published notes of all of them,
+	// so the tag is looked up among the tags of those notes
+	published := database.DB.Model(&models.Note{}).Select("id").Where("status = ?", models.NoteStatusPublished)
+	var tag models.Tag
+	if err := database.DB.Where("slug = ?", slug).
+		Where("id IN (?)", database.DB.Table("note_tags").Select("tag_id").Where("note_id IN (?)", published)).
+		Order("id asc").
+		First(&tag).Error; err != nil {
+		return c.Status(404).SendString("Tag not found")
+	}
+
+	var notes []models.Note
+	if err := database.DB.Where("status = ?", "PUBLISHED").
+		Where("id IN (?)", database.DB.Table("note_tags").
+			Select("note_tags.note_id").
+			Joins("JOIN tags ON tags.id = note_tags.tag_id").
+			Where("tags.slug = ?", slug)).
+		Preload("User").Preload("Notebook").Preload("Tags").
+		Order("published_at desc").
+		Find(&notes).Error; err != nil {
+		return c.Status(500).SendString("Error fetching notes")
+	}
+
+	var notebooks []models.Notebook
+	database.DB.Find(&notebooks)
 
 	props := fiber.Map{
+		"tag":       tag,
 		"notes":     notes,
 		"notebooks": notebooks,
 	}
@@ -111,5 +210,43 @@ func TaraNoteBrowser(c *fiber.Ctx) error {
 		props["auth"] = fiber.Map{"user": user}
 	}
 
-	return utils.RenderInertia(c, "TaraNote", props)
+	head := services.PageHead(services.LoadSiteInfo(), "#"+tag.Name, "", "/tags/"+tag.Slug)
+	return utils.RenderInertiaWithHead(c, "TaraNote", props, head)
+}
+
+// recordView counts a page view of a published note. Visitors are identified by a cookie and,
+// until the cookie comes back, by their IP and User-Agent, so clients ignoring cookies count once.
+// Bots are filtered out by the counter.
+func recordView(c *fiber.Ctx, note *models.Note) {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	fingerprint := sha256.Sum256([]byte(c.IP() + "|" + userAgent))
+	visitors := []string{"fp:" + hex.EncodeToString(fingerprint[:16])}
+	if id := c.Cookies(visitorCookie); id != "" {
+		visitors = []string{"c:" + id}
+	} else {
+		id = uuid.New().String()
+		c.Cookie(&fiber.Cookie{
+			Name:     visitorCookie,
+			Value:    id,
+			Path:     "/",
+			Expires:  time.Now().AddDate(1, 0, 0),
+			HTTPOnly: true,
+			SameSite: "Lax",
+		})
+		visitors = append(visitors, "c:"+id)
+	}
+
+	var viewerID uint
+	sess, _ := config.Store.Get(c)
+	if sess != nil {
+		viewerID, _ = sess.Get("user_id").(uint)
+	}
+
+	services.Views.Record(services.PageView{
+		NoteID:    note.ID,
+		AuthorID:  note.UserID,
+		ViewerID:  viewerID,
+		Visitors:  visitors,
+		UserAgent: userAgent,
+	})
 }
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var revisionService = services.NewRevisionService(services.SystemClock)
+
+// revisionErrorStatus maps revision service errors to HTTP status codes
+func revisionErrorStatus(err error) int {
+	if errors.Is(err, services.ErrNoteNotFound) || errors.Is(err, services.ErrRevisionNotFound) {
+		return 404
+	}
+	return 500
+}
+
+// ListRevisions returns the revision history of a note
+func ListRevisions(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	revisions, err := revisionService.ListRevisions(c.Params("id"), userID)
+	if err != nil {
+		return c.Status(revisionErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": revisions})
+}
+
+// ShowRevision returns a single revision including its content
+func ShowRevision(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	revision, err := revisionService.GetRevision(c.Params("id"), c.Params("revisionId"), userID)
+	if err != nil {
+		return c.Status(revisionErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": revision})
+}
+
+// DiffRevisions compares two revisions (?from=&to=&mode=line|word)
+func DiffRevisions(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	from := c.Query("from")
+	to := c.Query("to")
+	if from == "" || to == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "from and to are required"})
+	}
+
+	diff, err := revisionService.DiffRevisions(c.Params("id"), from, to, userID, c.Query("mode"))
+	if err != nil {
+		return c.Status(revisionErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": diff})
+}
+
+// RestoreRevision replaces the note content with the chosen revision
+func RestoreRevision(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	note, err := revisionService.RestoreRevision(services.RestoreRevisionRequest{
+		NoteID:     c.Params("id"),
+		RevisionID: c.Params("revisionId"),
+		UserID:     userID,
+		Version:    version,
+	})
+	if errors.Is(err, services.ErrVersionConflict) {
+		setVersionETag(c, note.Version)
+		return c.Status(409).JSON(fiber.Map{"error": err.Error(), "data": note})
+	}
+	if err != nil {
+		return c.Status(revisionErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	setVersionETag(c, note.Version)
+	return c.JSON(fiber.Map{"data": note})
+}
@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedUserAndLogin creates a user with the given email and returns it with a session cookie
+func seedUserAndLogin(app *fiber.App, email string) (models.User, string) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	user := models.User{Name: "Test User", Email: email, Password: string(hashed)}
+	database.DB.Create(&user)
+	return user, loginAndGetCookie(app, email, "password")
+}
+
+func TestRevision_HistoryDiffRestore(t *testing.T) {
+	t.Setenv("REVISION_COALESCE_WINDOW", "0")
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "rev@test.com")
+
+	// 1. Create and edit a note twice
+	_, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{"title": "Draft"}, cookie)
+	var created map[string]interface{}
+	json.Unmarshal([]byte(body), &created)
+	noteID := uint(created["data"].(map[string]interface{})["id"].(float64))
+	notePath := fmt.Sprintf("/api/v1/admin/notes/%d", noteID)
+
+	testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+		"title": "Draft", "content": "<p>first line</p><p>second line</p>", "status": "DRAFT",
+	}, cookie)
+	testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+		"title": "Draft", "content": "<p>first line</p><p>changed line</p>", "status": "DRAFT",
+	}, cookie)
+
+	// 2. List revisions (newest first)
+	resp, body, err := testutils.MakeRequest(app, "GET", notePath+"/revisions", nil, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var list map[string]interface{}
+	json.Unmarshal([]byte(body), &list)
+	revisions := list["data"].([]interface{})
+	assert.Len(t, revisions, 3)
+	newest := uint(revisions[0].(map[string]interface{})["id"].(float64))
+	middle := uint(revisions[1].(map[string]interface{})["id"].(float64))
+
+	// 3. Diff the two edits line by line
+	resp, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("%s/revisions/diff?from=%d&to=%d", notePath, middle, newest), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var diffResult struct {
+		Data struct {
+			Content []struct {
+				Op   string `json:"op"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &diffResult)
+	assert.Equal(t, []string{"equal", "delete", "insert"}, []string{
+		diffResult.Data.Content[0].Op, diffResult.Data.Content[1].Op, diffResult.Data.Content[2].Op,
+	})
+	assert.Equal(t, "<p>second line</p>", diffResult.Data.Content[1].Text)
+	assert.Equal(t, "<p>changed line</p>", diffResult.Data.Content[2].Text)
+
+	// 4. Restore the middle revision; a stale If-Match gets 409 with the server copy
+	var note models.Note
+	database.DB.First(&note, noteID)
+	restorePath := fmt.Sprintf("%s/revisions/%d/restore", notePath, middle)
+
+	resp, body, _ = testutils.MakeRequestWithHeaders(app, "POST", restorePath, nil, cookie,
+		map[string]string{"If-Match": fmt.Sprintf(`"%d"`, note.Version-1)})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf(`"%d"`, note.Version), resp.Header.Get("ETag"))
+	var conflict map[string]interface{}
+	json.Unmarshal([]byte(body), &conflict)
+	assert.Equal(t, "<p>first line</p><p>changed line</p>", conflict["data"].(map[string]interface{})["content"])
+
+	resp, _, _ = testutils.MakeRequestWithHeaders(app, "POST", restorePath, nil, cookie,
+		map[string]string{"If-Match": fmt.Sprintf(`"%d"`, note.Version)})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf(`"%d"`, note.Version+1), resp.Header.Get("ETag"))
+
+	database.DB.First(&note, noteID)
+	assert.Equal(t, "<p>first line</p><p>second line</p>", note.Content)
+
+	var count int64
+	database.DB.Model(&models.NoteRevision{}).Where("note_id = ?", noteID).Count(&count)
+	assert.Equal(t, int64(4), count)
+
+	// 5. Other users cannot see the history
+	_, otherCookie := seedUserAndLogin(app, "rev-other@test.com")
+	resp, _, _ = testutils.MakeRequest(app, "GET", notePath+"/revisions", nil, otherCookie)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRevision_Retention(t *testing.T) {
+	t.Setenv("REVISION_KEEP_LAST", "2")
+	t.Setenv("REVISION_COALESCE_WINDOW", "1h")
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "retention@test.com")
+
+	note := models.Note{UserID: user.ID, Title: "Autosaved", Slug: "autosaved"}
+	database.DB.Create(&note)
+	notePath := fmt.Sprintf("/api/v1/admin/notes/%d", note.ID)
+
+	// Autosaves within the window collapse into one revision
+	for i := 0; i < 3; i++ {
+		testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+			"title": "Autosaved", "content": fmt.Sprintf("<p>v%d</p>", i), "status": "DRAFT",
+		}, cookie)
+	}
+
+	var revisions []models.NoteRevision
+	database.DB.Where("note_id = ?", note.ID).Find(&revisions)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, "<p>v2</p>", revisions[0].Content)
+
+	// Without coalescing every save is kept, but only the newest two survive pruning
+	t.Setenv("REVISION_COALESCE_WINDOW", "0")
+	for i := 0; i < 3; i++ {
+		testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{
+			"title": "Autosaved", "content": fmt.Sprintf("<p>edit %d</p>", i), "status": "DRAFT",
+		}, cookie)
+	}
+
+	var count int64
+	database.DB.Model(&models.NoteRevision{}).Where("note_id = ?", note.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	database.DB.Where("note_id = ?", note.ID).Order("id asc").Find(&revisions)
+	assert.Equal(t, "<p>edit 1</p>", revisions[0].Content)
+	assert.Equal(t, "<p>edit 2</p>", revisions[1].Content)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var searchService = services.NewSearchService()
+
+// Public search result limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// PublicSearch returns published notes matching ?q=, ranked by relevance
+func PublicSearch(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "q is required"})
+	}
+
+	limit := c.QueryInt("limit", defaultSearchLimit)
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	hits, err := searchService.Search(query, services.SearchOptions{PublishedOnly: true, Limit: limit})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Search failed"})
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.NoteID
+	}
+
+	var notes []models.Note
+	if err := database.DB.Where("id IN ?", ids).
+		Preload("User").Preload("Notebook").Preload("Tags").
+		Find(&notes).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Search failed"})
+	}
+
+	// Keep relevance order
+	byID := make(map[uint]models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+	results := make([]models.Note, 0, len(hits))
+	for _, hit := range hits {
+		if note, ok := byID[hit.NoteID]; ok {
+			note.Snippet = hit.Snippet
+			results = append(results, note)
+		}
+	}
+
+	return c.JSON(fiber.Map{"data": results})
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func searchTitles(t *testing.T, body string) ([]string, []string) {
+	var result struct {
+		Data []models.Note `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+
+	titles := []string{}
+	snippets := []string{}
+	for _, note := range result.Data {
+		titles = append(titles, note.Title)
+		snippets = append(snippets, note.Snippet)
+	}
+	return titles, snippets
+}
+
+func TestSearch_PublicAndAdmin(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "search@test.com")
+
+	// 1. Seed notes through the API so the index is kept in sync
+	seed := []struct{ title, content, status string }{
+		{"Gardening Basics", "<p>How to water <strong>tomatoes</strong> in summer.</p>", "PUBLISHED"},
+		{"Kitchen Log", "<p>Notes about gardening tools and a tomato <em>sauce</em> recipe.</p>", "PUBLISHED"},
+		{"Secret Draft", "<p>Gardening plans nobody should see yet.</p>", "DRAFT"},
+	}
+	for _, n := range seed {
+		_, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{"title": n.title}, cookie)
+		var created map[string]interface{}
+		json.Unmarshal([]byte(body), &created)
+		id := uint(created["data"].(map[string]interface{})["id"].(float64))
+		testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/notes/%d", id), map[string]interface{}{
+			"title": n.title, "content": n.content, "status": n.status,
+		}, cookie)
+	}
+
+	// 2. Public search only returns published notes
+	resp, body, err := testutils.MakeRequest(app, "GET", "/api/v1/search?q=gardening", nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	titles, _ := searchTitles(t, body)
+	assert.ElementsMatch(t, []string{"Gardening Basics", "Kitchen Log"}, titles)
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/api/v1/search", nil, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// 3. Admin search sees drafts too
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?search=gardening", nil, cookie)
+	titles, _ = searchTitles(t, body)
+	assert.Len(t, titles, 3)
+
+	// Search results page through in relevance order
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?search=gardening&limit=2&total=true", nil, cookie)
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+		Total      int    `json:"total"`
+	}
+	json.Unmarshal([]byte(body), &page)
+	firstPage, _ := searchTitles(t, body)
+	assert.Len(t, firstPage, 2)
+	assert.Equal(t, 3, page.Total)
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?search=gardening&limit=2&cursor="+page.NextCursor, nil, cookie)
+	secondPage, _ := searchTitles(t, body)
+	assert.ElementsMatch(t, titles, append(firstPage, secondPage...))
+
+	if !services.SearchAvailable() {
+		t.Skip("FTS5 not compiled in (run with -tags sqlite_fts5)")
+	}
+
+	// 4. Title matches rank first and snippets highlight matches
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/search?q=gardening", nil, "")
+	titles, snippets := searchTitles(t, body)
+	assert.Equal(t, []string{"Gardening Basics", "Kitchen Log"}, titles)
+	assert.Contains(t, snippets[1], "<mark>gardening</mark>")
+
+	// 5. Markup is not searchable
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/search?q=strong", nil, "")
+	titles, _ = searchTitles(t, body)
+	assert.Empty(t, titles)
+
+	// 6. Prefix and phrase queries
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/search?q="+url.QueryEscape("tomat*"), nil, "")
+	titles, _ = searchTitles(t, body)
+	assert.Len(t, titles, 2)
+
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/search?q="+url.QueryEscape(`"tomato sauce"`), nil, "")
+	titles, _ = searchTitles(t, body)
+	assert.Equal(t, []string{"Kitchen Log"}, titles)
+}
+
+func TestSearch_LikeFallbackMatchesLiterally(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+	if services.SearchAvailable() {
+		t.Skip("full-text search is available, so the LIKE fallback is not used")
+	}
+
+	_, cookie := seedUserAndLogin(app, "like@test.com")
+	for _, title := range []string{"100% done", "1000 done", "snake_case", "snakeXcase", `C:\temp`, "C:temp"} {
+		testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{"title": title}, cookie)
+	}
+
+	for query, expected := range map[string][]string{
+		"100%":     {"100% done"},
+		"snake_":   {"snake_case"},
+		`C:\`:      {`C:\temp`},
+		"%":        {"100% done"},
+		"e_c":      {"snake_case"},
+		"nothing%": {},
+	} {
+		resp, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?search="+url.QueryEscape(query), nil, cookie)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, query)
+		titles, _ := searchTitles(t, body)
+		assert.ElementsMatch(t, expected, titles, query)
+	}
+}
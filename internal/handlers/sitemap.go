@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var sitemapService = services.NewSitemapService("docs", services.SitemapMaxURLs)
+
+// Sitemap serves the sitemap of published notes, notebooks and docs, or a sitemap index once it
+// needs more than one file
+func Sitemap(c *fiber.Ctx) error {
+	sitemap, err := sitemapService.Build(services.LoadSiteInfo())
+	if err != nil {
+		return c.Status(500).SendString("Error building sitemap")
+	}
+
+	var buf bytes.Buffer
+	if err := sitemap.WriteRoot(&buf); err != nil {
+		return c.Status(500).SendString("Error building sitemap")
+	}
+	c.Set(fiber.HeaderContentType, "application/xml; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Send(buf.Bytes())
+}
+
+// SitemapPage serves one file of a split sitemap (/sitemap-2.xml)
+func SitemapPage(c *fiber.Ctx) error {
+	n, err := strconv.Atoi(c.Params("page"))
+	if err != nil {
+		return c.Status(404).SendString("Sitemap not found")
+	}
+	sitemap, err := sitemapService.Build(services.LoadSiteInfo())
+	if err != nil {
+		return c.Status(500).SendString("Error building sitemap")
+	}
+	if sitemap.Pages() < 2 || n < 1 || n > sitemap.Pages() {
+		return c.Status(404).SendString("Sitemap not found")
+	}
+
+	var buf bytes.Buffer
+	if err := services.WriteSitemap(&buf, sitemap.Page(n)); err != nil {
+		return c.Status(500).SendString("Error building sitemap")
+	}
+	c.Set(fiber.HeaderContentType, "application/xml; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Send(buf.Bytes())
+}
+
+// RobotsTxt serves the robots_txt setting, so crawler rules change without a deploy
+func RobotsTxt(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	return c.SendString(services.RobotsTxt(services.LoadSiteInfo()))
+}
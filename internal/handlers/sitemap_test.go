@@ -0,0 +1,50 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestSitemapAndRobots(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	database.DB.Create(&models.Setting{Key: "site_url", Value: "https://blog.example.com"})
+	notebook := models.Notebook{UserID: 1, Name: "Journal", Slug: "journal"}
+	database.DB.Create(&notebook)
+	database.DB.Create(&models.Note{UserID: 1, NotebookID: &notebook.ID, Title: "Live", Slug: "live", Status: models.NoteStatusPublished})
+	database.DB.Create(&models.Note{UserID: 1, Title: "Draft", Slug: "draft", Status: models.NoteStatusDraft})
+
+	resp, body, _ := testutils.MakeRequest(app, "GET", "/sitemap.xml", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, body, "<loc>https://blog.example.com/articles/live</loc>")
+	assert.NotContains(t, body, "/articles/draft")
+	assert.Contains(t, body, "<loc>https://blog.example.com/notebooks/journal</loc>")
+
+	// Listed notebook pages exist
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/notebooks/journal", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/notebooks/missing", nil, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// A sitemap that fits one file has no parts
+	resp, body, _ = testutils.MakeRequest(app, "GET", "/sitemap-1.xml", nil, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "Sitemap not found", body)
+
+	resp, body, _ = testutils.MakeRequest(app, "GET", "/robots.txt", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, "Sitemap: https://blog.example.com/sitemap.xml")
+
+	database.DB.Create(&models.Setting{Key: "robots_txt", Value: "User-agent: BadBot\nDisallow: /"})
+	_, body, _ = testutils.MakeRequest(app, "GET", "/robots.txt", nil, "")
+	assert.Contains(t, body, "User-agent: BadBot\nDisallow: /\n")
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var viewStatsService = services.NewViewStatsService()
+
+// ViewStats returns the daily views of the user's notes for the last ?days (default 30),
+// optionally narrowed to ?note_id, with per-note totals
+func ViewStats(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > services.MaxStatsDays {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("days must be between 1 and %d", services.MaxStatsDays)})
+	}
+
+	var noteID *uint
+	if id := c.QueryInt("note_id", 0); id > 0 {
+		value := uint(id)
+		noteID = &value
+	}
+
+	report, err := viewStatsService.Report(userID, days, noteID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch view stats"})
+	}
+
+	return c.JSON(fiber.Map{"data": report})
+}
@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestStats_ViewCounting(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+	services.Views = services.NewViewCounter(services.SystemClock)
+
+	_, cookie := seedUserAndLogin(app, "views@test.com")
+	id := createNoteWithContent(app, cookie, "Popular Post", "<p>read me</p>")
+	other := createNoteWithContent(app, cookie, "Quiet Post", "<p>nobody</p>")
+	for _, noteID := range []uint{id, other} {
+		testutils.MakeRequest(app, "PATCH", fmt.Sprintf("/api/v1/admin/notes/%d", noteID), map[string]interface{}{"status": "PUBLISHED"}, cookie)
+	}
+
+	browse := func(cookies, ua string) *http.Response {
+		resp, _, err := testutils.MakeRequestWithHeaders(app, "GET", "/articles/popular-post", nil, cookies, map[string]string{"User-Agent": ua})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp
+	}
+
+	// 1. The first visit issues a visitor cookie; coming back with it doesn't count again
+	resp := browse("", browserUA)
+	visitor := ""
+	for _, c := range resp.Cookies() {
+		if c.Name == "tn_vid" {
+			visitor = c.Name + "=" + c.Value
+		}
+	}
+	assert.NotEmpty(t, visitor)
+	browse(visitor, browserUA)
+
+	// 2. Another browser counts; bots and the author don't
+	browse("tn_vid=second-browser", "Mozilla/5.0 (Macintosh) Safari/605.1.15")
+	browse("", "Googlebot/2.1 (+http://www.google.com/bot.html)")
+	browse(cookie, browserUA)
+
+	written, err := services.Views.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	var note models.Note
+	database.DB.First(&note, id)
+	assert.Equal(t, 2, note.Views)
+
+	// 3. The dashboard gets a zero-filled daily series and per-note counts
+	resp, body, _ := testutils.MakeRequest(app, "GET", "/api/v1/admin/stats/views?days=7", nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Data services.ViewReport `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Len(t, result.Data.Days, 7)
+	assert.Equal(t, 2, result.Data.Days[6].Views)
+	assert.Equal(t, 2, result.Data.Total)
+	if assert.Len(t, result.Data.Notes, 1) {
+		assert.Equal(t, "Popular Post", result.Data.Notes[0].Title)
+		assert.Equal(t, 2, result.Data.Notes[0].PeriodViews)
+	}
+
+	resp, body, _ = testutils.MakeRequest(app, "GET", fmt.Sprintf("/api/v1/admin/stats/views?note_id=%d", other), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Len(t, result.Data.Days, 30)
+	assert.Equal(t, 0, result.Data.Total)
+	assert.Empty(t, result.Data.Notes)
+
+	// 4. Another user sees none of these views
+	_, stranger := seedUserAndLogin(app, "stranger@test.com")
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/stats/views", nil, stranger)
+	assert.True(t, strings.Contains(body, `"total":0`))
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/stats/views?days=0", nil, cookie)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var tagService = services.NewTagService()
+
+// tagErrorResponse maps tag service errors to a JSON error response
+func tagErrorResponse(c *fiber.Ctx, err error) error {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTagExists):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTagMergeSelf), errors.As(err, &validationErrors):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "Failed to update tags"})
+}
+
+// ListTags returns all tags with note counts
+func ListTags(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	tags, err := tagService.ListTags(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch tags"})
+	}
+
+	return c.JSON(fiber.Map{"data": tags})
+}
+
+// CreateTag creates a new tag
+func CreateTag(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	type Request struct {
+		Name string `json:"name"`
+	}
+
+	req := new(Request)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+
+	tag, err := tagService.CreateTag(services.TagRequest{UserID: userID, Name: req.Name})
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{"data": tag})
+}
+
+// UpdateTag renames a tag
+func UpdateTag(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	type Request struct {
+		Name string `json:"name"`
+	}
+
+	req := new(Request)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Bad Request"})
+	}
+
+	tag, err := tagService.RenameTag(c.Params("id"), services.TagRequest{UserID: userID, Name: req.Name})
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": tag})
+}
+
+// MergeTag merges the tag into another tag ({"target_id": 2})
+func MergeTag(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	type Request struct {
+		TargetID uint `json:"target_id"`
+	}
+
+	req := new(Request)
+	if err := c.BodyParser(req); err != nil || req.TargetID == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "target_id is required"})
+	}
+
+	tag, err := tagService.MergeTags(c.Params("id"), fmt.Sprint(req.TargetID), userID)
+	if err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": tag})
+}
+
+// DeleteTag deletes a tag and removes it from all notes
+func DeleteTag(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	if err := tagService.DeleteTag(c.Params("id"), userID); err != nil {
+		return tagErrorResponse(c, err)
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestTag_AssignFilterMerge(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	_, cookie := seedUserAndLogin(app, "tags@test.com")
+
+	// 1. Create notes with tags
+	_, body, _ := testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{
+		"title": "Garden Note", "tags": []string{"Evergreen", "golang"},
+	}, cookie)
+	var created map[string]interface{}
+	json.Unmarshal([]byte(body), &created)
+	data := created["data"].(map[string]interface{})
+	assert.Len(t, data["tags"], 2)
+	noteID := uint(data["id"].(float64))
+
+	testutils.MakeRequest(app, "POST", "/api/v1/admin/notes", map[string]interface{}{
+		"title": "Sprout", "tags": []string{"Seedling", "Go Lang"},
+	}, cookie)
+
+	// 2. Tag counts
+	resp, body, err := testutils.MakeRequest(app, "GET", "/api/v1/admin/tags", nil, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var tagList struct {
+		Data []models.Tag `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &tagList)
+	counts := map[string]int64{}
+	ids := map[string]uint{}
+	for _, tag := range tagList.Data {
+		counts[tag.Slug] = tag.NotesCount
+		ids[tag.Slug] = tag.ID
+	}
+	assert.Equal(t, map[string]int64{"evergreen": 1, "golang": 1, "go-lang": 1, "seedling": 1}, counts)
+
+	// 3. Filter notes by tag
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?tag=evergreen", nil, cookie)
+	var notes map[string]interface{}
+	json.Unmarshal([]byte(body), &notes)
+	assert.Len(t, notes["data"], 1)
+
+	// 4. Renaming onto an existing slug conflicts; merging combines them
+	resp, _, _ = testutils.MakeRequest(app, "PUT", fmt.Sprintf("/api/v1/admin/tags/%d", ids["go-lang"]), map[string]string{"name": "golang"}, cookie)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, _, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/tags/%d/merge", ids["go-lang"]), map[string]uint{"target_id": ids["golang"]}, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, body, _ = testutils.MakeRequest(app, "GET", "/api/v1/admin/notes?tag=golang", nil, cookie)
+	json.Unmarshal([]byte(body), &notes)
+	assert.Len(t, notes["data"], 2)
+
+	// 5. Updating without tags keeps them, an empty list clears them
+	notePath := fmt.Sprintf("/api/v1/admin/notes/%d", noteID)
+	testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{"title": "Garden Note", "status": "PUBLISHED"}, cookie)
+	var note models.Note
+	database.DB.Preload("Tags").First(&note, noteID)
+	assert.Len(t, note.Tags, 2)
+
+	// 6. Public tag page lists published notes only
+	resp, body, _ = testutils.MakeRequest(app, "GET", "/tags/golang", nil, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Garden Note")
+	assert.NotContains(t, body, "Sprout")
+
+	testutils.MakeRequest(app, "PUT", notePath, map[string]interface{}{"title": "Garden Note", "tags": []string{}}, cookie)
+	database.DB.Preload("Tags").First(&note, noteID)
+	assert.Len(t, note.Tags, 0)
+
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/tags/unknown", nil, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTag_PublicPageSharedSlug(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	// Another user's tag with the same slug was created first, on a draft
+	private := models.Tag{UserID: 2, Name: "Recipes (private)", Slug: "recipes"}
+	database.DB.Create(&private)
+	draft := models.Note{UserID: 2, Title: "Secret Sauce", Slug: "secret-sauce", Status: models.NoteStatusDraft}
+	database.DB.Create(&draft)
+	database.DB.Model(&draft).Association("Tags").Append(&private)
+
+	public := models.Tag{UserID: 1, Name: "Recipes", Slug: "recipes"}
+	database.DB.Create(&public)
+	soup := models.Note{UserID: 1, Title: "Soup", Slug: "soup", Status: models.NoteStatusPublished}
+	database.DB.Create(&soup)
+	database.DB.Model(&soup).Association("Tags").Append(&public)
+
+	resp, body, _ := testutils.MakeRequestWithHeaders(app, "GET", "/tags/recipes", nil, "", map[string]string{"X-Inertia": "true"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var page struct {
+		Props struct {
+			Tag   models.Tag    `json:"tag"`
+			Notes []models.Note `json:"notes"`
+		} `json:"props"`
+	}
+	json.Unmarshal([]byte(body), &page)
+	assert.Equal(t, public.ID, page.Props.Tag.ID)
+	assert.Equal(t, "Recipes", page.Props.Tag.Name)
+	if assert.Len(t, page.Props.Notes, 1) {
+		assert.Equal(t, "Soup", page.Props.Notes[0].Title)
+	}
+
+	// A slug only used on drafts has no public page
+	database.DB.Model(&soup).Update("status", models.NoteStatusDraft)
+	resp, _, _ = testutils.MakeRequest(app, "GET", "/tags/recipes", nil, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
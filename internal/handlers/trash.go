@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/config"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+var trashService = services.NewTrashService()
+
+// trashErrorResponse maps trash service errors to a JSON error response
+func trashErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrTrashItemNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrNotebookExists) {
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "Failed to process trash request"})
+}
+
+// ListTrash returns soft-deleted notes and notebooks
+func ListTrash(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	trash, err := trashService.ListTrash(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch trash"})
+	}
+
+	return c.JSON(fiber.Map{"data": trash})
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func EmptyTrash(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	if err := trashService.EmptyTrash(userID); err != nil {
+		return trashErrorResponse(c, err)
+	}
+
+	return c.SendStatus(204)
+}
+
+// RestoreNote restores a soft-deleted note
+func RestoreNote(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	note, err := trashService.RestoreNote(c.Params("id"), userID)
+	if err != nil {
+		return trashErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": note})
+}
+
+// PurgeNote permanently deletes a soft-deleted note
+func PurgeNote(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	if err := trashService.PurgeNote(c.Params("id"), userID); err != nil {
+		return trashErrorResponse(c, err)
+	}
+
+	return c.SendStatus(204)
+}
+
+// RestoreNotebook restores a soft-deleted notebook and the notes trashed with it
+func RestoreNotebook(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	notebook, restoredNotes, err := trashService.RestoreNotebook(c.Params("id"), userID)
+	if err != nil {
+		return trashErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": notebook, "restored_notes": restoredNotes})
+}
+
+// PurgeNotebook permanently deletes a soft-deleted notebook
+func PurgeNotebook(c *fiber.Ctx) error {
+	sess, _ := config.Store.Get(c)
+	userID := sess.Get("user_id").(uint)
+
+	if err := trashService.PurgeNotebook(c.Params("id"), userID); err != nil {
+		return trashErrorResponse(c, err)
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestTrash_RestoreAndPurge(t *testing.T) {
+	app := testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	user, cookie := seedUserAndLogin(app, "trash@test.com")
+
+	notebook := models.Notebook{UserID: user.ID, Name: "Archive", Slug: "archive"}
+	database.DB.Create(&notebook)
+	earlier := models.Note{UserID: user.ID, NotebookID: &notebook.ID, Title: "Deleted Earlier", Slug: "deleted-earlier"}
+	together := models.Note{UserID: user.ID, NotebookID: &notebook.ID, Title: "Deleted Together", Slug: "deleted-together"}
+	loose := models.Note{UserID: user.ID, Title: "Loose", Slug: "loose"}
+	database.DB.Create(&earlier)
+	database.DB.Create(&together)
+	database.DB.Create(&loose)
+
+	// 1. Trash items: one note first, then the notebook with its remaining note, then a loose note
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notes/%d", earlier.ID), nil, cookie)
+	database.DB.Unscoped().Model(&earlier).Update("deleted_at", time.Now().Add(-time.Hour))
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notebooks/%d?notes=trash", notebook.ID), nil, cookie)
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notes/%d", loose.ID), nil, cookie)
+
+	// 2. List trash
+	resp, body, err := testutils.MakeRequest(app, "GET", "/api/v1/admin/trash", nil, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var trash struct {
+		Data struct {
+			Notes     []models.Note     `json:"notes"`
+			Notebooks []models.Notebook `json:"notebooks"`
+		} `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &trash)
+	assert.Len(t, trash.Data.Notes, 3)
+	assert.Len(t, trash.Data.Notebooks, 1)
+
+	// 3. Restoring the notebook brings back only the note trashed with it
+	resp, body, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/trash/notebooks/%d/restore", notebook.ID), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var restoreResult map[string]interface{}
+	json.Unmarshal([]byte(body), &restoreResult)
+	assert.Equal(t, float64(1), restoreResult["restored_notes"])
+
+	var count int64
+	database.DB.Model(&models.Note{}).Where("notebook_id = ?", notebook.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 4. Restore a single note
+	resp, _, _ = testutils.MakeRequest(app, "POST", fmt.Sprintf("/api/v1/admin/trash/notes/%d/restore", loose.ID), nil, cookie)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Live notes are not in the trash
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/trash/notes/%d", loose.ID), nil, cookie)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// 5. Purge the remaining trashed note
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/trash/notes/%d", earlier.ID), nil, cookie)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	database.DB.Unscoped().Model(&models.Note{}).Where("id = ?", earlier.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 6. Empty trash
+	testutils.MakeRequest(app, "DELETE", fmt.Sprintf("/api/v1/admin/notes/%d", loose.ID), nil, cookie)
+	resp, _, _ = testutils.MakeRequest(app, "DELETE", "/api/v1/admin/trash", nil, cookie)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	database.DB.Unscoped().Model(&models.Note{}).Where("deleted_at IS NOT NULL").Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestTrash_Sweep(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	old := models.Note{UserID: 1, Title: "Old", Slug: "old"}
+	recent := models.Note{UserID: 1, Title: "Recent", Slug: "recent"}
+	database.DB.Create(&old)
+	database.DB.Create(&recent)
+	database.DB.Delete(&old)
+	database.DB.Delete(&recent)
+	database.DB.Unscoped().Model(&old).Update("deleted_at", time.Now().Add(-48*time.Hour))
+
+	err := services.NewTrashService().Sweep(24 * time.Hour)
+	assert.NoError(t, err)
+
+	var ids []uint
+	database.DB.Unscoped().Model(&models.Note{}).Pluck("id", &ids)
+	assert.Equal(t, []uint{recent.ID}, ids)
+}
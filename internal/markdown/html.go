@@ -0,0 +1,592 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// FromHTML converts editor HTML to Markdown.
+// Elements without a Markdown equivalent (layout blocks, highlights, embeds) are kept as raw HTML,
+// so converting the result back with ToHTML gives the same document.
+func FromHTML(input string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(input), context)
+	if err != nil {
+		return "", err
+	}
+
+	blocks := convertBlocks(nodes)
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true,
+	"dl": true, "div": true, "fieldset": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "iframe": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "ul": true,
+}
+
+// convertBlocks turns sibling nodes into Markdown blocks; loose inline content becomes a paragraph
+func convertBlocks(nodes []*html.Node) []string {
+	var inline []*html.Node
+	var out []string
+
+	flush := func() {
+		if text := paragraph(inline); text != "" {
+			out = append(out, text)
+		}
+		inline = nil
+	}
+
+	for _, n := range nodes {
+		switch {
+		case n.Type == html.ElementNode && blockElements[n.Data]:
+			flush()
+			out = append(out, convertBlock(n)...)
+		case n.Type == html.ElementNode || n.Type == html.TextNode:
+			inline = append(inline, n)
+		}
+	}
+	flush()
+	return out
+}
+
+func convertBlock(n *html.Node) []string {
+	switch n.Data {
+	case "p":
+		if text := paragraph(children(n)); text != "" {
+			return []string{text}
+		}
+		return nil
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		text := strings.ReplaceAll(strings.TrimSpace(convertInline(children(n))), hardBreak, " ")
+		return []string{strings.Repeat("#", level) + " " + text}
+	case "hr":
+		return []string{"---"}
+	case "pre":
+		return []string{codeBlock(n)}
+	case "blockquote":
+		inner := strings.Join(convertBlocks(children(n)), "\n\n")
+		return []string{prefixLines(inner, "> ", ">")}
+	case "ul", "ol":
+		return []string{list(n)}
+	case "table":
+		if text, ok := table(n); ok {
+			return []string{text}
+		}
+	case "div":
+		// Plain wrappers (e.g. the content of a Tiptap task item) are transparent
+		if len(n.Attr) == 0 {
+			return convertBlocks(children(n))
+		}
+	}
+	return []string{rawBlock(n)}
+}
+
+const hardBreak = "\\\n"
+
+// paragraph converts inline nodes and escapes anything that would read as block syntax
+func paragraph(nodes []*html.Node) string {
+	text := strings.TrimSpace(convertInline(nodes))
+	// A trailing <br> leaves an odd backslash behind (escaped backslashes come in pairs)
+	for (len(text)-len(strings.TrimRight(text, "\\")))%2 == 1 {
+		text = strings.TrimSpace(text[:len(text)-1])
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = escapeLineStart(strings.TrimLeft(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var orderedMarkerPattern = regexp.MustCompile(`^(\d{1,9})([.)])`)
+
+func escapeLineStart(line string) string {
+	if line == "" {
+		return line
+	}
+	switch line[0] {
+	case '#', '>', '+', '-', '=', '|':
+		return "\\" + line
+	}
+	if m := orderedMarkerPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + "\\" + line[len(m[1]):]
+	}
+	return line
+}
+
+func codeBlock(pre *html.Node) string {
+	source := pre
+	language := ""
+	for c := pre.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "code" {
+			source = c
+			for _, class := range strings.Fields(attr(c, "class")) {
+				if strings.HasPrefix(class, "language-") {
+					language = strings.TrimPrefix(class, "language-")
+				}
+			}
+			break
+		}
+	}
+
+	code := strings.TrimSuffix(textContent(source), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+var listMarkerPattern = regexp.MustCompile(`^(- |\d+\. )`)
+
+func list(n *html.Node) string {
+	ordered := n.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && ordered {
+		number = start
+	}
+
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		checked, isTask := taskState(li)
+		content := children(li)
+		if isTask {
+			content = withoutCheckbox(content)
+		}
+
+		// Nested lists stay attached to the item; other blocks are separated by a blank line
+		blocks := convertBlocks(content)
+		body := ""
+		for i, block := range blocks {
+			if i > 0 {
+				if listMarkerPattern.MatchString(block) {
+					body += "\n"
+				} else {
+					body += "\n\n"
+				}
+			}
+			body += block
+		}
+
+		prefix := marker
+		if isTask {
+			if checked {
+				prefix += "[x] "
+			} else {
+				prefix += "[ ] "
+			}
+		}
+		items = append(items, prefix+indentLines(body, len(marker)))
+	}
+	return strings.Join(items, "\n")
+}
+
+// taskState reads a task item in Tiptap form (data-type="taskItem") or a leading checkbox input
+func taskState(li *html.Node) (checked bool, ok bool) {
+	if attr(li, "data-type") == "taskItem" {
+		return attr(li, "data-checked") == "true", true
+	}
+	for c := li.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		if input := checkbox(c); input != nil {
+			return hasAttr(input, "checked"), true
+		}
+		return false, false
+	}
+	return false, false
+}
+
+// checkbox returns the checkbox input n is or wraps (Tiptap puts it in a <label>)
+func checkbox(n *html.Node) *html.Node {
+	if n.Type != html.ElementNode {
+		return nil
+	}
+	if n.Data == "input" && attr(n, "type") == "checkbox" {
+		return n
+	}
+	if n.Data == "label" {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if input := checkbox(c); input != nil {
+				return input
+			}
+		}
+	}
+	return nil
+}
+
+func withoutCheckbox(nodes []*html.Node) []*html.Node {
+	for i, n := range nodes {
+		if checkbox(n) != nil {
+			return append(append([]*html.Node{}, nodes[:i]...), nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
+
+// table writes a GFM table; tables with merged or block-level cells are not representable
+func table(n *html.Node) (string, bool) {
+	var rows [][]*html.Node
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "thead", "tbody", "tfoot":
+				walk(c)
+			case "tr":
+				var cells []*html.Node
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "th" || cell.Data == "td") {
+						cells = append(cells, cell)
+					}
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return "", false
+	}
+
+	columns := 0
+	for _, row := range rows {
+		for _, cell := range row {
+			if span := attr(cell, "colspan"); span != "" && span != "1" {
+				return "", false
+			}
+			if span := attr(cell, "rowspan"); span != "" && span != "1" {
+				return "", false
+			}
+		}
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		values := make([]string, columns)
+		for j, cell := range row {
+			value, ok := tableCell(cell)
+			if !ok {
+				return "", false
+			}
+			values[j] = value
+		}
+		lines = append(lines, "| "+strings.Join(values, " | ")+" |")
+
+		if i == 0 {
+			delimiters := make([]string, columns)
+			for j := range delimiters {
+				delimiters[j] = "---"
+				if j < len(row) {
+					delimiters[j] = alignmentDelimiter(row[j])
+				}
+			}
+			lines = append(lines, "| "+strings.Join(delimiters, " | ")+" |")
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// tableCell converts a cell's content to a single line; Tiptap wraps cell text in paragraphs
+func tableCell(cell *html.Node) (string, bool) {
+	var parts []string
+	var inline []*html.Node
+	flush := func() {
+		if len(inline) > 0 {
+			parts = append(parts, strings.TrimSpace(convertInline(inline)))
+			inline = nil
+		}
+	}
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockElements[c.Data] {
+			if c.Data != "p" {
+				return "", false
+			}
+			flush()
+			parts = append(parts, strings.TrimSpace(convertInline(children(c))))
+			continue
+		}
+		inline = append(inline, c)
+	}
+	flush()
+
+	value := strings.Join(parts, "<br>")
+	value = strings.ReplaceAll(value, hardBreak, "<br>")
+	return strings.ReplaceAll(value, "|", "\\|"), true
+}
+
+func alignmentDelimiter(cell *html.Node) string {
+	align := attr(cell, "align")
+	for _, declaration := range strings.Split(attr(cell, "style"), ";") {
+		if property, value, ok := strings.Cut(declaration, ":"); ok && strings.TrimSpace(property) == "text-align" {
+			align = strings.TrimSpace(value)
+		}
+	}
+	switch align {
+	case "left":
+		return ":---"
+	case "right":
+		return "---:"
+	case "center":
+		return ":---:"
+	}
+	return "---"
+}
+
+func convertInline(nodes []*html.Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		writeInline(&b, n)
+	}
+	return b.String()
+}
+
+var whitespacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+func writeInline(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(escapeText(whitespacePattern.ReplaceAllString(n.Data, " ")))
+		return
+	}
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	switch n.Data {
+	case "strong", "b":
+		emphasis(b, "**", n)
+	case "em", "i":
+		emphasis(b, "*", n)
+	case "s", "del", "strike":
+		emphasis(b, "~~", n)
+	case "code":
+		b.WriteString(codeSpan(textContent(n)))
+	case "br":
+		b.WriteString(hardBreak)
+	case "a":
+		link(b, n)
+	case "img":
+		b.WriteString("![" + escapeText(attr(n, "alt")) + "](" + destination(attr(n, "src")) + title(n) + ")")
+	case "input":
+		// Stray checkboxes outside task lists carry no text
+	case "span", "label":
+		if len(n.Attr) == 0 {
+			b.WriteString(convertInline(children(n)))
+			return
+		}
+		b.WriteString(rawInline(n))
+	default:
+		b.WriteString(rawInline(n))
+	}
+}
+
+// emphasis wraps content in a delimiter, moving edge spaces outside so the delimiters still apply
+func emphasis(b *strings.Builder, delimiter string, n *html.Node) {
+	inner := convertInline(children(n))
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		b.WriteString(inner)
+		return
+	}
+	if strings.HasPrefix(inner, " ") {
+		b.WriteString(" ")
+	}
+	b.WriteString(delimiter + trimmed + delimiter)
+	if strings.HasSuffix(inner, " ") {
+		b.WriteString(" ")
+	}
+}
+
+var autolinkPattern = regexp.MustCompile(`^(https?://|mailto:)[^\s<>]+$`)
+
+func link(b *strings.Builder, n *html.Node) {
+	href := attr(n, "href")
+	text := convertInline(children(n))
+	if text == escapeText(href) && autolinkPattern.MatchString(href) && !hasAttr(n, "title") {
+		b.WriteString("<" + href + ">")
+		return
+	}
+	b.WriteString("[" + text + "](" + destination(href) + title(n) + ")")
+}
+
+func destination(url string) string {
+	if url == "" || strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+func title(n *html.Node) string {
+	value := attr(n, "title")
+	if value == "" {
+		return ""
+	}
+	return ` "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func codeSpan(code string) string {
+	code = strings.ReplaceAll(code, "\n", " ")
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") ||
+		(strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") && strings.TrimSpace(code) != "") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+var entityPattern = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+
+// escapeText backslash-escapes characters that would start inline Markdown syntax.
+// [[wikilinks]] are left as they are so links keep working in Markdown notes.
+func escapeText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '\\', '*', '`', '~':
+			b.WriteByte('\\')
+		case '_':
+			if i == 0 || i == len(text)-1 || !isWordByte(text[i-1]) || !isWordByte(text[i+1]) {
+				b.WriteByte('\\')
+			}
+		case '[', ']':
+			if !strings.HasPrefix(text[i:], string([]byte{c, c})) && (i == 0 || text[i-1] != c) {
+				b.WriteByte('\\')
+			}
+		case '<':
+			if i+1 < len(text) && (isLetter(text[i+1]) || strings.ContainsRune("/!?", rune(text[i+1]))) {
+				b.WriteByte('\\')
+			}
+		case '&':
+			if entityPattern.MatchString(text[i:]) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isWordByte(c byte) bool {
+	return isLetter(c) || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+var blankLinePattern = regexp.MustCompile(`\n[ \t]*\n`)
+
+// rawBlock keeps an element as an HTML block; blank lines would end the block early
+func rawBlock(n *html.Node) string {
+	return blankLinePattern.ReplaceAllString(strings.TrimSpace(rawInline(n)), "\n")
+}
+
+func rawInline(n *html.Node) string {
+	var buf bytes.Buffer
+	_ = html.Render(&buf, n)
+	return buf.String()
+}
+
+func prefixLines(text, prefix, blankPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blankPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentLines indents every line after the first, leaving blank lines empty
+func indentLines(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = strings.Repeat(" ", width) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func children(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, c)
+	}
+	return nodes
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if n.Type == html.ElementNode && n.Data == "br" {
+		return "\n"
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, name string) bool {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Package markdown converts between Markdown and the HTML produced by the Tiptap editor.
+//
+// Note content is stored as HTML; Markdown is an alternative input and output format.
+// Both directions cover CommonMark plus the GFM tables, task lists and strikethrough.
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var converter = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough),
+	goldmark.WithParserOptions(
+		parser.WithInlineParsers(util.Prioritized(extension.NewTaskCheckBoxParser(), 0)),
+		parser.WithASTTransformers(util.Prioritized(taskListTransformer{}, 0)),
+	),
+	goldmark.WithRendererOptions(
+		// Raw HTML is kept; callers sanitize the result before storing it
+		html.WithUnsafe(),
+		renderer.WithNodeRenderers(util.Prioritized(taskCheckBoxRenderer{}, 0)),
+	),
+)
+
+// ToHTML renders Markdown as editor HTML.
+// Task lists use Tiptap's markup: <ul data-type="taskList"><li data-type="taskItem" data-checked="true">.
+func ToHTML(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// taskListTransformer marks lists whose items all start with a checkbox the way Tiptap does
+type taskListTransformer struct{}
+
+func (taskListTransformer) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		list, ok := n.(*ast.List)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+
+		items := []ast.Node{}
+		for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+			if taskCheckBox(item) == nil {
+				return ast.WalkContinue, nil
+			}
+			items = append(items, item)
+		}
+		if len(items) == 0 {
+			return ast.WalkContinue, nil
+		}
+
+		list.SetAttributeString("data-type", []byte("taskList"))
+		for _, item := range items {
+			checked := "false"
+			if taskCheckBox(item).IsChecked {
+				checked = "true"
+			}
+			item.SetAttributeString("data-type", []byte("taskItem"))
+			item.SetAttributeString("data-checked", []byte(checked))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// taskCheckBox returns the checkbox opening a list item, if any
+func taskCheckBox(item ast.Node) *extast.TaskCheckBox {
+	block := item.FirstChild()
+	if block == nil {
+		return nil
+	}
+	box, _ := block.FirstChild().(*extast.TaskCheckBox)
+	return box
+}
+
+// taskCheckBoxRenderer writes the checkbox label Tiptap renders inside task items
+type taskCheckBoxRenderer struct{}
+
+func (r taskCheckBoxRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(extast.KindTaskCheckBox, r.render)
+}
+
+func (taskCheckBoxRenderer) render(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	if n.(*extast.TaskCheckBox).IsChecked {
+		_, _ = w.WriteString(`<label><input type="checkbox" checked="checked"><span></span></label>`)
+	} else {
+		_, _ = w.WriteString(`<label><input type="checkbox"><span></span></label>`)
+	}
+	return ast.WalkContinue, nil
+}
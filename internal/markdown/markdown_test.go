@@ -0,0 +1,132 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTML_TiptapMarkup(t *testing.T) {
+	out, err := ToHTML("- [x] Done\n- [ ] Todo\n\n```go\nfmt.Println(1)\n```\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`<ul data-type="taskList">`,
+		`<li data-type="taskItem" data-checked="true"><label><input type="checkbox" checked="checked"><span></span></label>Done</li>`,
+		`<li data-type="taskItem" data-checked="false"><label><input type="checkbox"><span></span></label>Todo</li>`,
+		`<pre><code class="language-go">fmt.Println(1)`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	// A list mixing tasks and plain items stays a plain list
+	out, _ = ToHTML("- [x] Done\n- Plain\n")
+	if strings.Contains(out, "taskList") {
+		t.Errorf("mixed list became a task list:\n%s", out)
+	}
+}
+
+// Markdown -> HTML -> Markdown gives back the same source
+func TestRoundTrip_Markdown(t *testing.T) {
+	cases := map[string]string{
+		"inline":         "Some **bold**, *italic*, ~~struck~~ and `code` with a [link](https://example.com \"Example\") and <https://example.org>.\n",
+		"headings":       "# Title\n\n## Section\n\nText after.\n",
+		"table":          "| Name | Qty | Note |\n| :--- | ---: | :---: |\n| apple | 3 | fresh \\| ripe |\n| pear | 10 | *soft* |\n",
+		"task list":      "- [x] Write the draft\n- [ ] Review it\n",
+		"nested lists":   "1. First\n2. Second\n   - Nested\n   - Also nested\n",
+		"code blocks":    "```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n```\nplain\n```\n\n````md\n```\nfenced\n```\n````\n",
+		"images":         "![A cat](/public/uploads/cat.png \"Cover\")\n\n![](https://example.com/a%20b.png)\n",
+		"quote and rule": "> Quoted **text**\n>\n> Second paragraph\n\n---\n\nAfter.\n",
+		"wikilinks":      "See [[Compost Basics]] and [[Soil]].\n",
+		"escapes":        "1\\. not a list\n\n\\# not a heading\n\nliteral \\*stars\\* and snake_case\n",
+		"raw html":       "Text with <mark>highlight</mark> inside.\n",
+		"hard break":     "line one\\\nline two\n",
+	}
+
+	for name, source := range cases {
+		t.Run(name, func(t *testing.T) {
+			html, err := ToHTML(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			back, err := FromHTML(html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if back != source {
+				t.Errorf("round trip changed the source\nsource:\n%s\nhtml:\n%s\nback:\n%s", source, html, back)
+			}
+		})
+	}
+}
+
+// Editor HTML -> Markdown -> HTML keeps the structure Tiptap needs
+func TestRoundTrip_TiptapHTML(t *testing.T) {
+	input := `<h2>Plan</h2>` +
+		`<ul data-type="taskList">` +
+		`<li data-checked="true" data-type="taskItem"><label><input type="checkbox" checked="checked"><span></span></label><div><p>Buy seeds</p></div></li>` +
+		`<li data-checked="false" data-type="taskItem"><label><input type="checkbox"><span></span></label><div><p>Plant them</p></div></li>` +
+		`</ul>` +
+		`<table><tbody><tr><th colspan="1" rowspan="1"><p>Bed</p></th><th colspan="1" rowspan="1"><p>Crop</p></th></tr>` +
+		`<tr><td colspan="1" rowspan="1"><p>North</p></td><td colspan="1" rowspan="1"><p>Beans</p></td></tr></tbody></table>` +
+		`<pre><code class="language-sql">SELECT *
+FROM beds;</code></pre>` +
+		`<p><img src="/public/uploads/bed.jpg" alt="Bed"></p>` +
+		`<div data-layout-type="two-column" class="grid grid-cols-2"><div class="col"><p>Left</p></div></div>`
+
+	md, err := FromHTML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "## Plan\n\n" +
+		"- [x] Buy seeds\n- [ ] Plant them\n\n" +
+		"| Bed | Crop |\n| --- | --- |\n| North | Beans |\n\n" +
+		"```sql\nSELECT *\nFROM beds;\n```\n\n" +
+		"![Bed](/public/uploads/bed.jpg)\n\n" +
+		`<div data-layout-type="two-column" class="grid grid-cols-2"><div class="col"><p>Left</p></div></div>` + "\n"
+	if md != want {
+		t.Fatalf("unexpected markdown:\n%s\nwant:\n%s", md, want)
+	}
+
+	out, err := ToHTML(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fragment := range []string{
+		`<h2>Plan</h2>`,
+		`<li data-type="taskItem" data-checked="true">`,
+		`<li data-type="taskItem" data-checked="false">`,
+		`<th>Bed</th>`,
+		`<td>Beans</td>`,
+		`<pre><code class="language-sql">SELECT *
+FROM beds;
+</code></pre>`,
+		`<img src="/public/uploads/bed.jpg" alt="Bed">`,
+		`<div data-layout-type="two-column" class="grid grid-cols-2">`,
+	} {
+		if !strings.Contains(out, fragment) {
+			t.Errorf("expected %q in:\n%s", fragment, out)
+		}
+	}
+
+	// A second pass is stable
+	again, _ := FromHTML(out)
+	if again != md {
+		t.Errorf("second round trip differs:\n%s\nwant:\n%s", again, md)
+	}
+}
+
+func TestFromHTML_UnrepresentableTablesStayHTML(t *testing.T) {
+	input := `<table><tbody><tr><td colspan="2"><p>Wide</p></td></tr></tbody></table>`
+	md, err := FromHTML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(md, "<table>") || !strings.Contains(md, `colspan="2"`) {
+		t.Errorf("expected the table as raw HTML, got:\n%s", md)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects requests whose body is larger than limit, or than the limit given for their
+// path in routes, with 413. The server streams request bodies (StreamRequestBody) instead of
+// rejecting them at its own limit, so this must run before anything reads the body.
+// Chunked bodies have no length up front; they are read into memory and held to limit on every route.
+func BodyLimit(limit int, routes map[string]int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		max := limit
+		if n, ok := routes[c.Path()]; ok {
+			max = n
+		}
+
+		// The rest of a rejected body is never read, so the connection can't be reused
+		length := c.Request().Header.ContentLength()
+		if length > max {
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+		if length < 0 && c.Request().IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(c.Context().RequestBodyStream(), int64(limit)+1))
+			if err != nil {
+				return fiber.ErrBadRequest
+			}
+			if len(body) > limit {
+				c.Context().SetConnectionClose()
+				return fiber.ErrRequestEntityTooLarge
+			}
+			c.Request().SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of marks and the items they go on
+const (
+	MarkPin      = "pin"
+	MarkFavorite = "favorite"
+
+	MarkItemNote     = "note"
+	MarkItemNotebook = "notebook"
+)
+
+// Mark is a user's pin or favorite on one of their notes or notebooks.
+// Unlike Note.IsFeatured, which picks notes for the public homepage, marks are private.
+type Mark struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	ItemType  string    `gorm:"primaryKey;size:16" json:"item_type"` // MarkItemNote or MarkItemNotebook
+	ItemID    uint      `gorm:"primaryKey;autoIncrement:false" json:"item_id"`
+	Kind      string    `gorm:"primaryKey;size:16" json:"kind"` // MarkPin or MarkFavorite
+	CreatedAt time.Time `json:"created_at"`
+}
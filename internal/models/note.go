@@ -6,24 +6,56 @@ import (
 	"gorm.io/gorm"
 )
 
+// Note statuses
+const (
+	NoteStatusDraft     = "DRAFT"
+	NoteStatusScheduled = "SCHEDULED" // Waiting for PublishAt
+	NoteStatusPublished = "PUBLISHED"
+	NoteStatusArchived  = "ARCHIVED"
+)
+
+// Content formats a note can be authored in. Content always holds the rendered HTML.
+const (
+	ContentFormatHTML     = "html"
+	ContentFormatMarkdown = "markdown"
+)
+
 type Note struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	UserID      uint           `gorm:"index" json:"user_id"`
-	NotebookID  *uint          `gorm:"index;default:null" json:"notebook_id"`
-	Title       string         `json:"title"`
-	Slug        string         `gorm:"uniqueIndex" json:"slug"`
-	Excerpt     string         `json:"excerpt"`
-	Content     string         `json:"content"` // longText in GORM usually maps to string
-	CoverImage  string         `json:"cover_image"`
-	Status      string         `gorm:"default:'DRAFT'" json:"status"`
-	PublishedAt *time.Time     `json:"published_at"`
-	Views       int            `gorm:"default:0" json:"views"`
-	IsFeatured  bool           `gorm:"default:false" json:"is_featured"` // Added based on seen migrations list earlier
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	UserID        uint           `gorm:"index" json:"user_id"`
+	NotebookID    *uint          `gorm:"index;default:null" json:"notebook_id"`
+	Title         string         `json:"title"`
+	Slug          string         `gorm:"uniqueIndex" json:"slug"`
+	Excerpt       string         `json:"excerpt"`
+	AutoExcerpt   bool           `gorm:"default:false" json:"auto_excerpt"` // Excerpt was derived from Content and follows it
+	WordCount     int            `gorm:"default:0" json:"word_count"`
+	ReadingTime   int            `gorm:"default:0" json:"reading_time"`        // Estimated minutes
+	Content       string         `json:"content"`                              // longText in GORM usually maps to string
+	ContentFormat string         `gorm:"default:'html'" json:"content_format"` // Format the note is written in (html or markdown)
+	Markdown      string         `json:"-"`                                    // Source of markdown notes; Content holds the rendered HTML
+	CoverImage    string         `json:"cover_image"`
+	Status        string         `gorm:"default:'DRAFT'" json:"status"`
+	PublishedAt   *time.Time     `json:"published_at"`
+	PublishAt     *time.Time     `gorm:"index" json:"publish_at"`   // Scheduled publish time (status SCHEDULED)
+	UnpublishAt   *time.Time     `gorm:"index" json:"unpublish_at"` // Optional time to archive a published note
+	Views         int            `gorm:"default:0" json:"views"`
+	IsFeatured    bool           `gorm:"default:false" json:"is_featured"`   // Added based on seen migrations list earlier
+	IsTemplate    bool           `gorm:"default:false" json:"is_template"`   // Can be instantiated into new notes with placeholders expanded
+	Position      float64        `gorm:"not null;default:0" json:"position"` // Manual order within the notebook, ascending
+	Version       uint           `gorm:"not null;default:1" json:"version"`  // Bumped on every edit, served as the ETag
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	// Search match excerpt (HTML-escaped, matches wrapped in <mark>), only set on search results
+	Snippet string `gorm:"-" json:"snippet,omitempty"`
+
+	// The requesting user's marks, set by the admin listings
+	Pinned   bool `gorm:"-" json:"pinned"`
+	Favorite bool `gorm:"-" json:"favorite"`
 
 	// Relations
 	User     User      `json:"user,omitempty"`
 	Notebook *Notebook `json:"notebook,omitempty"`
+	Tags     []Tag     `gorm:"many2many:note_tags;" json:"tags,omitempty"`
 }
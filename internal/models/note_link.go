@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// NoteLink is a [[wiki link]] found in a note's content.
+// TargetNoteID is nil while the link does not resolve to an existing note.
+type NoteLink struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	SourceNoteID uint      `gorm:"index" json:"source_note_id"`
+	TargetNoteID *uint     `gorm:"index;default:null" json:"target_note_id"`
+	Target       string    `json:"target"` // Raw text inside the brackets (title or slug)
+	Label        string    `json:"label"`  // Optional display text from [[target|label]]
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relations
+	SourceNote *Note `gorm:"foreignKey:SourceNoteID" json:"source_note,omitempty"`
+	TargetNote *Note `gorm:"foreignKey:TargetNoteID" json:"target_note,omitempty"`
+	Broken     bool  `gorm:"-" json:"broken"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// Revision kinds
+const (
+	RevisionKindEdit    = "edit"
+	RevisionKindRestore = "restore" // Left by RestoreRevision; later saves never overwrite it
+)
+
+// NoteRevision is an immutable snapshot of a note's editable content.
+// A revision is written on every save so earlier versions can be diffed and restored.
+type NoteRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	NoteID    uint      `gorm:"index" json:"note_id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	Title     string    `json:"title"`
+	Excerpt   string    `json:"excerpt"`
+	Content   string    `json:"content"`
+	Kind      string    `gorm:"size:16;not null;default:edit" json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// NoteDailyViews counts the views of a note on one UTC day
+type NoteDailyViews struct {
+	NoteID uint   `gorm:"primaryKey;autoIncrement:false" json:"note_id"`
+	Day    string `gorm:"primaryKey;size:10" json:"day"` // YYYY-MM-DD
+	Views  int    `gorm:"not null;default:0" json:"views"`
+}
+
+func (NoteDailyViews) TableName() string {
+	return "note_daily_views"
+}
@@ -9,14 +9,23 @@ import (
 type Notebook struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	UserID      uint           `gorm:"index" json:"user_id"`
+	ParentID    *uint          `gorm:"index" json:"parent_id"` // Nil for top-level notebooks
 	Name        string         `json:"name"`
 	Slug        string         `gorm:"uniqueIndex" json:"slug"`
 	Description string         `json:"description"`
+	Position    float64        `gorm:"not null;default:0" json:"position"` // Manual order among siblings, ascending
+	Version     uint           `gorm:"not null;default:1" json:"version"`  // Bumped on every edit, served as the ETag
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
 	// Relations
-	User       User  `json:"user,omitempty"`
-	NotesCount int64 `gorm:"-" json:"notes_count"`
+	User            User       `json:"user,omitempty"`
+	NotesCount      int64      `gorm:"-" json:"notes_count"`
+	TotalNotesCount int64      `gorm:"-" json:"total_notes_count"` // Including the notebooks below
+	Children        []Notebook `gorm:"-" json:"children,omitempty"`
+
+	// The requesting user's marks, set by the admin listings
+	Pinned   bool `gorm:"-" json:"pinned"`
+	Favorite bool `gorm:"-" json:"favorite"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// SlugHistory remembers slugs a note used to have so old URLs can redirect
+type SlugHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	NoteID    uint      `gorm:"index" json:"note_id"`
+	Slug      string    `gorm:"uniqueIndex" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SlugHistory) TableName() string {
+	return "slug_history"
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Tag groups notes across notebooks (many-to-many through note_tags).
+// Slugs are unique per user so public /tags/:slug pages can span authors.
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_tags_user_slug" json:"user_id"`
+	Name      string    `json:"name"`
+	Slug      string    `gorm:"uniqueIndex:idx_tags_user_slug" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Notes      []Note `gorm:"many2many:note_tags;" json:"-"`
+	NotesCount int64  `gorm:"-" json:"notes_count"`
+}
@@ -8,20 +8,73 @@ import (
 
 // SetupAPI routes
 func SetupAPI(app *fiber.App) {
+	// Public API
+	app.Get("/api/v1/search", handlers.PublicSearch).Name("api.search")
+
 	// API Group (Protected)
 	api := app.Group("/api/v1/admin", middleware.Protected)
 
 	// Notebooks
 	api.Get("/notebooks", handlers.ListNotebooks).Name("api.notebooks.index")
 	api.Post("/notebooks", handlers.CreateNotebook).Name("api.notebooks.store")
+	api.Post("/notebooks/reorder", handlers.ReorderNotebooks).Name("api.notebooks.reorder")
 	api.Put("/notebooks/:id", handlers.UpdateNotebook).Name("api.notebooks.update")
+	api.Patch("/notebooks/:id", handlers.PatchNotebook).Name("api.notebooks.patch")
+	api.Post("/notebooks/:id/move", handlers.MoveNotebook).Name("api.notebooks.move")
+	api.Post("/notebooks/:id/pin", handlers.ToggleNotebookPin).Name("api.notebooks.pin")
+	api.Post("/notebooks/:id/favorite", handlers.ToggleNotebookFavorite).Name("api.notebooks.favorite")
 	api.Delete("/notebooks/:id", handlers.DeleteNotebook).Name("api.notebooks.destroy")
 
+	// Tags
+	api.Get("/tags", handlers.ListTags).Name("api.tags.index")
+	api.Post("/tags", handlers.CreateTag).Name("api.tags.store")
+	api.Put("/tags/:id", handlers.UpdateTag).Name("api.tags.update")
+	api.Post("/tags/:id/merge", handlers.MergeTag).Name("api.tags.merge")
+	api.Delete("/tags/:id", handlers.DeleteTag).Name("api.tags.destroy")
+
 	// Notes
 	api.Get("/notes", handlers.ListNotes).Name("api.notes.index")
 	api.Post("/notes", handlers.CreateNote).Name("api.notes.store")
+	api.Post("/notes/reorder", handlers.ReorderNotes).Name("api.notes.reorder")
+	api.Get("/notes/:id", handlers.ShowNote).Name("api.notes.show")
 	api.Put("/notes/:id", handlers.UpdateNote).Name("api.notes.update")
+	api.Patch("/notes/:id", handlers.PatchNote).Name("api.notes.patch")
 	api.Delete("/notes/:id", handlers.DeleteNote).Name("api.notes.destroy")
+	api.Post("/notes/:id/pin", handlers.ToggleNotePin).Name("api.notes.pin")
+	api.Post("/notes/:id/favorite", handlers.ToggleNoteFavorite).Name("api.notes.favorite")
+	api.Post("/notes/:id/duplicate", handlers.DuplicateNote).Name("api.notes.duplicate")
+	api.Post("/notes/:id/instantiate", handlers.InstantiateTemplate).Name("api.notes.instantiate")
+
+	// Favorites
+	api.Get("/favorites", handlers.ListFavorites).Name("api.favorites")
+
+	// Note Links
+	api.Get("/notes/:id/links", handlers.ListNoteLinks).Name("api.notes.links")
+	api.Get("/notes/:id/backlinks", handlers.ListBacklinks).Name("api.notes.backlinks")
+	api.Get("/links/broken", handlers.ListBrokenLinks).Name("api.links.broken")
+
+	// Note Revisions
+	api.Get("/notes/:id/revisions", handlers.ListRevisions).Name("api.notes.revisions.index")
+	api.Get("/notes/:id/revisions/diff", handlers.DiffRevisions).Name("api.notes.revisions.diff")
+	api.Get("/notes/:id/revisions/:revisionId", handlers.ShowRevision).Name("api.notes.revisions.show")
+	api.Post("/notes/:id/revisions/:revisionId/restore", handlers.RestoreRevision).Name("api.notes.revisions.restore")
+
+	// Trash
+	api.Get("/trash", handlers.ListTrash).Name("api.trash.index")
+	api.Delete("/trash", handlers.EmptyTrash).Name("api.trash.empty")
+	api.Post("/trash/notes/:id/restore", handlers.RestoreNote).Name("api.trash.notes.restore")
+	api.Delete("/trash/notes/:id", handlers.PurgeNote).Name("api.trash.notes.purge")
+	api.Post("/trash/notebooks/:id/restore", handlers.RestoreNotebook).Name("api.trash.notebooks.restore")
+	api.Delete("/trash/notebooks/:id", handlers.PurgeNotebook).Name("api.trash.notebooks.purge")
+
+	// Export / Import
+	api.Get("/export", handlers.ExportNotes).Name("api.export")
+	api.Post("/import", handlers.ImportNotes).Name("api.import")
+	api.Post("/import/enex", handlers.ImportEnex).Name("api.import.enex")
+	api.Get("/import/jobs/:id", handlers.ImportJob).Name("api.import.jobs.show")
+
+	// Stats
+	api.Get("/stats/views", handlers.ViewStats).Name("api.stats.views")
 
 	// Uploads
 	api.Post("/upload", handlers.UploadImage).Name("api.upload")
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tarakreasi/taraNote_go/internal/middleware"
+)
+
+// Imports upload whole archives; Evernote exports can run to hundreds of MB
+const (
+	importBodyLimit = 100 << 20
+	enexBodyLimit   = 1 << 30
+)
+
+// bodyLimits are the routes allowed past Fiber's default body limit
+var bodyLimits = map[string]int{
+	"/api/v1/admin/import":      importBodyLimit,
+	"/api/v1/admin/import/enex": enexBodyLimit,
+}
+
+// SetupLimits enforces the request body limits. Register it before any middleware that reads the body.
+func SetupLimits(app *fiber.App) {
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, bodyLimits))
+}
@@ -9,9 +9,24 @@ import (
 // SetupWeb routes
 func SetupWeb(app *fiber.App) {
 	// Guest Routes (Public)
-	app.Get("/", handlers.PublicList).Name("home")                        // Home/Articles List
-	app.Get("/articles/:slug", handlers.PublicShow).Name("articles.show") // Single Article
-	app.Get("/taranote", handlers.TaraNoteBrowser).Name("taranote")       // 3-Column Browser
+	app.Get("/", handlers.PublicList).Name("home")                              // Home/Articles List
+	app.Get("/articles/:slug", handlers.PublicShow).Name("articles.show")       // Single Article
+	app.Get("/taranote", handlers.TaraNoteBrowser).Name("taranote")             // 3-Column Browser
+	app.Get("/tags/:slug", handlers.PublicTag).Name("tags.show")                // Published Notes by Tag
+	app.Get("/notebooks/:slug", handlers.PublicNotebook).Name("notebooks.show") // Browser on a Notebook
+
+	// Feeds
+	app.Get("/feed.xml", handlers.RSSFeed).Name("feed.rss")
+	app.Get("/atom.xml", handlers.AtomFeed).Name("feed.atom")
+	app.Get("/notebooks/:slug/feed.xml", handlers.RSSFeed).Name("notebooks.feed.rss")
+	app.Get("/notebooks/:slug/atom.xml", handlers.AtomFeed).Name("notebooks.feed.atom")
+	app.Get("/authors/:username/feed.xml", handlers.RSSFeed).Name("authors.feed.rss")
+	app.Get("/authors/:username/atom.xml", handlers.AtomFeed).Name("authors.feed.atom")
+
+	// Crawlers
+	app.Get("/sitemap.xml", handlers.Sitemap).Name("sitemap")
+	app.Get("/sitemap-:page.xml", handlers.SitemapPage).Name("sitemap.page")
+	app.Get("/robots.txt", handlers.RobotsTxt).Name("robots")
 
 	// Auth Routes
 	app.Get("/login", handlers.ShowLogin).Name("login.view")
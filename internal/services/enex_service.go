@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/enex"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+// MaxEnexResourceSize bounds a single attachment of an ENEX note
+const MaxEnexResourceSize = 50 << 20
+
+// Extensions attachments are stored with; anything else (e.g. .html or .svg, which would be served
+// from our origin and can run scripts) is stored as .bin
+var enexExtensions = map[string]string{
+	"image/png": ".png", "image/jpeg": ".jpg", "image/gif": ".gif", "image/webp": ".webp", "image/bmp": ".bmp",
+	"application/pdf": ".pdf", "audio/mpeg": ".mp3", "audio/wav": ".wav", "audio/mp4": ".m4a",
+	"video/mp4": ".mp4", "text/plain": ".txt", "application/zip": ".zip",
+}
+
+// EnexProgress counts what an ENEX import has done so far
+type EnexProgress struct {
+	Read      int64    `json:"read"` // Bytes of the export processed
+	Notes     int      `json:"notes"`
+	Created   int      `json:"created"`
+	Skipped   int      `json:"skipped"` // Same title and creation time already in the notebook
+	Resources int      `json:"resources"`
+	Errors    []string `json:"errors"` // Notes that failed; the import goes on without them
+}
+
+type EnexOptions struct {
+	UserID   uint
+	Notebook string             // Notebook for the notes (ENEX exports don't name it); empty leaves them outside notebooks
+	Progress func(EnexProgress) // Called after every note
+}
+
+type EnexService struct {
+	uploadsDir string
+	revisions  *RevisionService
+}
+
+func NewEnexService(uploadsDir string) *EnexService {
+	return &EnexService{uploadsDir: uploadsDir, revisions: NewRevisionService(SystemClock)}
+}
+
+// Import streams an Evernote export note by note: ENML is converted to editor HTML, resources
+// are written to the uploads directory, and timestamps and tags are kept. Each note is saved in
+// its own transaction, so a bad note is reported and skipped. Re-importing the same export skips
+// the notes it already created.
+func (s *EnexService) Import(r io.Reader, opts EnexOptions) (*EnexProgress, error) {
+	progress := &EnexProgress{Errors: []string{}}
+
+	notebookID, err := s.notebook(opts.UserID, opts.Notebook)
+	if err != nil {
+		return progress, err
+	}
+	existing, err := s.existingNotes(opts.UserID, notebookID)
+	if err != nil {
+		return progress, err
+	}
+
+	reader := enex.NewReader(r, MaxEnexResourceSize)
+	for {
+		note, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return progress, fmt.Errorf("reading export: %w", err)
+		}
+		progress.Notes++
+
+		title := strings.TrimSpace(note.Title)
+		if title == "" {
+			title = "Untitled"
+		}
+		created := note.CreatedAt()
+		key := enexKey(title, created)
+		if existing[key] {
+			progress.Skipped++
+		} else if resources, err := s.importNote(opts.UserID, notebookID, title, note); err != nil {
+			progress.Errors = append(progress.Errors, fmt.Sprintf("%s: %v", title, err))
+		} else {
+			existing[key] = true
+			progress.Created++
+			progress.Resources += resources
+		}
+
+		progress.Read = reader.InputOffset()
+		if opts.Progress != nil {
+			opts.Progress(*progress)
+		}
+	}
+	progress.Read = reader.InputOffset()
+	return progress, nil
+}
+
+// notebook finds the user's notebook by name (case-insensitive) or creates it
+func (s *EnexService) notebook(userID uint, name string) (*uint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+
+	var notebook models.Notebook
+	err := database.DB.Where("user_id = ? AND lower(name) = lower(?)", userID, name).Order("id asc").First(&notebook).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		notebook = models.Notebook{UserID: userID, Name: name, Version: 1}
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			notebook.Slug = uniqueNotebookSlug(tx, notebookSlug("", name), 0)
+			return tx.Create(&notebook).Error
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notebook.ID, nil
+}
+
+func (s *EnexService) existingNotes(userID uint, notebookID *uint) (map[string]bool, error) {
+	query := database.DB.Select("title, created_at").Where("user_id = ?", userID)
+	if notebookID != nil {
+		query = query.Where("notebook_id = ?", *notebookID)
+	} else {
+		query = query.Where("notebook_id IS NULL")
+	}
+
+	var notes []models.Note
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		keys[enexKey(note.Title, note.CreatedAt)] = true
+	}
+	return keys, nil
+}
+
+func enexKey(title string, created time.Time) string {
+	return fmt.Sprintf("%s|%d", strings.ToLower(title), created.Unix())
+}
+
+// importNote stores the note's resources and saves it, returning the number of resources written
+func (s *EnexService) importNote(userID uint, notebookID *uint, title string, source *enex.Note) (int, error) {
+	media, written, err := s.writeResources(source.Resources)
+	if err != nil {
+		removeFiles(written)
+		return 0, err
+	}
+
+	content, err := enex.ToHTML(source.Content, media)
+	if err != nil {
+		removeFiles(written)
+		return 0, err
+	}
+
+	if len(title) > 255 {
+		title = strings.ToValidUTF8(title[:255], "")
+	}
+	note := models.Note{
+		UserID:     userID,
+		NotebookID: notebookID,
+		Title:      title,
+		Status:     models.NoteStatusDraft,
+		CreatedAt:  source.CreatedAt(),
+		UpdatedAt:  source.UpdatedAt(),
+		Version:    1,
+	}
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = note.CreatedAt
+	}
+	if err := setNoteContent(&note, content, models.ContentFormatHTML); err != nil {
+		removeFiles(written)
+		return 0, err
+	}
+	applyContentStats(&note)
+
+	tags := []string{}
+	for _, tag := range source.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return insertImportedNote(tx, s.revisions, &note, "", tags)
+	})
+	if err != nil {
+		removeFiles(written)
+		return 0, err
+	}
+	return len(written), nil
+}
+
+// writeResources decodes the note's attachments into the uploads directory, keyed by the hash ENML uses
+func (s *EnexService) writeResources(resources []enex.Resource) (map[string]enex.Media, []string, error) {
+	media := map[string]enex.Media{}
+	var written []string
+	if len(resources) == 0 {
+		return media, written, nil
+	}
+	if err := os.MkdirAll(s.uploadsDir, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range resources {
+		resource := &resources[i]
+		data, hash, err := resource.Decode()
+		if errors.Is(err, enex.ErrResourceTooLarge) || len(data) > MaxEnexResourceSize {
+			return nil, written, fmt.Errorf("resource %s is larger than %d MB", resource.FileName, MaxEnexResourceSize>>20)
+		}
+		if err != nil {
+			return nil, written, fmt.Errorf("resource %s: %w", resource.FileName, err)
+		}
+		if _, ok := media[hash]; ok {
+			continue
+		}
+
+		name := uuid.New().String() + enexExtension(resource.Mime, resource.FileName)
+		dst := filepath.Join(s.uploadsDir, name)
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return nil, written, err
+		}
+		written = append(written, dst)
+		media[hash] = enex.Media{URL: UploadsURLPrefix + name, Mime: resource.Mime, FileName: resource.FileName}
+	}
+	return media, written, nil
+}
+
+func enexExtension(mimeType, fileName string) string {
+	ext := strings.ToLower(path.Ext(fileName))
+	for _, allowed := range enexExtensions {
+		if ext == allowed {
+			return ext
+		}
+	}
+	if ext == ".jpeg" {
+		return ext
+	}
+	if ext, ok := enexExtensions[strings.ToLower(mimeType)]; ok {
+		return ext
+	}
+	return ".bin"
+}
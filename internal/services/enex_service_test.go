@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestEnexService_Import(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	png := []byte("\x89PNG fake image")
+	sum := md5.Sum(png)
+	export := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240102T030405Z" application="Evernote" version="10">
+<note><title>Trip plan</title>
+<content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>Pack <b>light</b>.</div><div><en-todo checked="true"/>Passport</div><div><en-media hash="` + hex.EncodeToString(sum[:]) + `" type="image/png"/></div></en-note>]]></content>
+<created>20230105T101500Z</created><updated>20230210T080000Z</updated>
+<tag>travel</tag><tag>Family</tag>
+<resource><data encoding="base64">` + base64.StdEncoding.EncodeToString(png) + `</data><mime>image/png</mime>
+<resource-attributes><file-name>map.png</file-name></resource-attributes></resource>
+</note>
+<note><title>Broken resource</title><content><![CDATA[<en-note/>]]></content><created>20230106T101500Z</created>
+<resource><data encoding="base64">!!not base64!!</data><mime>image/png</mime></resource></note>
+<note><title></title><content><![CDATA[<en-note><div>No title</div></en-note>]]></content><created>20230107T101500Z</created></note>
+</en-export>`
+
+	uploads := t.TempDir()
+	service := services.NewEnexService(uploads)
+
+	var updates []services.EnexProgress
+	progress, err := service.Import(strings.NewReader(export), services.EnexOptions{
+		UserID:   1,
+		Notebook: "Evernote",
+		Progress: func(p services.EnexProgress) { updates = append(updates, p) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, progress.Notes)
+	assert.Equal(t, 2, progress.Created)
+	assert.Equal(t, 1, progress.Resources)
+	assert.Len(t, progress.Errors, 1)
+	assert.Contains(t, progress.Errors[0], "Broken resource")
+	assert.Equal(t, int64(len(export)), progress.Read)
+	assert.Len(t, updates, 3)
+	assert.Equal(t, 1, updates[0].Created)
+
+	// Notebook, timestamps, tags and content
+	var notebook models.Notebook
+	assert.NoError(t, database.DB.Where("user_id = ? AND name = ?", 1, "Evernote").First(&notebook).Error)
+
+	var note models.Note
+	assert.NoError(t, database.DB.Preload("Tags").Where("title = ?", "Trip plan").First(&note).Error)
+	assert.Equal(t, notebook.ID, *note.NotebookID)
+	assert.Equal(t, models.NoteStatusDraft, note.Status)
+	assert.Equal(t, time.Date(2023, 1, 5, 10, 15, 0, 0, time.UTC), note.CreatedAt.UTC())
+	assert.Equal(t, time.Date(2023, 2, 10, 8, 0, 0, 0, time.UTC), note.UpdatedAt.UTC())
+	assert.Len(t, note.Tags, 2)
+	assert.Contains(t, note.Content, "<strong>light</strong>")
+	assert.Contains(t, note.Content, `data-type="taskList"`)
+
+	files, _ := os.ReadDir(uploads)
+	assert.Len(t, files, 1)
+	assert.Equal(t, ".png", filepath.Ext(files[0].Name()))
+	assert.Contains(t, note.Content, `src="/public/uploads/`+files[0].Name()+`"`)
+	data, _ := os.ReadFile(filepath.Join(uploads, files[0].Name()))
+	assert.Equal(t, png, data)
+
+	assert.NoError(t, database.DB.Where("title = ?", "Untitled").First(&models.Note{}).Error)
+
+	// Importing again skips what was created
+	progress, err = service.Import(strings.NewReader(export), services.EnexOptions{UserID: 1, Notebook: "evernote"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, progress.Created)
+	assert.Equal(t, 2, progress.Skipped)
+
+	var count int64
+	database.DB.Model(&models.Note{}).Where("user_id = ?", 1).Count(&count)
+	assert.Equal(t, int64(2), count)
+	database.DB.Model(&models.Notebook{}).Where("user_id = ?", 1).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestEnexService_MalformedExport(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	progress, err := services.NewEnexService(t.TempDir()).Import(
+		strings.NewReader(`<en-export><note><title>Ok</title><content>&lt;en-note/&gt;</content></note><note><title>cut`),
+		services.EnexOptions{UserID: 1})
+	assert.Error(t, err)
+	assert.Equal(t, 1, progress.Created)
+}
+
+func TestEnexService_SvgStoredAsBin(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"/>`)
+	sum := md5.Sum(svg)
+	export := `<en-export><note><title>Drawing</title>
+<content><![CDATA[<en-note><en-media hash="` + hex.EncodeToString(sum[:]) + `" type="image/svg+xml"/></en-note>]]></content>
+<resource><data encoding="base64">` + base64.StdEncoding.EncodeToString(svg) + `</data><mime>image/svg+xml</mime>
+<resource-attributes><file-name>drawing.svg</file-name></resource-attributes></resource></note></en-export>`
+
+	uploads := t.TempDir()
+	progress, err := services.NewEnexService(uploads).Import(strings.NewReader(export), services.EnexOptions{UserID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, progress.Resources)
+
+	files, _ := os.ReadDir(uploads)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, ".bin", filepath.Ext(files[0].Name()))
+	}
+
+	var note models.Note
+	assert.NoError(t, database.DB.Where("title = ?", "Drawing").First(&note).Error)
+	assert.Contains(t, note.Content, `>drawing.svg</a>`)
+	assert.NotContains(t, note.Content, "<img")
+}
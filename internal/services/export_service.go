@@ -0,0 +1,194 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Where UploadImage stores files and the URL they are served under
+const (
+	UploadsDir       = "./resources/public/uploads"
+	UploadsURLPrefix = "/public/uploads/"
+)
+
+// Upload references in note content, e.g. <img src="/public/uploads/x.png"> or ![](/public/uploads/x.png)
+var uploadRefPattern = regexp.MustCompile(`/public/uploads/([A-Za-z0-9_-][A-Za-z0-9._-]*)`)
+
+// NoteFrontMatter is the YAML header of an exported Markdown note
+type NoteFrontMatter struct {
+	Title       string     `yaml:"title"`
+	Slug        string     `yaml:"slug,omitempty"`
+	Status      string     `yaml:"status,omitempty"`
+	Notebook    string     `yaml:"notebook,omitempty"`
+	Tags        StringList `yaml:"tags,omitempty"`
+	Featured    bool       `yaml:"featured,omitempty"`
+	Excerpt     string     `yaml:"excerpt,omitempty"` // Only hand-written excerpts
+	Cover       string     `yaml:"cover,omitempty"`
+	CreatedAt   *time.Time `yaml:"created_at,omitempty"`
+	UpdatedAt   *time.Time `yaml:"updated_at,omitempty"`
+	PublishedAt *time.Time `yaml:"published_at,omitempty"`
+	PublishAt   *time.Time `yaml:"publish_at,omitempty"`
+	UnpublishAt *time.Time `yaml:"unpublish_at,omitempty"`
+}
+
+type ExportService struct {
+	uploadsDir string
+}
+
+func NewExportService(uploadsDir string) *ExportService {
+	return &ExportService{uploadsDir: uploadsDir}
+}
+
+// Notes loads the notes to export: all of the user's notes, or one notebook's. Trashed notes are left out.
+func (s *ExportService) Notes(userID uint, notebookID *uint) ([]models.Note, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if notebookID != nil {
+		var notebook models.Notebook
+		if err := database.DB.Where("id = ? AND user_id = ?", *notebookID, userID).First(&notebook).Error; err != nil {
+			return nil, ErrNotebookNotFound
+		}
+		query = query.Where("notebook_id = ?", *notebookID)
+	}
+
+	var notes []models.Note
+	if err := query.Preload("Notebook").Preload("Tags").Order("id asc").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// WriteZip writes the notes as a ZIP archive: one Markdown file per note with YAML front matter,
+// in a folder per notebook, and the uploads they reference under uploads/ with links rewritten
+// to relative paths. Uploads missing on disk keep their original URL.
+func (s *ExportService) WriteZip(w io.Writer, notes []models.Note) error {
+	archive := zip.NewWriter(w)
+	uploads := map[string]bool{}
+	used := map[string]bool{}
+
+	for i := range notes {
+		note := &notes[i]
+
+		dir := ""
+		if note.Notebook != nil {
+			dir = exportName(note.Notebook.Slug, fmt.Sprintf("notebook-%d", note.Notebook.ID)) + "/"
+		}
+		name := dir + exportName(note.Slug, fmt.Sprintf("note-%d", note.ID))
+		for n := 1; used[name+".md"]; n++ {
+			name = fmt.Sprintf("%s%s-%d", dir, exportName(note.Slug, "note"), n)
+		}
+		used[name+".md"] = true
+
+		body, err := NoteContentAs(note, models.ContentFormatMarkdown)
+		if err != nil {
+			return fmt.Errorf("note %d: %w", note.ID, err)
+		}
+		uploadsPath := strings.Repeat("../", strings.Count(dir, "/")) + "uploads/"
+		rewrite := func(text string) string {
+			return uploadRefPattern.ReplaceAllStringFunc(text, func(ref string) string {
+				file := strings.TrimPrefix(ref, UploadsURLPrefix)
+				if !s.uploadExists(file) {
+					return ref
+				}
+				uploads[file] = true
+				return uploadsPath + file
+			})
+		}
+
+		data, err := yaml.Marshal(noteFrontMatter(note, rewrite(note.CoverImage)))
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString("---\n")
+		buf.Write(data)
+		buf.WriteString("---\n\n")
+		buf.WriteString(rewrite(body))
+
+		if err := writeZipFile(archive, name+".md", note.UpdatedAt, &buf); err != nil {
+			return err
+		}
+	}
+
+	for file := range uploads {
+		if err := s.copyUpload(archive, file); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func noteFrontMatter(note *models.Note, cover string) NoteFrontMatter {
+	meta := NoteFrontMatter{
+		Title:       note.Title,
+		Slug:        note.Slug,
+		Status:      note.Status,
+		Featured:    note.IsFeatured,
+		Cover:       cover,
+		CreatedAt:   &note.CreatedAt,
+		UpdatedAt:   &note.UpdatedAt,
+		PublishedAt: note.PublishedAt,
+		PublishAt:   note.PublishAt,
+		UnpublishAt: note.UnpublishAt,
+	}
+	if note.Notebook != nil {
+		meta.Notebook = note.Notebook.Name
+	}
+	for _, tag := range note.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	if !note.AutoExcerpt {
+		meta.Excerpt = note.Excerpt
+	}
+	return meta
+}
+
+// exportName makes a slug safe as a single path segment
+func exportName(slug, fallback string) string {
+	name := strings.Trim(strings.NewReplacer("/", "-", "\\", "-").Replace(slug), ". ")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+func (s *ExportService) uploadExists(file string) bool {
+	info, err := os.Stat(filepath.Join(s.uploadsDir, file))
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (s *ExportService) copyUpload(archive *zip.Writer, file string) error {
+	src, err := os.Open(filepath.Join(s.uploadsDir, file))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	return writeZipFile(archive, path.Join("uploads", file), info.ModTime(), src)
+}
+
+func writeZipFile(archive *zip.Writer, name string, modified time.Time, content io.Reader) error {
+	dst, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, content)
+	return err
+}
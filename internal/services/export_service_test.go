@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestExportService_WriteZip(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	uploads := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(uploads, "photo.png"), []byte("png-bytes"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(uploads, "cover.jpg"), []byte("jpg-bytes"), 0o644))
+
+	notebook := models.Notebook{UserID: 1, Name: "Travel Log", Slug: "travel-log"}
+	database.DB.Create(&notebook)
+	tag := models.Tag{Name: "Japan", Slug: "japan"}
+	database.DB.Create(&tag)
+
+	published := time.Date(2026, 4, 2, 10, 0, 0, 0, time.UTC)
+	trip := models.Note{
+		UserID: 1, NotebookID: &notebook.ID, Title: "Kyoto: Day 1", Slug: "kyoto-day-1", Status: models.NoteStatusPublished,
+		Content:     `<h2>Temples</h2><p>Arrived early.</p><p><img src="/public/uploads/photo.png" alt="Gate"><img src="/public/uploads/missing.png" alt="Lost"></p>`,
+		CoverImage:  "/public/uploads/cover.jpg",
+		Excerpt:     "Hand-written summary",
+		IsFeatured:  true,
+		PublishedAt: &published,
+		Tags:        []models.Tag{tag},
+	}
+	loose := models.Note{UserID: 1, Title: "Loose Thought", Slug: "loose-thought", Status: models.NoteStatusDraft,
+		Content: `<p>See <img src="/public/uploads/photo.png" alt="Same"></p>`, AutoExcerpt: true, Excerpt: "See"}
+	other := models.Note{UserID: 2, Title: "Not Mine", Slug: "not-mine", Content: "<p>x</p>"}
+	database.DB.Create(&trip)
+	database.DB.Create(&loose)
+	database.DB.Create(&other)
+
+	service := services.NewExportService(uploads)
+	notes, err := service.Notes(1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, notes, 2)
+
+	var buf bytes.Buffer
+	assert.NoError(t, service.WriteZip(&buf, notes))
+	files := readZip(t, buf.Bytes())
+
+	// 1. One Markdown file per note, in a folder per notebook, plus the referenced uploads
+	assert.Len(t, files, 4)
+	assert.Equal(t, "png-bytes", files["uploads/photo.png"])
+	assert.Equal(t, "jpg-bytes", files["uploads/cover.jpg"])
+
+	// 2. Front matter carries the metadata; uploads are relative, missing ones keep their URL
+	md := files["travel-log/kyoto-day-1.md"]
+	assert.True(t, strings.HasPrefix(md, "---\ntitle: 'Kyoto: Day 1'\nslug: kyoto-day-1\nstatus: PUBLISHED\nnotebook: Travel Log\n"), md)
+	assert.Contains(t, md, "tags:\n    - Japan\n")
+	assert.Contains(t, md, "featured: true\n")
+	assert.Contains(t, md, "excerpt: Hand-written summary\n")
+	assert.Contains(t, md, "cover: ../uploads/cover.jpg\n")
+	assert.Contains(t, md, "published_at: 2026-04-02T10:00:00Z\n")
+	assert.Contains(t, md, "## Temples\n\nArrived early.")
+	assert.Contains(t, md, "![Gate](../uploads/photo.png)")
+	assert.Contains(t, md, "![Lost](/public/uploads/missing.png)")
+
+	looseMD := files["loose-thought.md"]
+	assert.NotContains(t, looseMD, "excerpt:")
+	assert.NotContains(t, looseMD, "notebook:")
+	assert.Contains(t, looseMD, "![Same](uploads/photo.png)")
+
+	// 3. A single notebook, and only the owner's
+	notes, err = service.Notes(1, &notebook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, notes, 1)
+
+	_, err = service.Notes(2, &notebook.ID)
+	assert.ErrorIs(t, err, services.ErrNotebookNotFound)
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+)
+
+// FeedLimit is how many of the latest published notes a feed carries
+const FeedLimit = 20
+
+// SettingFeedContent selects what feed entries carry: the full note (FeedContentFull) or only its excerpt
+const (
+	SettingFeedContent = "feed_content"
+	FeedContentFull    = "full"
+	FeedContentExcerpt = "excerpt"
+)
+
+var ErrAuthorNotFound = errors.New("author not found")
+
+// Root-relative links in note content, made absolute for feed readers
+var relativeRefPattern = regexp.MustCompile(`(\s(?:src|href)=")/([^/"])`)
+
+// Feed is a list of published notes with what describes it
+type Feed struct {
+	Site        SiteInfo
+	Title       string
+	Description string
+	Link        string // Page the feed mirrors
+	Self        string // URL of the feed itself
+	FullContent bool
+	Notes       []models.Note // Newest first
+	// Modified is the latest change that can alter the feed: an edit to a note of any status, trashed
+	// ones included, or to a notebook, author or setting. It can run ahead of the feed's content,
+	// which costs a needless full response at worst, never a stale 304.
+	Modified time.Time
+}
+
+// Updated is when the newest change to the feed's notes happened, or the zero time for an empty feed
+func (f *Feed) Updated() time.Time {
+	var updated time.Time
+	for i := range f.Notes {
+		if f.Notes[i].UpdatedAt.After(updated) {
+			updated = f.Notes[i].UpdatedAt
+		}
+	}
+	return updated
+}
+
+// ETag identifies the feed's content: it changes whenever a note is added, removed or edited, or the feed settings change.
+// Self is part of it, so RSS and Atom versions of a feed differ.
+func (f *Feed) ETag() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|%t", f.Self, f.Site.Name, f.Title, f.Description, f.FullContent)
+	for i := range f.Notes {
+		fmt.Fprintf(h, "|%d:%d", f.Notes[i].ID, f.Notes[i].UpdatedAt.UnixNano())
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// FeedScope limits a feed to a notebook or an author (matched by username, or ID when numeric)
+type FeedScope struct {
+	NotebookSlug string
+	Author       string
+}
+
+type FeedService struct{}
+
+func NewFeedService() *FeedService {
+	return &FeedService{}
+}
+
+// Feed loads the latest published notes of the scope, newest PublishedAt first
+func (s *FeedService) Feed(site SiteInfo, scope FeedScope) (*Feed, error) {
+	feed := &Feed{
+		Site:        site,
+		Title:       site.Name,
+		Description: firstNonEmpty(site.Description, site.Name),
+		Link:        site.AbsURL("/"),
+		FullContent: settingValue(SettingFeedContent) == FeedContentFull,
+	}
+
+	query := database.DB.Where("status = ?", models.NoteStatusPublished)
+	if scope.NotebookSlug != "" {
+		var notebook models.Notebook
+		if err := database.DB.Where("slug = ?", scope.NotebookSlug).First(&notebook).Error; err != nil {
+			return nil, ErrNotebookNotFound
+		}
+		query = query.Where("notebook_id = ?", notebook.ID)
+		feed.Title = notebook.Name + " · " + site.Name
+		feed.Description = firstNonEmpty(notebook.Description, feed.Description)
+		feed.Link = site.AbsURL(NotebookPath(&notebook))
+	}
+	if scope.Author != "" {
+		author, err := findAuthor(scope.Author)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("user_id = ?", author.ID)
+		feed.Title = firstNonEmpty(author.Name, author.Username) + " · " + site.Name
+	}
+
+	if err := query.Preload("User").Preload("Tags").
+		Order("COALESCE(published_at, created_at) desc").Order("id desc").
+		Limit(FeedLimit).
+		Find(&feed.Notes).Error; err != nil {
+		return nil, err
+	}
+
+	// Removals leave no trace among the feed's notes, so look at every row that could have dropped out
+	for _, source := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.Note{}, "updated_at"},
+		{&models.Note{}, "deleted_at"},
+		{&models.Notebook{}, "updated_at"},
+		{&models.Notebook{}, "deleted_at"},
+		{&models.User{}, "updated_at"},
+		{&models.Setting{}, "updated_at"},
+	} {
+		latest, err := latestChange(source.model, source.column)
+		if err != nil {
+			return nil, err
+		}
+		if latest.After(feed.Modified) {
+			feed.Modified = latest
+		}
+	}
+	return feed, nil
+}
+
+// latestChange is the newest value of a timestamp column across a table, trashed rows included
+func latestChange(model interface{}, column string) (time.Time, error) {
+	var latest []time.Time
+	err := database.DB.Unscoped().Model(model).
+		Where(column+" IS NOT NULL").Order(column+" desc").Limit(1).
+		Pluck(column, &latest).Error
+	if err != nil || len(latest) == 0 {
+		return time.Time{}, err
+	}
+	return latest[0], nil
+}
+
+func findAuthor(key string) (*models.User, error) {
+	var user models.User
+	err := database.DB.Where("username = ?", key).First(&user).Error
+	if err != nil {
+		if id, convErr := strconv.ParseUint(key, 10, 64); convErr == nil {
+			err = database.DB.First(&user, id).Error
+		}
+	}
+	if err != nil {
+		return nil, ErrAuthorNotFound
+	}
+	return &user, nil
+}
+
+// absoluteContent makes root-relative links and images in note content absolute
+func (f *Feed) absoluteContent(note *models.Note) string {
+	return relativeRefPattern.ReplaceAllString(note.Content, "${1}"+f.Site.URL+"/${2}")
+}
+
+// notePublishedAt is when a published note went out, falling back to its creation for older notes
+func notePublishedAt(note *models.Note) time.Time {
+	if note.PublishedAt != nil {
+		return *note.PublishedAt
+	}
+	return note.CreatedAt
+}
+
+// RSS 2.0 (https://www.rssboard.org/rss-specification) with the content module for full text
+
+type rssFeed struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	XmlnsContent string     `xml:"xmlns:content,attr"`
+	XmlnsDC      string     `xml:"xmlns:dc,attr"`
+	XmlnsAtom    string     `xml:"xmlns:atom,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      *atomLink `xml:"atom:link,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Author      string   `xml:"dc:creator,omitempty"`
+	Description string   `xml:"description"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// WriteRSS writes the feed as RSS 2.0. Descriptions are the excerpts; full content goes in content:encoded.
+func WriteRSS(w io.Writer, feed *Feed) error {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+	}
+	if feed.Self != "" {
+		channel.AtomLink = &atomLink{Href: feed.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	if updated := feed.Updated(); !updated.IsZero() {
+		channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for i := range feed.Notes {
+		note := &feed.Notes[i]
+		link := feed.Site.AbsURL(ArticlePath(note))
+		item := rssItem{
+			Title:       note.Title,
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			PubDate:     notePublishedAt(note).UTC().Format(time.RFC1123Z),
+			Author:      note.User.Name,
+			Description: note.Excerpt,
+		}
+		if feed.FullContent {
+			item.Content = &cdata{Value: feed.absoluteContent(note)}
+		}
+		for _, tag := range note.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	rss := rssFeed{
+		Version:      "2.0",
+		XmlnsContent: "http://purl.org/rss/1.0/modules/content/",
+		XmlnsDC:      "http://purl.org/dc/elements/1.1/",
+		XmlnsAtom:    "http://www.w3.org/2005/Atom",
+		Channel:      channel,
+	}
+	return writeXML(w, rss)
+}
+
+// Atom (RFC 4287)
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomPerson  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// WriteAtom writes the feed as Atom 1.0, with the excerpt as summary and the full note as HTML content
+func WriteAtom(w io.Writer, feed *Feed) error {
+	updated := feed.Updated()
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	self := firstNonEmpty(feed.Self, feed.Link)
+	atom := atomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		ID:       self,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+			{Href: self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Author: atomPerson{Name: feed.Site.Name},
+	}
+
+	for i := range feed.Notes {
+		note := &feed.Notes[i]
+		link := feed.Site.AbsURL(ArticlePath(note))
+		entry := atomEntry{
+			ID:        link,
+			Title:     note.Title,
+			Link:      atomLink{Href: link, Rel: "alternate", Type: "text/html"},
+			Published: notePublishedAt(note).UTC().Format(time.RFC3339),
+			Updated:   note.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if note.User.Name != "" {
+			entry.Author = &atomPerson{Name: note.User.Name}
+		}
+		if note.Excerpt != "" {
+			entry.Summary = &atomText{Type: "text", Value: note.Excerpt}
+		}
+		if feed.FullContent {
+			entry.Content = &atomText{Type: "html", Value: feed.absoluteContent(note)}
+		}
+		for _, tag := range note.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag.Name})
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+	return writeXML(w, atom)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,251 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+// The parts of RSS 2.0 and Atom (RFC 4287) the validation below checks, read back with encoding/xml
+type rssDoc struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		// Before Link: a field without a namespace matches <atom:link> too
+		AtomLink struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"http://www.w3.org/2005/Atom link"`
+		Title         string `xml:"title"`
+		Link          string `xml:"link"`
+		Description   string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		Items         []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			GUID        struct {
+				IsPermaLink string `xml:"isPermaLink,attr"`
+				Value       string `xml:",chardata"`
+			} `xml:"guid"`
+			PubDate    string   `xml:"pubDate"`
+			Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Encoded    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			Categories []string `xml:"category"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomLinkDoc struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomDoc struct {
+	XMLName xml.Name      `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string        `xml:"id"`
+	Title   string        `xml:"title"`
+	Updated string        `xml:"updated"`
+	Links   []atomLinkDoc `xml:"link"`
+	Author  []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Entries []struct {
+		ID        string        `xml:"id"`
+		Title     string        `xml:"title"`
+		Updated   string        `xml:"updated"`
+		Published string        `xml:"published"`
+		Links     []atomLinkDoc `xml:"link"`
+		Summary   string        `xml:"summary"`
+		Content   struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"content"`
+	} `xml:"entry"`
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.IsAbs() && u.Host != ""
+}
+
+// validateRSS checks the elements RSS 2.0 requires and the formats it prescribes
+func validateRSS(t *testing.T, data []byte) rssDoc {
+	t.Helper()
+	var doc rssDoc
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("feed is not well-formed XML: %v", err)
+	}
+
+	assert.Equal(t, "2.0", doc.Version)
+	assert.NotEmpty(t, doc.Channel.Title, "channel title is required")
+	assert.True(t, isAbsoluteURL(doc.Channel.Link), "channel link must be a URL")
+	assert.NotEmpty(t, doc.Channel.Description, "channel description is required")
+	assert.Equal(t, "self", doc.Channel.AtomLink.Rel)
+	if doc.Channel.LastBuildDate != "" {
+		_, err := time.Parse(time.RFC1123Z, doc.Channel.LastBuildDate)
+		assert.NoError(t, err, "lastBuildDate must be an RFC 822 date")
+	}
+
+	guids := map[string]bool{}
+	for _, item := range doc.Channel.Items {
+		assert.True(t, item.Title != "" || item.Description != "", "an item needs a title or a description")
+		assert.True(t, isAbsoluteURL(item.Link))
+		_, err := time.Parse(time.RFC1123Z, item.PubDate)
+		assert.NoError(t, err, "pubDate must be an RFC 822 date")
+		assert.Equal(t, "true", item.GUID.IsPermaLink)
+		assert.True(t, isAbsoluteURL(item.GUID.Value), "a permalink guid must be a URL")
+		assert.False(t, guids[item.GUID.Value], "guids must be unique")
+		guids[item.GUID.Value] = true
+	}
+	return doc
+}
+
+// validateAtom checks the constraints of RFC 4287 section 4.1
+func validateAtom(t *testing.T, data []byte) atomDoc {
+	t.Helper()
+	var doc atomDoc
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("feed is not well-formed Atom: %v", err)
+	}
+
+	assert.True(t, isAbsoluteURL(doc.ID), "feed id must be an IRI")
+	assert.NotEmpty(t, doc.Title)
+	_, err := time.Parse(time.RFC3339, doc.Updated)
+	assert.NoError(t, err, "updated must be an RFC 3339 date")
+	rels := map[string]int{}
+	for _, link := range doc.Links {
+		rels[link.Rel]++
+	}
+	assert.Equal(t, 1, rels["self"], "feed should have one self link")
+	assert.Equal(t, 1, rels["alternate"], "at most one alternate link per type")
+	feedAuthor := len(doc.Author) > 0 && doc.Author[0].Name != ""
+
+	ids := map[string]bool{}
+	for _, entry := range doc.Entries {
+		assert.True(t, isAbsoluteURL(entry.ID), "entry id must be an IRI")
+		assert.False(t, ids[entry.ID], "entry ids must be unique")
+		ids[entry.ID] = true
+		assert.NotEmpty(t, entry.Title)
+		_, err := time.Parse(time.RFC3339, entry.Updated)
+		assert.NoError(t, err)
+		_, err = time.Parse(time.RFC3339, entry.Published)
+		assert.NoError(t, err)
+		assert.True(t, feedAuthor, "entries without an author need a feed author")
+		alternate := false
+		for _, link := range entry.Links {
+			alternate = alternate || link.Rel == "alternate"
+		}
+		assert.True(t, alternate || entry.Content.Value != "", "an entry needs content or an alternate link")
+	}
+	return doc
+}
+
+func TestFeeds_ValidAgainstSpecs(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	author := models.User{Name: "Ada <Lovelace>", Username: "ada", Email: "ada@test.com"}
+	database.DB.Create(&author)
+	notebook := models.Notebook{UserID: author.ID, Name: "Engines", Slug: "engines", Description: "Analytical"}
+	database.DB.Create(&notebook)
+
+	older := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 2, 3, 9, 0, 0, 0, time.UTC)
+	database.DB.Create(&models.Note{UserID: author.ID, NotebookID: &notebook.ID, Title: "Notes & <sketches>", Slug: "sketches", Status: models.NoteStatusPublished,
+		PublishedAt: &older, Excerpt: "On the engine", Content: `<p>See <a href="/articles/other">this</a></p><img src="/public/uploads/e.png"><a href="https://example.org/x">x</a>`,
+		Tags: []models.Tag{{Name: "math", Slug: "math", UserID: author.ID}}})
+	database.DB.Create(&models.Note{UserID: author.ID, Title: "Newest", Slug: "newest", Status: models.NoteStatusPublished, PublishedAt: &newer, Content: "<p>]]> tricky</p>"})
+	database.DB.Create(&models.Note{UserID: author.ID, Title: "Draft", Slug: "draft", Status: models.NoteStatusDraft})
+
+	site := services.SiteInfo{Name: "Blog", URL: "https://blog.example.com"}
+	service := services.NewFeedService()
+
+	feed, err := service.Feed(site, services.FeedScope{})
+	assert.NoError(t, err)
+	feed.Self = site.AbsURL("/feed.xml")
+
+	// 1. Excerpt mode
+	var buf bytes.Buffer
+	assert.NoError(t, services.WriteRSS(&buf, feed))
+	rss := validateRSS(t, buf.Bytes())
+	if !assert.Len(t, rss.Channel.Items, 2) {
+		return
+	}
+	assert.Equal(t, "Newest", rss.Channel.Items[0].Title, "newest publication first")
+	assert.Equal(t, "Notes & <sketches>", rss.Channel.Items[1].Title)
+	assert.Equal(t, "https://blog.example.com/articles/sketches", rss.Channel.Items[1].Link)
+	assert.Equal(t, "On the engine", rss.Channel.Items[1].Description)
+	assert.Equal(t, "Ada <Lovelace>", rss.Channel.Items[1].Creator)
+	assert.Equal(t, []string{"math"}, rss.Channel.Items[1].Categories)
+	assert.Empty(t, rss.Channel.Items[1].Encoded)
+
+	buf.Reset()
+	feed.Self = site.AbsURL("/atom.xml")
+	assert.NoError(t, services.WriteAtom(&buf, feed))
+	atom := validateAtom(t, buf.Bytes())
+	if !assert.Len(t, atom.Entries, 2) {
+		return
+	}
+	assert.Equal(t, "https://blog.example.com/atom.xml", atom.ID)
+	assert.Equal(t, "On the engine", atom.Entries[1].Summary)
+	assert.Empty(t, atom.Entries[1].Content.Value)
+
+	// 2. Full content, with site-relative URLs made absolute
+	database.DB.Create(&models.Setting{Key: services.SettingFeedContent, Value: services.FeedContentFull})
+	feed, err = service.Feed(site, services.FeedScope{})
+	assert.NoError(t, err)
+	feed.Self = site.AbsURL("/feed.xml")
+	buf.Reset()
+	assert.NoError(t, services.WriteRSS(&buf, feed))
+	rss = validateRSS(t, buf.Bytes())
+	assert.Equal(t, "<p>]]> tricky</p>", rss.Channel.Items[0].Encoded)
+	encoded := rss.Channel.Items[1].Encoded
+	assert.Contains(t, encoded, `href="https://blog.example.com/articles/other"`)
+	assert.Contains(t, encoded, `src="https://blog.example.com/public/uploads/e.png"`)
+	assert.Contains(t, encoded, `href="https://example.org/x"`)
+
+	buf.Reset()
+	assert.NoError(t, services.WriteAtom(&buf, feed))
+	atom = validateAtom(t, buf.Bytes())
+	assert.Equal(t, "html", atom.Entries[1].Content.Type)
+	assert.True(t, strings.HasPrefix(atom.Entries[1].Content.Value, "<p>See"))
+
+	// 3. Scoped feeds
+	feed, err = service.Feed(site, services.FeedScope{NotebookSlug: "engines"})
+	assert.NoError(t, err)
+	assert.Len(t, feed.Notes, 1)
+	assert.Equal(t, "Engines · Blog", feed.Title)
+	assert.Equal(t, "https://blog.example.com/notebooks/engines", feed.Link)
+
+	feed, err = service.Feed(site, services.FeedScope{Author: "ada"})
+	assert.NoError(t, err)
+	assert.Len(t, feed.Notes, 2)
+
+	_, err = service.Feed(site, services.FeedScope{NotebookSlug: "missing"})
+	assert.ErrorIs(t, err, services.ErrNotebookNotFound)
+	_, err = service.Feed(site, services.FeedScope{Author: "nobody"})
+	assert.ErrorIs(t, err, services.ErrAuthorNotFound)
+
+	// 4. An empty feed is still valid
+	feed, err = service.Feed(site, services.FeedScope{Author: "1"})
+	assert.NoError(t, err)
+	feed.Self = site.AbsURL("/authors/1/atom.xml")
+	buf.Reset()
+	assert.NoError(t, services.WriteAtom(&buf, feed))
+	validateAtom(t, buf.Bytes())
+	buf.Reset()
+	assert.NoError(t, services.WriteRSS(&buf, feed))
+	validateRSS(t, buf.Bytes())
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+)
+
+// PageHead is the head metadata of a site page. An empty title or description falls back to the site's.
+func PageHead(site SiteInfo, title, description, path string) utils.HeadMeta {
+	return utils.HeadMeta{
+		Title:       title,
+		Description: firstNonEmpty(description, site.Description),
+		Canonical:   site.AbsURL(path),
+		Image:       site.Image,
+		Type:        "website",
+		SiteName:    site.Name,
+		Twitter:     site.Twitter,
+	}
+}
+
+// ArticleHead describes a published note for link previews: OpenGraph article tags and a schema.org Article.
+// The note's User should be loaded.
+func ArticleHead(site SiteInfo, note *models.Note) utils.HeadMeta {
+	head := PageHead(site, note.Title, note.Excerpt, ArticlePath(note))
+	head.Type = "article"
+	if note.CoverImage != "" {
+		head.Image = site.ResolveURL(note.CoverImage)
+	}
+	head.Author = firstNonEmpty(note.User.Name, note.User.Username)
+	head.PublishedAt = notePublishedAt(note).UTC().Format(time.RFC3339)
+	head.ModifiedAt = note.UpdatedAt.UTC().Format(time.RFC3339)
+
+	article := map[string]interface{}{
+		"@context":         "https://schema.org",
+		"@type":            "Article",
+		"headline":         note.Title,
+		"url":              head.Canonical,
+		"mainEntityOfPage": head.Canonical,
+		"datePublished":    head.PublishedAt,
+		"dateModified":     head.ModifiedAt,
+		"publisher":        map[string]string{"@type": "Organization", "name": site.Name, "url": site.AbsURL("/")},
+	}
+	if note.Excerpt != "" {
+		article["description"] = note.Excerpt
+	}
+	if head.Image != "" {
+		article["image"] = []string{head.Image}
+	}
+	if head.Author != "" {
+		article["author"] = map[string]string{"@type": "Person", "name": head.Author}
+	}
+	head.JSONLD = article
+	return head
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import job states
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Finished jobs are kept this long for clients to read their result
+const importJobRetention = time.Hour
+
+// ImportJob is a long-running import whose progress clients poll
+type ImportJob struct {
+	ID         string       `json:"id"`
+	UserID     uint         `json:"-"`
+	Status     string       `json:"status"`
+	Size       int64        `json:"size"` // Bytes to process, to compare with Progress.Read
+	Progress   EnexProgress `json:"progress"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at"`
+}
+
+// ImportJobs tracks the import jobs of this process
+type ImportJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*ImportJob
+}
+
+func NewImportJobs() *ImportJobs {
+	return &ImportJobs{jobs: map[string]*ImportJob{}}
+}
+
+// Jobs is the process-wide job registry used by the admin API
+var Jobs = NewImportJobs()
+
+// Start runs fn in the background as a new job. fn reports progress through update.
+func (j *ImportJobs) Start(userID uint, size int64, fn func(update func(EnexProgress)) (*EnexProgress, error)) ImportJob {
+	j.mu.Lock()
+	now := time.Now().UTC()
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > importJobRetention {
+			delete(j.jobs, id)
+		}
+	}
+	job := &ImportJob{ID: uuid.New().String(), UserID: userID, Status: JobRunning, Size: size, StartedAt: now}
+	job.Progress.Errors = []string{}
+	j.jobs[job.ID] = job
+	started := *job
+	j.mu.Unlock()
+
+	go func() {
+		result, err := runJob(fn, func(progress EnexProgress) {
+			j.mu.Lock()
+			job.Progress = progress
+			j.mu.Unlock()
+		})
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		finished := time.Now().UTC()
+		job.FinishedAt = &finished
+		if result != nil {
+			job.Progress = *result
+		}
+		job.Status = JobDone
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+		}
+	}()
+
+	return started
+}
+
+// runJob calls fn, turning a panic into an error so the job is marked failed instead of taking
+// the process down
+func runJob(fn func(update func(EnexProgress)) (*EnexProgress, error), update func(EnexProgress)) (result *EnexProgress, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Import job panicked: %v\n%s", r, debug.Stack())
+			result, err = nil, fmt.Errorf("import failed unexpectedly: %v", r)
+		}
+	}()
+	return fn(update)
+}
+
+// Get returns a snapshot of the user's job
+func (j *ImportJobs) Get(id string, userID uint) (ImportJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok || job.UserID != userID {
+		return ImportJob{}, false
+	}
+	snapshot := *job
+	snapshot.Progress.Errors = append([]string{}, job.Progress.Errors...)
+	return snapshot, true
+}
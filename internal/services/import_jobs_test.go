@@ -0,0 +1,26 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+)
+
+func TestImportJobs_PanicFailsJob(t *testing.T) {
+	jobs := services.NewImportJobs()
+	job := jobs.Start(1, 10, func(update func(services.EnexProgress)) (*services.EnexProgress, error) {
+		panic("boom")
+	})
+
+	got, ok := jobs.Get(job.ID, 1)
+	for i := 0; i < 100 && got.Status == services.JobRunning; i++ {
+		time.Sleep(10 * time.Millisecond)
+		got, ok = jobs.Get(job.ID, 1)
+	}
+	assert.True(t, ok)
+	assert.Equal(t, services.JobFailed, got.Status)
+	assert.Contains(t, got.Error, "boom")
+	assert.NotNil(t, got.FinishedAt)
+}
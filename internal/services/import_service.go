@@ -0,0 +1,711 @@
+package services
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Import limits, so a hostile archive can't exhaust memory or disk
+const (
+	MaxImportNoteSize  = 5 << 20  // Bytes per Markdown file
+	MaxImportImageSize = 20 << 20 // Bytes per image
+)
+
+// Import actions
+const (
+	ImportCreate = "create"
+	ImportSkip   = "skip"
+)
+
+var ErrNoMarkdownFiles = errors.New("no markdown files found")
+
+var importImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".svg": true, ".avif": true, ".bmp": true,
+}
+
+var (
+	embedPattern    = regexp.MustCompile(`!\[\[([^\[\]]+)\]\]`)
+	obsidianLink    = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+	mdImagePattern  = regexp.MustCompile(`!\[([^\]]*)\]\(\s*(<[^>]+>|[^)\s]+)((?:\s+"[^"]*")?\s*)\)`)
+	mdNoteLink      = regexp.MustCompile(`\[([^\]]+)\]\(\s*(<[^>]+\.md(?:#[^>]*)?>|[^)\s]+\.md(?:#[^)\s]*)?)\s*\)`)
+	htmlImgPattern  = regexp.MustCompile(`(<img\b[^>]*?\bsrc=["'])([^"']+)(["'])`)
+	codeFencePrefix = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// StringList reads a YAML sequence or a comma separated string, as Obsidian accepts both for tags
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	var items []string
+	if value.Kind == yaml.SequenceNode {
+		if err := value.Decode(&items); err != nil {
+			return err
+		}
+	} else {
+		var text string
+		if err := value.Decode(&text); err != nil {
+			return err
+		}
+		items = strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	*l = nil
+	for _, item := range items {
+		if item = strings.TrimPrefix(strings.TrimSpace(item), "#"); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// importFrontMatter also accepts the keys other tools use for the creation date
+type importFrontMatter struct {
+	NoteFrontMatter `yaml:",inline"`
+	Date            *time.Time `yaml:"date,omitempty"`
+	Created         *time.Time `yaml:"created,omitempty"`
+}
+
+// ImportItem is what happens (or would happen) to one Markdown file
+type ImportItem struct {
+	Path     string   `json:"path"`
+	Title    string   `json:"title"`
+	Notebook string   `json:"notebook,omitempty"`
+	Action   string   `json:"action"` // create or skip
+	Reason   string   `json:"reason,omitempty"`
+	NoteID   uint     `json:"note_id,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ImportReport summarizes an import; with DryRun nothing was written
+type ImportReport struct {
+	DryRun    bool         `json:"dry_run"`
+	Notebooks []string     `json:"notebooks"` // Notebooks created for folders that had no match
+	Notes     []ImportItem `json:"notes"`
+	Images    int          `json:"images"` // Images copied into uploads
+	Created   int          `json:"created"`
+	Skipped   int          `json:"skipped"`
+}
+
+type ImportOptions struct {
+	UserID uint
+	DryRun bool
+}
+
+// importDoc is a parsed Markdown file waiting to be created
+type importDoc struct {
+	item       *ImportItem
+	file       string // Path in the source
+	dir        string
+	meta       importFrontMatter
+	body       string
+	notebookID *uint
+}
+
+type ImportService struct {
+	uploadsDir string
+	revisions  *RevisionService
+}
+
+func NewImportService(uploadsDir string) *ImportService {
+	return &ImportService{uploadsDir: uploadsDir, revisions: NewRevisionService(SystemClock)}
+}
+
+// OpenImportSource opens a ZIP archive or a directory for Import. Close the returned closer when done.
+func OpenImportSource(source string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(source), io.NopCloser(nil), nil
+	}
+	archive, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return archive, archive, nil
+}
+
+// Import creates notes from the Markdown files in fsys. Top-level folders become notebooks
+// (front matter `notebook` wins), embedded images are copied into the uploads directory and
+// Obsidian links are converted to [[Title]] links. Notes whose title already exists in the
+// same notebook, or whose front matter slug is taken by one of the user's notes, are skipped.
+// Everything is created in one transaction.
+func (s *ImportService) Import(fsys fs.FS, opts ImportOptions) (*ImportReport, error) {
+	files, err := importFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	// A ZIP of a vault folder wraps everything in that folder; treat it as the root
+	root := ""
+	if top := commonTopDir(files); top != "" {
+		root = top + "/"
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Notebooks: []string{}, Notes: []ImportItem{}}
+	var docs []*importDoc
+	for _, file := range files {
+		ext := strings.ToLower(path.Ext(file))
+		if ext != ".md" && ext != ".markdown" {
+			continue
+		}
+		report.Notes = append(report.Notes, ImportItem{Path: strings.TrimPrefix(file, root), Action: ImportCreate})
+		docs = append(docs, &importDoc{file: file, dir: path.Dir(file)})
+	}
+	if len(docs) == 0 {
+		return nil, ErrNoMarkdownFiles
+	}
+	for i, doc := range docs {
+		doc.item = &report.Notes[i]
+	}
+
+	// 1. Parse files and pick titles and notebooks
+	titles := map[string]string{} // lower-case path from the root, and base name, without extension -> note title
+	for _, doc := range docs {
+		s.parseDoc(fsys, doc)
+		if doc.item.Action == ImportSkip {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(doc.item.Path, path.Ext(doc.item.Path)))
+		titles[name] = doc.item.Title
+		if _, ok := titles[path.Base(name)]; !ok {
+			titles[path.Base(name)] = doc.item.Title
+		}
+	}
+
+	// 2. Match notebooks and skip duplicates
+	notebooks, err := s.matchNotebooks(opts.UserID, docs, report)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.skipDuplicates(opts.UserID, docs, notebooks); err != nil {
+		return nil, err
+	}
+
+	// 3. Convert links and collect images
+	images := map[string]string{} // file in fsys -> upload name
+	byName := map[string][]string{}
+	for _, file := range files {
+		name := strings.ToLower(path.Base(file))
+		byName[name] = append(byName[name], file)
+	}
+	for _, doc := range docs {
+		if doc.item.Action == ImportSkip {
+			continue
+		}
+		s.convertDoc(fsys, root, doc, titles, byName, images)
+	}
+
+	for _, item := range report.Notes {
+		if item.Action == ImportSkip {
+			report.Skipped++
+		} else {
+			report.Created++
+		}
+	}
+	report.Images = len(images)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// 4. Copy images, then write everything at once
+	copied, err := s.copyImages(fsys, images)
+	if err != nil {
+		removeFiles(copied)
+		return nil, err
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, name := range report.Notebooks {
+			notebook := notebooks[strings.ToLower(name)]
+			notebook.Slug = uniqueNotebookSlug(tx, notebookSlug("", notebook.Name), 0)
+			if err := tx.Create(notebook).Error; err != nil {
+				return fmt.Errorf("notebook %s: %w", name, err)
+			}
+		}
+		for _, doc := range docs {
+			if doc.item.Action == ImportSkip {
+				continue
+			}
+			if doc.item.Notebook != "" {
+				doc.notebookID = &notebooks[strings.ToLower(doc.item.Notebook)].ID
+			}
+			if err := s.createNote(tx, opts.UserID, doc); err != nil {
+				return fmt.Errorf("%s: %w", doc.item.Path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		removeFiles(copied)
+		return nil, err
+	}
+	return report, nil
+}
+
+// importFiles lists regular files, leaving out hidden ones (.obsidian, .trash, ...) and macOS metadata
+func importFiles(fsys fs.FS) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if p != "." && (strings.HasPrefix(name, ".") || name == "__MACOSX") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// commonTopDir returns the folder every file sits in, if there is exactly one
+func commonTopDir(files []string) string {
+	top := ""
+	for _, file := range files {
+		dir, _, found := strings.Cut(file, "/")
+		if !found || (top != "" && dir != top) {
+			return ""
+		}
+		top = dir
+	}
+	return top
+}
+
+// parseDoc reads a file's front matter and decides its title and notebook name
+func (s *ImportService) parseDoc(fsys fs.FS, doc *importDoc) {
+	item := doc.item
+	item.Title = strings.TrimSuffix(path.Base(doc.file), path.Ext(doc.file))
+
+	data, err := readLimited(fsys, doc.file, MaxImportNoteSize)
+	if err != nil {
+		item.Action, item.Reason = ImportSkip, err.Error()
+		return
+	}
+	text := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		header, body, found := strings.Cut(rest, "\n---")
+		if found && (body == "" || body[0] == '\n') {
+			if err := yaml.Unmarshal([]byte(header), &doc.meta); err != nil {
+				item.Action, item.Reason = ImportSkip, "invalid front matter: "+err.Error()
+				return
+			}
+			text = strings.TrimPrefix(body, "\n")
+		}
+	}
+	doc.body = strings.TrimLeft(text, "\n")
+
+	if title := strings.TrimSpace(doc.meta.Title); title != "" {
+		item.Title = title
+	}
+	if len(item.Title) > 255 {
+		item.Title = strings.ToValidUTF8(item.Title[:255], "")
+	}
+
+	// Folders directly under the root are notebooks; deeper folders are flattened into them
+	if notebook := strings.TrimSpace(doc.meta.Notebook); notebook != "" {
+		item.Notebook = notebook
+	} else if dir, _, found := strings.Cut(item.Path, "/"); found {
+		item.Notebook = dir
+	}
+}
+
+// matchNotebooks maps notebook names (case-insensitive) to the user's notebooks,
+// preparing new ones for names that don't exist yet
+func (s *ImportService) matchNotebooks(userID uint, docs []*importDoc, report *ImportReport) (map[string]*models.Notebook, error) {
+	var existing []models.Notebook
+	if err := database.DB.Where("user_id = ?", userID).Order("id asc").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	notebooks := map[string]*models.Notebook{}
+	for i := range existing {
+		key := strings.ToLower(existing[i].Name)
+		if _, ok := notebooks[key]; !ok {
+			notebooks[key] = &existing[i]
+		}
+	}
+
+	for _, doc := range docs {
+		name := doc.item.Notebook
+		if name == "" || doc.item.Action == ImportSkip {
+			continue
+		}
+		if _, ok := notebooks[strings.ToLower(name)]; !ok {
+			notebooks[strings.ToLower(name)] = &models.Notebook{UserID: userID, Name: name, Version: 1}
+			report.Notebooks = append(report.Notebooks, name)
+		}
+		doc.item.Notebook = notebooks[strings.ToLower(name)].Name
+	}
+	return notebooks, nil
+}
+
+// skipDuplicates marks notes already present in the account or earlier in the import
+func (s *ImportService) skipDuplicates(userID uint, docs []*importDoc, notebooks map[string]*models.Notebook) error {
+	var existing []models.Note
+	if err := database.DB.Select("id, title, slug, notebook_id").Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	// Titles are compared within a notebook, by lower-case notebook name ("" outside notebooks)
+	names := map[uint]string{}
+	for key, notebook := range notebooks {
+		if notebook.ID != 0 {
+			names[notebook.ID] = key
+		}
+	}
+	titles := map[string]bool{}
+	slugs := map[string]bool{}
+	for _, note := range existing {
+		notebook := ""
+		if note.NotebookID != nil {
+			notebook = fmt.Sprintf("#%d", *note.NotebookID)
+			if name, ok := names[*note.NotebookID]; ok {
+				notebook = name
+			}
+		}
+		titles[notebook+"|"+strings.ToLower(note.Title)] = true
+		slugs[note.Slug] = true
+	}
+
+	for _, doc := range docs {
+		if doc.item.Action == ImportSkip {
+			continue
+		}
+		key := strings.ToLower(doc.item.Notebook) + "|" + strings.ToLower(doc.item.Title)
+		switch {
+		case doc.meta.Slug != "" && slugs[doc.meta.Slug]:
+			doc.item.Action, doc.item.Reason = ImportSkip, "duplicate: slug "+doc.meta.Slug+" already exists"
+		case titles[key]:
+			doc.item.Action, doc.item.Reason = ImportSkip, "duplicate: a note with this title already exists"
+		default:
+			titles[key] = true
+			if doc.meta.Slug != "" {
+				slugs[doc.meta.Slug] = true
+			}
+		}
+	}
+	return nil
+}
+
+// convertDoc rewrites Obsidian syntax to what the editor understands and assigns upload names to images
+func (s *ImportService) convertDoc(fsys fs.FS, root string, doc *importDoc, titles map[string]string, byName map[string][]string, images map[string]string) {
+	warn := func(format string, args ...interface{}) {
+		doc.item.Warnings = append(doc.item.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	// image returns the upload URL for a reference, or "" when it isn't a local image
+	image := func(ref string) string {
+		if ref == "" || strings.HasPrefix(ref, "/public/uploads/") || strings.HasPrefix(ref, "data:") || strings.Contains(ref, "://") {
+			return ""
+		}
+		if decoded, err := url.PathUnescape(ref); err == nil {
+			ref = decoded
+		}
+		if !importImageExts[strings.ToLower(path.Ext(ref))] {
+			return ""
+		}
+		file := resolveImportFile(fsys, doc.dir, ref, byName)
+		if file == "" {
+			warn("image not found: %s", ref)
+			return ""
+		}
+		if _, ok := images[file]; !ok {
+			images[file] = uuid.New().String() + strings.ToLower(path.Ext(file))
+		}
+		return UploadsURLPrefix + images[file]
+	}
+
+	// link turns an Obsidian target (folder/Note#Heading, relative to the vault) into the title of the note it points at
+	link := func(target string) string {
+		target, _, _ = strings.Cut(target, "#")
+		target = strings.TrimSpace(target)
+		if ext := strings.ToLower(path.Ext(target)); ext == ".md" || ext == ".markdown" {
+			target = strings.TrimSuffix(target, path.Ext(target))
+		}
+		if title, ok := titles[strings.ToLower(target)]; ok {
+			return title
+		}
+		if title, ok := titles[strings.ToLower(path.Clean(path.Join(strings.TrimPrefix(doc.dir+"/", root), target)))]; ok {
+			return title
+		}
+		if title, ok := titles[strings.ToLower(path.Base(target))]; ok {
+			return title
+		}
+		return path.Base(target)
+	}
+
+	convert := func(text string) string {
+		text = embedPattern.ReplaceAllStringFunc(text, func(match string) string {
+			target, _, _ := strings.Cut(embedPattern.FindStringSubmatch(match)[1], "|")
+			ext := strings.ToLower(path.Ext(target))
+			if importImageExts[ext] {
+				if url := image(target); url != "" {
+					return "![" + strings.TrimSuffix(path.Base(target), path.Ext(target)) + "](" + url + ")"
+				}
+				return match
+			}
+			if ext != "" && ext != ".md" && !strings.Contains(ext, "#") {
+				warn("attachment not imported: %s", target)
+				return match
+			}
+			return "[[" + link(target) + "]]"
+		})
+		text = obsidianLink.ReplaceAllStringFunc(text, func(match string) string {
+			target, label, _ := strings.Cut(obsidianLink.FindStringSubmatch(match)[1], "|")
+			if strings.HasPrefix(target, "#") {
+				// Same-note heading links have no equivalent; keep the text
+				if label != "" {
+					return label
+				}
+				return strings.TrimPrefix(target, "#")
+			}
+			if label != "" {
+				return "[[" + link(target) + "|" + label + "]]"
+			}
+			return "[[" + link(target) + "]]"
+		})
+		text = mdImagePattern.ReplaceAllStringFunc(text, func(match string) string {
+			m := mdImagePattern.FindStringSubmatch(match)
+			if url := image(strings.Trim(m[2], "<>")); url != "" {
+				return "![" + m[1] + "](" + url + m[3] + ")"
+			}
+			return match
+		})
+		text = replaceNoteLinks(text, func(label, target string) string {
+			if decoded, err := url.PathUnescape(target); err == nil {
+				target = decoded
+			}
+			return "[[" + link(target) + "|" + label + "]]"
+		})
+		return htmlImgPattern.ReplaceAllStringFunc(text, func(match string) string {
+			m := htmlImgPattern.FindStringSubmatch(match)
+			if url := image(m[2]); url != "" {
+				return m[1] + url + m[3]
+			}
+			return match
+		})
+	}
+
+	doc.body = outsideCodeFences(doc.body, convert)
+	if doc.meta.Cover != "" {
+		if url := image(doc.meta.Cover); url != "" {
+			doc.meta.Cover = url
+		}
+	}
+}
+
+// replaceNoteLinks rewrites [label](Other Note.md) links, leaving images alone
+func replaceNoteLinks(text string, fn func(label, target string) string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range mdNoteLink.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > 0 && text[m[0]-1] == '!' {
+			continue
+		}
+		out.WriteString(text[last:m[0]])
+		out.WriteString(fn(text[m[2]:m[3]], strings.Trim(text[m[4]:m[5]], "<>")))
+		last = m[1]
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// outsideCodeFences applies convert to the Markdown outside fenced code blocks
+func outsideCodeFences(text string, convert func(string) string) string {
+	var out, chunk strings.Builder
+	fence := ""
+	for _, line := range strings.SplitAfter(text, "\n") {
+		marker := codeFencePrefix.FindStringSubmatch(line)
+		switch {
+		case fence == "" && marker != nil:
+			out.WriteString(convert(chunk.String()))
+			chunk.Reset()
+			fence = marker[1]
+			out.WriteString(line)
+		case fence != "":
+			out.WriteString(line)
+			if marker != nil && marker[1] == fence {
+				fence = ""
+			}
+		default:
+			chunk.WriteString(line)
+		}
+	}
+	out.WriteString(convert(chunk.String()))
+	return out.String()
+}
+
+// resolveImportFile finds a referenced file relative to the note, then anywhere by name as Obsidian does
+func resolveImportFile(fsys fs.FS, dir, ref string, byName map[string][]string) string {
+	if !strings.HasPrefix(ref, "/") {
+		if file := path.Join(dir, ref); fs.ValidPath(file) {
+			if info, err := fs.Stat(fsys, file); err == nil && info.Mode().IsRegular() {
+				return file
+			}
+		}
+	}
+	if matches := byName[strings.ToLower(path.Base(ref))]; len(matches) > 0 {
+		return matches[0]
+	}
+	return ""
+}
+
+func readLimited(fsys fs.FS, file string, limit int64) ([]byte, error) {
+	f, err := fsys.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file is larger than %d MB", limit>>20)
+	}
+	return data, nil
+}
+
+// copyImages writes the images into the uploads directory, returning the paths written
+func (s *ImportService) copyImages(fsys fs.FS, images map[string]string) ([]string, error) {
+	var copied []string
+	if err := os.MkdirAll(s.uploadsDir, 0o755); err != nil {
+		return nil, err
+	}
+	for file, name := range images {
+		data, err := readLimited(fsys, file, MaxImportImageSize)
+		if err != nil {
+			return copied, fmt.Errorf("%s: %w", file, err)
+		}
+		dst := filepath.Join(s.uploadsDir, name)
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return copied, err
+		}
+		copied = append(copied, dst)
+	}
+	return copied, nil
+}
+
+func removeFiles(files []string) {
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// createNote saves an imported note the way CreateNote does, keeping its status and dates
+func (s *ImportService) createNote(tx *gorm.DB, userID uint, doc *importDoc) error {
+	meta := doc.meta
+	note := models.Note{
+		UserID:      userID,
+		NotebookID:  doc.notebookID,
+		Title:       doc.item.Title,
+		CoverImage:  meta.Cover,
+		IsFeatured:  meta.Featured,
+		Status:      models.NoteStatusDraft,
+		PublishedAt: meta.PublishedAt,
+		UnpublishAt: meta.UnpublishAt,
+		Version:     1,
+	}
+
+	switch status := strings.ToUpper(meta.Status); status {
+	case models.NoteStatusPublished, models.NoteStatusArchived:
+		note.Status = status
+	case models.NoteStatusScheduled:
+		if meta.PublishAt != nil {
+			note.Status, note.PublishAt = status, meta.PublishAt
+		}
+	}
+
+	for _, created := range []*time.Time{meta.CreatedAt, meta.Created, meta.Date} {
+		if created != nil {
+			note.CreatedAt = created.UTC()
+			break
+		}
+	}
+	if meta.UpdatedAt != nil {
+		note.UpdatedAt = meta.UpdatedAt.UTC()
+	}
+	if note.Status == models.NoteStatusPublished && note.PublishedAt == nil {
+		published := note.CreatedAt
+		if published.IsZero() {
+			published = time.Now().UTC()
+		}
+		note.PublishedAt = &published
+	}
+
+	if err := setNoteContent(&note, doc.body, models.ContentFormatMarkdown); err != nil {
+		return err
+	}
+	if meta.Excerpt != "" {
+		setExcerpt(&note, meta.Excerpt)
+	}
+	applyContentStats(&note)
+
+	if err := insertImportedNote(tx, s.revisions, &note, meta.Slug, meta.Tags); err != nil {
+		return err
+	}
+	doc.item.NoteID = note.ID
+	return nil
+}
+
+// insertImportedNote saves a note prepared by an importer the way CreateNote does:
+// unique slug, tags, search index, links and the initial revision
+func insertImportedNote(tx *gorm.DB, revisions *RevisionService, note *models.Note, slug string, tags []string) error {
+	if slug == "" {
+		slug = note.Title
+	}
+	note.Slug = uniqueNoteSlug(tx, utils.GenerateSlug(slug), 0)
+	updatedAt := note.UpdatedAt
+
+	if err := tx.Create(note).Error; err != nil {
+		return err
+	}
+	if err := replaceNoteTags(tx, note, tags); err != nil {
+		return err
+	}
+	// Saving the tags touches the note; keep the source's timestamp
+	if !updatedAt.IsZero() {
+		if err := tx.Model(note).UpdateColumn("updated_at", updatedAt).Error; err != nil {
+			return err
+		}
+		note.UpdatedAt = updatedAt
+	}
+	if err := indexNote(tx, note); err != nil {
+		return err
+	}
+	if err := syncNoteLinks(tx, note); err != nil {
+		return err
+	}
+	if err := refreshLinksTo(tx, note); err != nil {
+		return err
+	}
+	return revisions.Snapshot(tx, note, false)
+}
@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func importItem(report *services.ImportReport, path string) services.ImportItem {
+	for _, item := range report.Notes {
+		if item.Path == path {
+			return item
+		}
+	}
+	return services.ImportItem{}
+}
+
+func TestImportService_ObsidianVault(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	existing := models.Notebook{UserID: 1, Name: "Work", Slug: "work"}
+	database.DB.Create(&existing)
+	database.DB.Create(&models.Note{UserID: 1, NotebookID: &existing.ID, Title: "Standup", Slug: "standup"})
+
+	vault := fstest.MapFS{
+		"Vault/.obsidian/app.json": {Data: []byte(`{}`)},
+		"Vault/Inbox.md": {Data: []byte("---\ntitle: Inbox Zero\ntags: gtd, focus\ndate: 2025-01-05\nstatus: published\n---\n" +
+			"Plan the [[Roadmap|big plan]] and see [[Work/Roadmap#Q3]].\n\n![[diagram.png|300]]\n\n" +
+			"![Photo](attachments/photo%201.jpg \"Holiday\")\n\n[Meeting notes](Work/Standup.md)\n\n" +
+			"```\n[[Not A Link]] ![[diagram.png]]\n```\n")},
+		"Vault/Work/Roadmap.md":          {Data: []byte("# Roadmap\n\nBack to [[Inbox]]. Missing ![[ghost.png]] and ![[spec.pdf]].\n")},
+		"Vault/Work/Standup.md":          {Data: []byte("Duplicate of an existing note\n")},
+		"Vault/Work/Deep/Nested Idea.md": {Data: []byte("nested\n")},
+		"Vault/attachments/diagram.png":  {Data: []byte("png")},
+		"Vault/attachments/photo 1.jpg":  {Data: []byte("jpg")},
+		"Vault/Archive/Broken.md":        {Data: []byte("---\ntitle: [unclosed\n---\nbody\n")},
+	}
+
+	uploads := t.TempDir()
+	service := services.NewImportService(uploads)
+
+	// 1. Dry run reports without writing
+	report, err := service.Import(vault, services.ImportOptions{UserID: 1, DryRun: true})
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 3, report.Created)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, 2, report.Images)
+	assert.Empty(t, report.Notebooks)
+
+	assert.Equal(t, services.ImportSkip, importItem(report, "Work/Standup.md").Action)
+	assert.Contains(t, importItem(report, "Work/Standup.md").Reason, "duplicate")
+	assert.Contains(t, importItem(report, "Archive/Broken.md").Reason, "invalid front matter")
+	assert.Equal(t, "Work", importItem(report, "Work/Deep/Nested Idea.md").Notebook)
+
+	var count int64
+	database.DB.Model(&models.Note{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	files, _ := os.ReadDir(uploads)
+	assert.Empty(t, files)
+
+	// 2. Real import
+	report, err = service.Import(vault, services.ImportOptions{UserID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+	files, _ = os.ReadDir(uploads)
+	assert.Len(t, files, 2)
+
+	var inbox models.Note
+	database.DB.Preload("Tags").Where("title = ?", "Inbox Zero").First(&inbox)
+	assert.Equal(t, models.ContentFormatMarkdown, inbox.ContentFormat)
+	assert.Equal(t, models.NoteStatusPublished, inbox.Status)
+	assert.Nil(t, inbox.NotebookID)
+	assert.Equal(t, "2025-01-05", inbox.CreatedAt.UTC().Format("2006-01-02"))
+	if assert.NotNil(t, inbox.PublishedAt) {
+		assert.True(t, inbox.PublishedAt.Equal(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)))
+	}
+	assert.Len(t, inbox.Tags, 2)
+
+	md := inbox.Markdown
+	assert.Contains(t, md, "Plan the [[Roadmap|big plan]] and see [[Roadmap]].")
+	assert.Regexp(t, `!\[diagram\]\(/public/uploads/[0-9a-f-]+\.png\)`, md)
+	assert.Regexp(t, `!\[Photo\]\(/public/uploads/[0-9a-f-]+\.jpg "Holiday"\)`, md)
+	assert.Contains(t, md, "[[Standup|Meeting notes]]")
+	assert.Contains(t, md, "```\n[[Not A Link]] ![[diagram.png]]\n```")
+	assert.Contains(t, inbox.Content, "/public/uploads/")
+
+	// 3. Folders map to notebooks, matching existing ones by name
+	var roadmap models.Note
+	database.DB.Where("title = ?", "Roadmap").First(&roadmap)
+	if assert.NotNil(t, roadmap.NotebookID) {
+		assert.Equal(t, existing.ID, *roadmap.NotebookID)
+	}
+	assert.Contains(t, roadmap.Markdown, "![[ghost.png]]")
+	assert.Len(t, importItem(report, "Work/Roadmap.md").Warnings, 2)
+
+	// 4. Wiki links between imported notes resolve, whichever was created first
+	var links []models.NoteLink
+	database.DB.Where("source_note_id IN ? AND target IN ?", []uint{inbox.ID, roadmap.ID}, []string{"Roadmap", "Standup", "Inbox Zero"}).Find(&links)
+	assert.Len(t, links, 4)
+	for _, link := range links {
+		assert.NotNil(t, link.TargetNoteID, link.Target)
+	}
+
+	// 5. Importing again skips everything
+	report, err = service.Import(vault, services.ImportOptions{UserID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 5, report.Skipped)
+}
+
+func TestImportService_ExportRoundTrip(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	uploads := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(uploads, "cover.png"), []byte("png"), 0o644))
+
+	notebook := models.Notebook{UserID: 1, Name: "Recipes", Slug: "recipes"}
+	database.DB.Create(&notebook)
+	database.DB.Create(&models.Note{UserID: 1, NotebookID: &notebook.ID, Title: "Pancakes", Slug: "pancakes",
+		Status: models.NoteStatusPublished, Content: `<p>Flour <strong>and</strong> eggs</p><p><img src="/public/uploads/cover.png" alt="Stack"></p>`,
+		Excerpt: "Sunday breakfast", IsFeatured: true})
+
+	notes, err := services.NewExportService(uploads).Notes(1, nil)
+	assert.NoError(t, err)
+	var archive bytes.Buffer
+	assert.NoError(t, services.NewExportService(uploads).WriteZip(&archive, notes))
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	assert.NoError(t, err)
+
+	// Another user imports the archive into a fresh account
+	target := t.TempDir()
+	report, err := services.NewImportService(target).Import(reader, services.ImportOptions{UserID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []string{"Recipes"}, report.Notebooks)
+
+	var copy models.Note
+	database.DB.Preload("Notebook").Where("user_id = ?", 2).First(&copy)
+	assert.Equal(t, "Pancakes", copy.Title)
+	assert.Equal(t, "pancakes-1", copy.Slug)
+	assert.Equal(t, models.NoteStatusPublished, copy.Status)
+	assert.True(t, copy.IsFeatured)
+	assert.Equal(t, "Sunday breakfast", copy.Excerpt)
+	assert.False(t, copy.AutoExcerpt)
+	assert.Equal(t, "Recipes", copy.Notebook.Name)
+	assert.NotEqual(t, notebook.ID, copy.Notebook.ID)
+	assert.True(t, strings.Contains(copy.Content, "<strong>and</strong>"))
+	assert.Regexp(t, `src="/public/uploads/[0-9a-f-]+\.png"`, copy.Content)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+// wikiLinkPattern matches [[Target]] and [[Target|Label]]
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|([^\[\]]+))?\]\]`)
+
+// WikiLink is a parsed [[target|label]] reference
+type WikiLink struct {
+	Target string
+	Label  string
+}
+
+// ParseWikiLinks extracts unique wiki links from HTML content in order of appearance
+func ParseWikiLinks(content string) []WikiLink {
+	links := []WikiLink{}
+	seen := map[string]bool{}
+
+	for _, m := range wikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		target := strings.TrimSpace(html.UnescapeString(m[1]))
+		label := strings.TrimSpace(html.UnescapeString(m[2]))
+		key := strings.ToLower(target) + "|" + label
+		if target == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		links = append(links, WikiLink{Target: target, Label: label})
+	}
+
+	return links
+}
+
+// resolveLinkTarget finds the user's live note whose title (case-insensitive) or slug matches target
+func resolveLinkTarget(tx *gorm.DB, userID uint, target string) *uint {
+	var note models.Note
+	err := tx.Select("id").
+		Where("user_id = ? AND (lower(title) = lower(?) OR slug = ?)", userID, target, target).
+		Order("id asc").
+		First(&note).Error
+	if err != nil {
+		return nil
+	}
+	return &note.ID
+}
+
+// syncNoteLinks replaces the stored outgoing links of a note with those in its content
+func syncNoteLinks(tx *gorm.DB, note *models.Note) error {
+	if err := tx.Where("source_note_id = ?", note.ID).Delete(&models.NoteLink{}).Error; err != nil {
+		return err
+	}
+
+	for _, link := range ParseWikiLinks(note.Content) {
+		row := models.NoteLink{
+			SourceNoteID: note.ID,
+			TargetNoteID: resolveLinkTarget(tx, note.UserID, link.Target),
+			Target:       link.Target,
+			Label:        link.Label,
+		}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshLinksTo re-resolves links affected by a note's title or slug changing:
+// links that no longer match are broken, dangling links that now match are attached.
+func refreshLinksTo(tx *gorm.DB, note *models.Note) error {
+	userNotes := tx.Model(&models.Note{}).Select("id").Where("user_id = ?", note.UserID)
+
+	if err := tx.Model(&models.NoteLink{}).
+		Where("target_note_id = ? AND lower(target) != lower(?) AND target != ?", note.ID, note.Title, note.Slug).
+		Update("target_note_id", nil).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.NoteLink{}).
+		Where("target_note_id IS NULL AND (lower(target) = lower(?) OR target = ?)", note.Title, note.Slug).
+		Where("source_note_id IN (?)", userNotes).
+		Update("target_note_id", note.ID).Error
+}
+
+// rewriteLinksTo updates [[Old Title]] references in other notes after a rename.
+// It returns the notes whose content changed so callers can re-index and snapshot them.
+func rewriteLinksTo(tx *gorm.DB, note *models.Note, oldTitle string) ([]models.Note, error) {
+	var sources []models.Note
+	if err := tx.Where("id IN (?)", tx.Model(&models.NoteLink{}).
+		Select("source_note_id").
+		Where("target_note_id = ? AND lower(target) = lower(?)", note.ID, oldTitle)).
+		Find(&sources).Error; err != nil {
+		return nil, err
+	}
+
+	changed := []models.Note{}
+	for _, source := range sources {
+		content := replaceWikiLinks(source.Content, oldTitle, html.EscapeString(note.Title))
+		// Markdown notes keep their source in step with the rendered HTML
+		markdownSource := replaceWikiLinks(source.Markdown, oldTitle, note.Title)
+		if content == source.Content && markdownSource == source.Markdown {
+			continue
+		}
+
+		source.Content = content
+		source.Markdown = markdownSource
+		source.Version++
+		applyContentStats(&source)
+		columns := contentStatsColumns(&source)
+		columns["content"], columns["markdown"], columns["version"] = content, markdownSource, source.Version
+		if err := tx.Model(&source).Updates(columns).Error; err != nil {
+			return nil, err
+		}
+		if err := syncNoteLinks(tx, &source); err != nil {
+			return nil, err
+		}
+		changed = append(changed, source)
+	}
+
+	return changed, nil
+}
+
+// replaceWikiLinks points [[oldTitle]] and [[oldTitle|label]] references at newTarget
+func replaceWikiLinks(content, oldTitle, newTarget string) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		m := wikiLinkPattern.FindStringSubmatch(match)
+		if !strings.EqualFold(strings.TrimSpace(html.UnescapeString(m[1])), oldTitle) {
+			return match
+		}
+		if m[2] != "" {
+			return "[[" + newTarget + "|" + m[2] + "]]"
+		}
+		return "[[" + newTarget + "]]"
+	})
+}
+
+// removeNoteLinks drops links from purged notes and breaks links pointing at them
+func removeNoteLinks(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("source_note_id IN ?", ids).Delete(&models.NoteLink{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.NoteLink{}).Where("target_note_id IN ?", ids).Update("target_note_id", nil).Error
+}
+
+type LinkService struct{}
+
+func NewLinkService() *LinkService {
+	return &LinkService{}
+}
+
+// markBroken flags links whose target is missing or in the trash
+func markBroken(links []models.NoteLink) {
+	for i := range links {
+		links[i].Broken = links[i].TargetNote == nil
+	}
+}
+
+// OutgoingLinks returns the wiki links found in a note
+func (s *LinkService) OutgoingLinks(noteID string, userID uint) ([]models.NoteLink, error) {
+	note, err := findOwnedNote(database.DB, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []models.NoteLink
+	if err := database.DB.Where("source_note_id = ?", note.ID).
+		Preload("TargetNote", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, title, slug, status")
+		}).
+		Order("id asc").
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	markBroken(links)
+	return links, nil
+}
+
+// Backlinks returns the live notes that link to a note
+func (s *LinkService) Backlinks(noteID string, userID uint) ([]models.Note, error) {
+	note, err := findOwnedNote(database.DB, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []models.Note
+	if err := database.DB.Where("user_id = ?", userID).
+		Where("id IN (?)", database.DB.Model(&models.NoteLink{}).
+			Select("source_note_id").
+			Where("target_note_id = ?", note.ID)).
+		Order("updated_at desc").
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// BrokenLinks returns every unresolved link in the user's live notes
+func (s *LinkService) BrokenLinks(userID uint) ([]models.NoteLink, error) {
+	var links []models.NoteLink
+	if err := database.DB.
+		Joins("JOIN notes AS sources ON sources.id = note_links.source_note_id AND sources.deleted_at IS NULL").
+		Joins("LEFT JOIN notes AS targets ON targets.id = note_links.target_note_id AND targets.deleted_at IS NULL").
+		Where("sources.user_id = ? AND targets.id IS NULL", userID).
+		Preload("SourceNote", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, title, slug, status")
+		}).
+		Order("note_links.source_note_id asc, note_links.id asc").
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range links {
+		links[i].Broken = true
+	}
+	return links, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidMark = errors.New("mark must be pin or favorite")
+
+// MarkState is what a user has marked an item with
+type MarkState struct {
+	Pinned   bool `json:"pinned"`
+	Favorite bool `json:"favorite"`
+}
+
+// Favorites lists a user's favorite notes and notebooks, most recently favorited first
+type Favorites struct {
+	Notes     []models.Note     `json:"notes"`
+	Notebooks []models.Notebook `json:"notebooks"`
+}
+
+type MarkService struct{}
+
+func NewMarkService() *MarkService {
+	return &MarkService{}
+}
+
+// ToggleNote pins or favorites one of the user's notes, or takes the mark off again
+func (s *MarkService) ToggleNote(userID uint, id string, kind string) (*MarkState, error) {
+	return toggleMark(userID, models.MarkItemNote, &models.Note{}, id, kind, ErrNoteNotFound)
+}
+
+// ToggleNotebook pins or favorites one of the user's notebooks, or takes the mark off again
+func (s *MarkService) ToggleNotebook(userID uint, id string, kind string) (*MarkState, error) {
+	return toggleMark(userID, models.MarkItemNotebook, &models.Notebook{}, id, kind, ErrNotebookNotFound)
+}
+
+func toggleMark(userID uint, itemType string, model interface{}, id string, kind string, notFound error) (*MarkState, error) {
+	if kind != models.MarkPin && kind != models.MarkFavorite {
+		return nil, ErrInvalidMark
+	}
+
+	state := &MarkState{}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var itemID uint
+		if err := tx.Model(model).Select("id").Where("id = ? AND user_id = ?", id, userID).Limit(1).Scan(&itemID).Error; err != nil {
+			return err
+		}
+		if itemID == 0 {
+			return notFound
+		}
+
+		mark := models.Mark{UserID: userID, ItemType: itemType, ItemID: itemID, Kind: kind}
+		removed := tx.Where(&mark).Delete(&models.Mark{})
+		if removed.Error != nil {
+			return removed.Error
+		}
+		if removed.RowsAffected == 0 {
+			if err := tx.Create(&mark).Error; err != nil {
+				return err
+			}
+		}
+
+		marks, err := loadMarks(tx, userID, itemType, []uint{itemID})
+		if err != nil {
+			return err
+		}
+		*state = marks[itemID]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Favorites lists the live notes and notebooks the user has favorited
+func (s *MarkService) Favorites(userID uint) (*Favorites, error) {
+	favorites := &Favorites{Notes: []models.Note{}, Notebooks: []models.Notebook{}}
+
+	if err := database.DB.Preload("Notebook").Preload("Tags").
+		Joins("JOIN marks ON marks.item_id = notes.id AND marks.item_type = ? AND marks.kind = ? AND marks.user_id = ?",
+			models.MarkItemNote, models.MarkFavorite, userID).
+		Where("notes.user_id = ?", userID).
+		Order("marks.created_at desc").
+		Find(&favorites.Notes).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.
+		Joins("JOIN marks ON marks.item_id = notebooks.id AND marks.item_type = ? AND marks.kind = ? AND marks.user_id = ?",
+			models.MarkItemNotebook, models.MarkFavorite, userID).
+		Where("notebooks.user_id = ?", userID).
+		Order("marks.created_at desc").
+		Find(&favorites.Notebooks).Error; err != nil {
+		return nil, err
+	}
+
+	if err := fillNoteMarks(userID, favorites.Notes); err != nil {
+		return nil, err
+	}
+	if err := fillNotebookMarks(userID, favorites.Notebooks); err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// loadMarks reads the user's marks on the given items
+func loadMarks(tx *gorm.DB, userID uint, itemType string, ids []uint) (map[uint]MarkState, error) {
+	states := make(map[uint]MarkState, len(ids))
+	if len(ids) == 0 {
+		return states, nil
+	}
+
+	var marks []models.Mark
+	if err := tx.Where("user_id = ? AND item_type = ? AND item_id IN ?", userID, itemType, ids).Find(&marks).Error; err != nil {
+		return nil, err
+	}
+	for _, mark := range marks {
+		state := states[mark.ItemID]
+		switch mark.Kind {
+		case models.MarkPin:
+			state.Pinned = true
+		case models.MarkFavorite:
+			state.Favorite = true
+		}
+		states[mark.ItemID] = state
+	}
+	return states, nil
+}
+
+// fillNoteMarks sets Pinned and Favorite on notes for the user
+func fillNoteMarks(userID uint, notes []models.Note) error {
+	ids := make([]uint, len(notes))
+	for i := range notes {
+		ids[i] = notes[i].ID
+	}
+	states, err := loadMarks(database.DB, userID, models.MarkItemNote, ids)
+	if err != nil {
+		return err
+	}
+	for i := range notes {
+		notes[i].Pinned = states[notes[i].ID].Pinned
+		notes[i].Favorite = states[notes[i].ID].Favorite
+	}
+	return nil
+}
+
+// fillNotebookMarks sets Pinned and Favorite on notebooks for the user, including nested children
+func fillNotebookMarks(userID uint, notebooks []models.Notebook) error {
+	var ids []uint
+	var collect func(list []models.Notebook)
+	collect = func(list []models.Notebook) {
+		for i := range list {
+			ids = append(ids, list[i].ID)
+			collect(list[i].Children)
+		}
+	}
+	collect(notebooks)
+
+	states, err := loadMarks(database.DB, userID, models.MarkItemNotebook, ids)
+	if err != nil {
+		return err
+	}
+	var apply func(list []models.Notebook)
+	apply = func(list []models.Notebook) {
+		for i := range list {
+			list[i].Pinned = states[list[i].ID].Pinned
+			list[i].Favorite = states[list[i].ID].Favorite
+			apply(list[i].Children)
+		}
+	}
+	apply(notebooks)
+	return nil
+}
+
+// pinnedNoteIDs selects the IDs of the notes a user has pinned, for use as a subquery
+func pinnedNoteIDs(userID uint) *gorm.DB {
+	return database.DB.Model(&models.Mark{}).Select("item_id").
+		Where("user_id = ? AND item_type = ? AND kind = ?", userID, models.MarkItemNote, models.MarkPin)
+}
+
+// removeMarks deletes every mark on the given items, for items that are purged
+func removeMarks(tx *gorm.DB, itemType string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Where("item_type = ? AND item_id IN ?", itemType, ids).Delete(&models.Mark{}).Error
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotATemplate        = errors.New("note is not a template")
+	ErrInvalidNoteNotebook = errors.New("notebook_id must be one of your notebooks")
+)
+
+// placeholderPattern matches template placeholders such as {{date}} or {{ user.name }}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z]+(?:\.[a-z]+)?)\s*\}\}`)
+
+// DuplicateNoteRequest copies a note into a new draft
+type DuplicateNoteRequest struct {
+	ID         string         `validate:"required"`
+	UserID     uint           `validate:"required"`
+	Title      string         `validate:"omitempty,max=255"` // Defaults to "<title> (copy)"
+	NotebookID Optional[uint] // Unset keeps the original's notebook; null leaves the copy outside notebooks
+}
+
+// InstantiateTemplateRequest creates a new draft from a template note
+type InstantiateTemplateRequest struct {
+	ID         string         `validate:"required"`
+	UserID     uint           `validate:"required"`
+	Title      string         `validate:"omitempty,max=255"` // Defaults to the template's title with placeholders expanded
+	NotebookID Optional[uint] // Unset keeps the template's notebook; null leaves the note outside notebooks
+}
+
+// DuplicateNote copies a note's content, excerpt, cover and tags into a new draft under a new slug.
+// The copy of a template is a template too; placeholders are copied as they are.
+func (s *NoteService) DuplicateNote(req DuplicateNoteRequest) (*models.Note, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var note *models.Note
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		source, err := findOwnedNote(tx.Preload("Tags"), req.ID, req.UserID)
+		if err != nil {
+			return err
+		}
+
+		title := req.Title
+		if title == "" {
+			title = source.Title + " (copy)"
+		}
+		note, err = s.copyNote(tx, source, title, req.NotebookID, func(text string, _ bool) string { return text })
+		if err != nil {
+			return err
+		}
+		note.IsTemplate = source.IsTemplate
+		return s.insertNote(tx, note, title, tagNames(source.Tags))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// InstantiateTemplate creates a new draft from a template, expanding the placeholders in its title,
+// content and excerpt: {{date}}, {{time}}, {{title}} (the new note's title), {{notebook}},
+// {{user.name}} and {{user.username}}. Unknown placeholders are left as they are.
+func (s *NoteService) InstantiateTemplate(req InstantiateTemplateRequest) (*models.Note, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var note *models.Note
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		template, err := findOwnedNote(tx.Preload("Tags"), req.ID, req.UserID)
+		if err != nil {
+			return err
+		}
+		if !template.IsTemplate {
+			return ErrNotATemplate
+		}
+
+		var user models.User
+		if err := tx.First(&user, req.UserID).Error; err != nil {
+			return err
+		}
+		notebookID := template.NotebookID
+		if req.NotebookID.Set {
+			notebookID = req.NotebookID.Value
+		}
+		// A notebook that isn't the user's is rejected by copyNote
+		var notebook models.Notebook
+		if notebookID != nil {
+			if err := tx.Where("id = ? AND user_id = ?", *notebookID, req.UserID).Limit(1).Find(&notebook).Error; err != nil {
+				return err
+			}
+		}
+
+		now := s.clock.Now().UTC()
+		values := map[string]string{
+			"date":          now.Format(time.DateOnly),
+			"time":          now.Format("15:04"),
+			"title":         template.Title,
+			"notebook":      notebook.Name,
+			"user.name":     user.Name,
+			"user.username": user.Username,
+		}
+		title := req.Title
+		if title == "" {
+			title = expandPlaceholders(template.Title, values, false)
+		}
+		values["title"] = title
+
+		note, err = s.copyNote(tx, template, title, req.NotebookID, func(text string, isHTML bool) string {
+			return expandPlaceholders(text, values, isHTML)
+		})
+		if err != nil {
+			return err
+		}
+		return s.insertNote(tx, note, title, tagNames(template.Tags))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// copyNote builds an unsaved draft from source with the given title, passing its body and written
+// excerpt through expand (told whether the text is HTML). notebookID overrides the source's notebook when set.
+func (s *NoteService) copyNote(tx *gorm.DB, source *models.Note, title string, notebookID Optional[uint], expand func(text string, isHTML bool) string) (*models.Note, error) {
+	note := &models.Note{
+		UserID:     source.UserID,
+		Title:      title,
+		Status:     models.NoteStatusDraft,
+		NotebookID: source.NotebookID,
+		CoverImage: source.CoverImage,
+		Version:    1,
+	}
+	if notebookID.Set {
+		if notebookID.Value != nil {
+			var count int64
+			if err := tx.Model(&models.Notebook{}).Where("id = ? AND user_id = ?", *notebookID.Value, source.UserID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, ErrInvalidNoteNotebook
+			}
+		}
+		note.NotebookID = notebookID.Value
+	}
+
+	format, body := models.ContentFormatHTML, source.Content
+	if source.ContentFormat == models.ContentFormatMarkdown {
+		format, body = models.ContentFormatMarkdown, source.Markdown
+	}
+	if err := setNoteContent(note, expand(body, format == models.ContentFormatHTML), format); err != nil {
+		return nil, err
+	}
+	if !source.AutoExcerpt {
+		note.Excerpt = expand(source.Excerpt, false)
+	}
+	applyContentStats(note)
+	return note, nil
+}
+
+// expandPlaceholders replaces known placeholders with their values, HTML-escaped for HTML content
+func expandPlaceholders(text string, values map[string]string, escape bool) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		value, ok := values[placeholderPattern.FindStringSubmatch(match)[1]]
+		if !ok {
+			return match
+		}
+		if escape {
+			return html.EscapeString(value)
+		}
+		return value
+	})
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/tarakreasi/taraNote_go/internal/markdown"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+)
+
+var ErrInvalidFormat = errors.New("format must be html or markdown")
+
+// ValidContentFormat reports whether format is a supported note content format
+func ValidContentFormat(format string) bool {
+	return format == models.ContentFormatHTML || format == models.ContentFormatMarkdown
+}
+
+// setNoteContent stores content written in format. Markdown is kept as the source and
+// rendered to sanitized HTML, so Content is always safe to display and index.
+func setNoteContent(note *models.Note, content, format string) error {
+	switch format {
+	case models.ContentFormatMarkdown:
+		rendered, err := markdown.ToHTML(content)
+		if err != nil {
+			return err
+		}
+		note.Markdown = content
+		note.Content = SanitizeHTML(rendered)
+	case models.ContentFormatHTML:
+		note.Markdown = ""
+		note.Content = SanitizeHTML(content)
+	default:
+		return ErrInvalidFormat
+	}
+	note.ContentFormat = format
+	return nil
+}
+
+// NoteContentAs returns the note body in format. Markdown notes return their source;
+// HTML notes are converted.
+func NoteContentAs(note *models.Note, format string) (string, error) {
+	switch format {
+	case models.ContentFormatHTML:
+		return note.Content, nil
+	case models.ContentFormatMarkdown:
+		if note.ContentFormat == models.ContentFormatMarkdown {
+			return note.Markdown, nil
+		}
+		return markdown.FromHTML(note.Content)
+	}
+	return "", ErrInvalidFormat
+}
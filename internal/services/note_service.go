@@ -6,25 +6,43 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPublishAtRequired      = errors.New("publish_at is required for scheduled notes")
+	ErrUnpublishBeforePublish = errors.New("unpublish_at must be after the publish time")
 )
 
 type NoteService struct {
-	validate *validator.Validate
+	validate  *validator.Validate
+	revisions *RevisionService
+	search    *SearchService
+	clock     Clock
 }
 
 func NewNoteService() *NoteService {
 	return &NoteService{
-		validate: validator.New(),
+		validate:  validator.New(),
+		revisions: NewRevisionService(SystemClock),
+		search:    NewSearchService(),
+		clock:     SystemClock,
 	}
 }
 
 type CreateNoteRequest struct {
 	UserID     uint   `validate:"required"`
 	Title      string `validate:"required,min=1,max=255"`
+	Slug       string `validate:"omitempty,max=255"` // Defaults to the slugified title
 	NotebookID *uint
+	Tags       []string
+	// Content is written in ContentFormat (html when empty)
+	Content       string
+	ContentFormat string `validate:"omitempty,oneof=html markdown"`
+	IsTemplate    bool
 }
 
 func (s *NoteService) CreateNote(req CreateNoteRequest) (*models.Note, error) {
@@ -33,34 +51,85 @@ func (s *NoteService) CreateNote(req CreateNoteRequest) (*models.Note, error) {
 		return nil, err
 	}
 
-	// 2. Logic (Slug generation)
-	slug := fmt.Sprintf("%s-%d", uuid.New().String(), time.Now().Unix())
-
 	note := models.Note{
 		UserID:     req.UserID,
 		Title:      req.Title,
-		Slug:       slug,
 		Status:     "DRAFT",
 		NotebookID: req.NotebookID,
+		IsTemplate: req.IsTemplate,
+		Version:    1,
+	}
+	format := req.ContentFormat
+	if format == "" {
+		format = models.ContentFormatHTML
 	}
+	if err := setNoteContent(&note, req.Content, format); err != nil {
+		return nil, err
+	}
+	applyContentStats(&note)
 
-	// 3. Persistence
-	if err := database.DB.Create(&note).Error; err != nil {
+	// 2. Persistence (with the initial revision)
+	base := req.Slug
+	if base == "" {
+		base = req.Title
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return s.insertNote(tx, &note, base, req.Tags)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &note, nil
 }
 
+// insertNote saves a new note under a unique slug derived from slugBase, with its tags, search entry,
+// links and initial revision. Tags are given by name and created as needed.
+func (s *NoteService) insertNote(tx *gorm.DB, note *models.Note, slugBase string, tags []string) error {
+	note.Slug = uniqueNoteSlug(tx, utils.GenerateSlug(slugBase), 0)
+	// New notes come first in their notebook's manual order
+	note.Position = noteRanking.edgePosition(tx, note.UserID, note.NotebookID, true)
+
+	if err := tx.Create(note).Error; err != nil {
+		return err
+	}
+	if err := replaceNoteTags(tx, note, tags); err != nil {
+		return err
+	}
+	if err := indexNote(tx, note); err != nil {
+		return err
+	}
+	if err := syncNoteLinks(tx, note); err != nil {
+		return err
+	}
+	// Attach existing [[links]] that were waiting for this title
+	if err := refreshLinksTo(tx, note); err != nil {
+		return err
+	}
+	return s.revisions.Snapshot(tx, note, false)
+}
+
+// UpdateNoteRequest replaces every editable field of a note (PUT semantics)
 type UpdateNoteRequest struct {
 	ID         string `validate:"required"` // UUID or string ID
 	UserID     uint   `validate:"required"`
+	Version    *uint  // Expected version (If-Match); nil skips the check
 	Title      string `validate:"omitempty,min=1"`
+	Slug       string `validate:"omitempty,max=255"` // Empty keeps the current slug
 	Content    string
 	Excerpt    string
 	NotebookID *uint
-	Status     string `validate:"omitempty,oneof=DRAFT PUBLISHED ARCHIVED"`
+	Status     string `validate:"omitempty,oneof=DRAFT PUBLISHED ARCHIVED SCHEDULED"`
 	IsFeatured bool
+	IsTemplate *bool // nil keeps the current flag
+	// PublishAt in the future schedules the note; UnpublishAt archives it later (nil clears)
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+	Tags        []string // nil leaves tags unchanged, an empty slice clears them
+	// RewriteLinks updates [[Old Title]] references in other notes when the title changes
+	RewriteLinks bool
+	// ContentFormat is the format Content is written in (html when empty)
+	ContentFormat string `validate:"omitempty,oneof=html markdown"`
 }
 
 func (s *NoteService) UpdateNote(req UpdateNoteRequest) (*models.Note, error) {
@@ -69,58 +138,547 @@ func (s *NoteService) UpdateNote(req UpdateNoteRequest) (*models.Note, error) {
 		return nil, err
 	}
 
-	// 2. Fetch Existing
-	var note models.Note
-	if err := database.DB.Where("id = ? AND user_id = ?", req.ID, req.UserID).First(&note).Error; err != nil {
-		return nil, errors.New("note not found or unauthorized")
+	patch := PatchNoteRequest{
+		ID:           req.ID,
+		UserID:       req.UserID,
+		Version:      req.Version,
+		Title:        &req.Title,
+		Content:      &req.Content,
+		Excerpt:      &req.Excerpt,
+		NotebookID:   Some(req.NotebookID),
+		Status:       &req.Status,
+		IsFeatured:   &req.IsFeatured,
+		IsTemplate:   req.IsTemplate,
+		PublishAt:    Some(req.PublishAt),
+		UnpublishAt:  Some(req.UnpublishAt),
+		Tags:         req.Tags,
+		RewriteLinks: req.RewriteLinks,
+	}
+	if req.Slug != "" {
+		patch.Slug = &req.Slug
 	}
+	if req.ContentFormat != "" {
+		patch.ContentFormat = &req.ContentFormat
+	}
+	return s.applyPatch(patch)
+}
 
-	// 3. Update Fields
-	note.Title = req.Title
-	note.Content = req.Content
-	note.Excerpt = req.Excerpt
-	note.NotebookID = req.NotebookID
-	note.Status = req.Status
-	note.IsFeatured = req.IsFeatured
+// PatchNoteRequest changes only the fields that are set (PATCH semantics)
+type PatchNoteRequest struct {
+	ID           string  `validate:"required"`
+	UserID       uint    `validate:"required"`
+	Version      *uint   // Expected version (If-Match); nil skips the check
+	Title        *string `validate:"omitnil,min=1,max=255"`
+	Slug         *string `validate:"omitnil,min=1,max=255"`
+	Content      *string
+	Excerpt      *string
+	NotebookID   Optional[uint]
+	Status       *string `validate:"omitnil,oneof=DRAFT PUBLISHED ARCHIVED SCHEDULED"`
+	IsFeatured   *bool
+	IsTemplate   *bool
+	PublishAt    Optional[time.Time]
+	UnpublishAt  Optional[time.Time]
+	Tags         []string // nil leaves tags unchanged, an empty slice clears them
+	RewriteLinks bool
+	// ContentFormat is the format Content is written in (html when nil); ignored without Content
+	ContentFormat *string `validate:"omitnil,oneof=html markdown"`
+}
 
-	// 4. Save
-	if err := database.DB.Save(&note).Error; err != nil {
+// PatchNote applies a partial update. On ErrVersionConflict the current server copy is returned.
+func (s *NoteService) PatchNote(req PatchNoteRequest) (*models.Note, error) {
+	if err := s.validate.Struct(req); err != nil {
 		return nil, err
 	}
+	return s.applyPatch(req)
+}
 
-	return &note, nil
+func (s *NoteService) applyPatch(req PatchNoteRequest) (*models.Note, error) {
+	var note *models.Note
+
+	// Save and snapshot (autosaves within the coalesce window share a revision)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		note, err = findOwnedNote(tx, req.ID, req.UserID)
+		if err != nil {
+			return err
+		}
+		if req.Version != nil && *req.Version != note.Version {
+			return ErrVersionConflict
+		}
+		if err := claimVersion(tx, &models.Note{}, note.ID, note.Version); err != nil {
+			return err
+		}
+		note.Version++
+
+		// Update provided fields
+		oldTitle := note.Title
+		oldSlug := note.Slug
+		if req.Title != nil {
+			note.Title = *req.Title
+		}
+		if req.Content != nil {
+			format := models.ContentFormatHTML
+			if req.ContentFormat != nil {
+				format = *req.ContentFormat
+			}
+			if err := setNoteContent(note, *req.Content, format); err != nil {
+				return err
+			}
+		}
+		if req.Excerpt != nil {
+			setExcerpt(note, *req.Excerpt)
+		}
+		if req.NotebookID.Set {
+			// A note moving to another notebook goes first there, like a new note
+			if !sameContainer(note.NotebookID, req.NotebookID.Value) {
+				note.Position = noteRanking.edgePosition(tx, note.UserID, req.NotebookID.Value, true)
+			}
+			note.NotebookID = req.NotebookID.Value
+		}
+		if req.IsFeatured != nil {
+			note.IsFeatured = *req.IsFeatured
+		}
+		if req.IsTemplate != nil {
+			note.IsTemplate = *req.IsTemplate
+		}
+		if req.Status != nil || req.PublishAt.Set || req.UnpublishAt.Set {
+			if req.Status != nil {
+				note.Status = *req.Status
+			}
+			publishAt, unpublishAt := note.PublishAt, note.UnpublishAt
+			if req.PublishAt.Set {
+				publishAt = req.PublishAt.Value
+			}
+			if req.UnpublishAt.Set {
+				unpublishAt = req.UnpublishAt.Value
+			}
+			if err := s.applyPublishing(note, publishAt, unpublishAt); err != nil {
+				return err
+			}
+		}
+
+		if req.Slug != nil && utils.GenerateSlug(*req.Slug) != oldSlug {
+			note.Slug = uniqueNoteSlug(tx, utils.GenerateSlug(*req.Slug), note.ID)
+		}
+		if note.Slug != oldSlug {
+			if err := recordSlugChange(tx, note.ID, oldSlug, note.Slug); err != nil {
+				return err
+			}
+		}
+		applyContentStats(note)
+
+		if err := tx.Save(note).Error; err != nil {
+			return err
+		}
+		if err := replaceNoteTags(tx, note, req.Tags); err != nil {
+			return err
+		}
+		if err := indexNote(tx, note); err != nil {
+			return err
+		}
+		if err := syncNoteLinks(tx, note); err != nil {
+			return err
+		}
+		if note.Title != oldTitle || note.Slug != oldSlug {
+			rewrite := req.RewriteLinks && note.Title != oldTitle
+			if err := s.renameLinks(tx, note, oldTitle, rewrite); err != nil {
+				return err
+			}
+		}
+		return s.revisions.Snapshot(tx, note, true)
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		current, findErr := findOwnedNote(database.DB.Preload("Tags"), req.ID, req.UserID)
+		if findErr != nil {
+			return nil, findErr
+		}
+		return current, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// uniqueNoteSlug appends -1, -2, ... until no other note (including trashed ones) uses the slug
+func uniqueNoteSlug(tx *gorm.DB, base string, excludeID uint) string {
+	slug := base
+	counter := 1
+	for {
+		var count int64
+		tx.Unscoped().Model(&models.Note{}).Where("slug = ? AND id != ?", slug, excludeID).Count(&count)
+		if count == 0 {
+			return slug
+		}
+		slug = fmt.Sprintf("%s-%d", base, counter)
+		counter++
+	}
+}
+
+// recordSlugChange keeps the old slug in slug_history so old URLs keep redirecting.
+// The new slug is live again, so any history entry claiming it is dropped.
+func recordSlugChange(tx *gorm.DB, noteID uint, oldSlug, newSlug string) error {
+	if err := tx.Where("slug IN ?", []string{oldSlug, newSlug}).Delete(&models.SlugHistory{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.SlugHistory{NoteID: noteID, Slug: oldSlug}).Error
+}
+
+// applyPublishing resolves the requested status against the publish schedule.
+// A future PublishAt turns PUBLISHED/SCHEDULED into SCHEDULED; a past one publishes immediately.
+func (s *NoteService) applyPublishing(note *models.Note, publishAt, unpublishAt *time.Time) error {
+	now := s.clock.Now().UTC()
+
+	if note.Status == models.NoteStatusScheduled && publishAt == nil {
+		return ErrPublishAtRequired
+	}
+
+	note.PublishAt = nil
+	if publishAt != nil && (note.Status == models.NoteStatusScheduled || note.Status == models.NoteStatusPublished) {
+		at := publishAt.UTC()
+		if at.After(now) {
+			note.Status = models.NoteStatusScheduled
+			note.PublishAt = &at
+		} else {
+			note.Status = models.NoteStatusPublished
+			note.PublishedAt = &at
+		}
+	}
+
+	if note.Status == models.NoteStatusPublished && note.PublishedAt == nil {
+		note.PublishedAt = &now
+	}
+
+	note.UnpublishAt = nil
+	if unpublishAt != nil {
+		at := unpublishAt.UTC()
+		start := now
+		if note.PublishAt != nil {
+			start = *note.PublishAt
+		}
+		if !at.After(start) {
+			return ErrUnpublishBeforePublish
+		}
+		note.UnpublishAt = &at
+	}
+
+	return nil
+}
+
+// renameLinks keeps the link graph consistent after a title change,
+// optionally rewriting [[Old Title]] references in the linking notes first
+func (s *NoteService) renameLinks(tx *gorm.DB, note *models.Note, oldTitle string, rewrite bool) error {
+	if rewrite {
+		changed, err := rewriteLinksTo(tx, note, oldTitle)
+		if err != nil {
+			return err
+		}
+		for i := range changed {
+			if err := indexNote(tx, &changed[i]); err != nil {
+				return err
+			}
+			if err := s.revisions.Snapshot(tx, &changed[i], false); err != nil {
+				return err
+			}
+		}
+	}
+	return refreshLinksTo(tx, note)
+}
+
+// replaceNoteTags sets the note's tags by name; a nil slice leaves them untouched
+func replaceNoteTags(tx *gorm.DB, note *models.Note, names []string) error {
+	if names == nil {
+		return tx.Model(note).Association("Tags").Find(&note.Tags)
+	}
+
+	tags, err := resolveTags(tx, note.UserID, names)
+	if err != nil {
+		return err
+	}
+	return tx.Model(note).Association("Tags").Replace(tags)
 }
 
 type NoteFilter struct {
-	Search     string
-	NotebookID *uint
-	Status     string
+	Search      string
+	NotebookID  *uint
+	Status      string
+	Tag         string // Tag slug
+	Template    *bool  // Only templates (true) or only regular notes (false)
+	PinnedFirst bool   // The user's pinned notes lead the first page
 }
 
-func (s *NoteService) ListNotes(userID uint, filter NoteFilter) ([]models.Note, error) {
-	var notes []models.Note
-	db := database.DB.Preload("Notebook").Where("user_id = ?", userID)
+// noteSortKeys are the sort keys accepted by ListNotes; a search without a sort key orders by relevance
+var noteSortKeys = map[string]sortKey[models.Note]{
+	"updated_at": {expr: "notes.updated_at", kind: sortTime, value: func(n *models.Note) interface{} { return n.UpdatedAt }},
+	"created_at": {expr: "notes.created_at", kind: sortTime, value: func(n *models.Note) interface{} { return n.CreatedAt }},
+	"title":      {expr: "notes.title COLLATE NOCASE", kind: sortString, value: func(n *models.Note) interface{} { return n.Title }},
+	"views":      {expr: "notes.views", kind: sortInt, value: func(n *models.Note) interface{} { return int64(n.Views) }},
+	sortManual:   {expr: "notes.position", kind: sortFloat, value: func(n *models.Note) interface{} { return n.Position }},
+	"published_at": {expr: "COALESCE(notes.published_at, '')", kind: sortTime, value: func(n *models.Note) interface{} {
+		if n.PublishedAt == nil {
+			return nil
+		}
+		return *n.PublishedAt
+	}},
+}
 
+const sortRelevance = "relevance"
+
+func (s *NoteService) ListNotes(userID uint, filter NoteFilter, page PageRequest) ([]models.Note, PageInfo, error) {
+	defaultSort := "updated_at"
 	if filter.Search != "" {
-		searchTerm := "%" + filter.Search + "%"
-		db = db.Where("title LIKE ? OR content LIKE ?", searchTerm, searchTerm)
+		defaultSort = sortRelevance
+	}
+	if page.Sort == sortManual && page.Direction == "" {
+		page.Direction = "asc"
+	}
+	page, err := page.normalize(defaultSort, "desc", func(key string) bool {
+		_, ok := noteSortKeys[key]
+		return ok || (key == sortRelevance && filter.Search != "")
+	})
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	db := database.DB.Model(&models.Note{}).Preload("Notebook").Preload("Tags").Where("notes.user_id = ?", userID)
+
+	// Full-text search narrows the result set
+	var hits []SearchHit
+	if filter.Search != "" {
+		hits, err = s.search.Search(filter.Search, SearchOptions{UserID: userID})
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		ids := make([]uint, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.NoteID
+		}
+		db = db.Where("notes.id IN ?", ids)
 	}
 
 	if filter.NotebookID != nil {
-		db = db.Where("notebook_id = ?", *filter.NotebookID)
+		db = db.Where("notes.notebook_id = ?", *filter.NotebookID)
 	}
 
 	if filter.Status != "" {
-		db = db.Where("status = ?", filter.Status)
+		db = db.Where("notes.status = ?", filter.Status)
+	}
+
+	if filter.Template != nil {
+		db = db.Where("notes.is_template = ?", *filter.Template)
+	}
+
+	if filter.Tag != "" {
+		db = db.Where("notes.id IN (?)", database.DB.Table("note_tags").
+			Select("note_tags.note_id").
+			Joins("JOIN tags ON tags.id = note_tags.tag_id").
+			Where("tags.slug = ? AND tags.user_id = ?", filter.Tag, userID))
 	}
 
-	// Order by updated_at desc by default
-	db = db.Order("updated_at desc")
+	if page.Sort == sortRelevance {
+		if filter.PinnedFirst {
+			hits, err = pinnedHitsFirst(userID, hits)
+			if err != nil {
+				return nil, PageInfo{}, err
+			}
+		}
+		notes, info, err := pageBySearchHits(db, hits, page)
+		if err != nil {
+			return nil, info, err
+		}
+		return notes, info, fillNoteMarks(userID, notes)
+	}
+
+	var notes []models.Note
+	var info PageInfo
+	if filter.PinnedFirst {
+		notes, info, err = pagePinnedFirst(db, userID, page)
+	} else {
+		notes, info, err = paginate(db, page, noteSortKeys[page.Sort], "notes.id", func(n *models.Note) uint { return n.ID })
+	}
+	if err != nil {
+		return nil, info, err
+	}
 
-	if err := db.Find(&notes).Error; err != nil {
+	if filter.Search != "" {
+		attachSnippets(notes, hits)
+	}
+	return notes, info, fillNoteMarks(userID, notes)
+}
+
+// pinnedHitsFirst moves the hits on pinned notes ahead of the others, keeping relevance order within each
+func pinnedHitsFirst(userID uint, hits []SearchHit) ([]SearchHit, error) {
+	var ids []uint
+	if err := pinnedNoteIDs(userID).Pluck("item_id", &ids).Error; err != nil {
 		return nil, err
 	}
-	return notes, nil
+	pinned := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		pinned[id] = true
+	}
+
+	ordered := make([]SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		if pinned[hit.NoteID] {
+			ordered = append(ordered, hit)
+		}
+	}
+	for _, hit := range hits {
+		if !pinned[hit.NoteID] {
+			ordered = append(ordered, hit)
+		}
+	}
+	return ordered, nil
+}
+
+// pagePinnedFirst pages through the user's pinned notes and then the others, both in the requested
+// order. Pinned notes count against the page limit; the cursor records which of the two it is in.
+func pagePinnedFirst(db *gorm.DB, userID uint, page PageRequest) ([]models.Note, PageInfo, error) {
+	key := noteSortKeys[page.Sort]
+	idOf := func(n *models.Note) uint { return n.ID }
+	pinned := db.Session(&gorm.Session{}).Where("notes.id IN (?)", pinnedNoteIDs(userID))
+	others := db.Session(&gorm.Session{}).Where("notes.id NOT IN (?)", pinnedNoteIDs(userID))
+
+	info := PageInfo{}
+	if page.WithTotal {
+		var total int64
+		if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, info, err
+		}
+		info.Total = &total
+		page.WithTotal = false
+	}
+
+	cursor, err := decodeCursor(page.Cursor, page.Sort, page.Direction)
+	if err != nil {
+		return nil, info, err
+	}
+	if cursor != nil && cursor.Rest {
+		if cursor.ID == 0 {
+			page.Cursor = ""
+		}
+		notes, rest, err := paginate(others, page, key, "notes.id", idOf)
+		info.NextCursor = restCursor(rest.NextCursor)
+		return notes, info, err
+	}
+
+	notes, first, err := paginate(pinned, page, key, "notes.id", idOf)
+	if err != nil || first.NextCursor != nil {
+		info.NextCursor = first.NextCursor
+		return notes, info, err
+	}
+
+	// The pinned notes ran out on this page; the others fill the rest of it
+	if len(notes) == page.Limit {
+		var next []uint
+		if err := others.Session(&gorm.Session{}).Limit(1).Pluck("notes.id", &next).Error; err != nil {
+			return nil, info, err
+		}
+		if len(next) > 0 {
+			info.NextCursor = pageCursor{Sort: page.Sort, Direction: page.Direction, Rest: true}.encode()
+		}
+		return notes, info, nil
+	}
+	rest := page
+	rest.Cursor = ""
+	rest.Limit = page.Limit - len(notes)
+	more, restInfo, err := paginate(others, rest, key, "notes.id", idOf)
+	info.NextCursor = restCursor(restInfo.NextCursor)
+	return append(notes, more...), info, err
+}
+
+// pageBySearchHits pages through filtered notes in relevance order; the cursor is an offset into the hits
+func pageBySearchHits(db *gorm.DB, hits []SearchHit, page PageRequest) ([]models.Note, PageInfo, error) {
+	info := PageInfo{}
+	cursor, err := decodeCursor(page.Cursor, page.Sort, page.Direction)
+	if err != nil {
+		return nil, info, err
+	}
+
+	// Filters may drop some hits, so resolve the visible ones first
+	var visible []uint
+	if err := db.Session(&gorm.Session{}).Pluck("notes.id", &visible).Error; err != nil {
+		return nil, info, err
+	}
+	allowed := make(map[uint]bool, len(visible))
+	for _, id := range visible {
+		allowed[id] = true
+	}
+	ordered := make([]SearchHit, 0, len(visible))
+	for _, hit := range hits {
+		if allowed[hit.NoteID] {
+			ordered = append(ordered, hit)
+		}
+	}
+	if page.Direction == "asc" {
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+
+	if page.WithTotal {
+		total := int64(len(ordered))
+		info.Total = &total
+	}
+
+	offset := 0
+	if cursor != nil {
+		offset = cursor.Offset
+	}
+	if offset > len(ordered) {
+		offset = len(ordered)
+	}
+	end := offset + page.Limit
+	if end < len(ordered) {
+		info.NextCursor = pageCursor{Sort: page.Sort, Direction: page.Direction, Offset: end}.encode()
+	} else {
+		end = len(ordered)
+	}
+	ordered = ordered[offset:end]
+
+	ids := make([]uint, len(ordered))
+	for i, hit := range ordered {
+		ids[i] = hit.NoteID
+	}
+	var notes []models.Note
+	if err := db.Where("notes.id IN ?", ids).Find(&notes).Error; err != nil {
+		return nil, info, err
+	}
+	return orderBySearchHits(notes, ordered), info, nil
+}
+
+// attachSnippets copies search snippets onto notes listed in a non-relevance order
+func attachSnippets(notes []models.Note, hits []SearchHit) {
+	snippets := make(map[uint]string, len(hits))
+	for _, hit := range hits {
+		snippets[hit.NoteID] = hit.Snippet
+	}
+	for i := range notes {
+		notes[i].Snippet = snippets[notes[i].ID]
+	}
+}
+
+// orderBySearchHits sorts notes into relevance order and attaches their snippets
+func orderBySearchHits(notes []models.Note, hits []SearchHit) []models.Note {
+	byID := make(map[uint]models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+
+	ordered := make([]models.Note, 0, len(notes))
+	for _, hit := range hits {
+		if note, ok := byID[hit.NoteID]; ok {
+			note.Snippet = hit.Snippet
+			ordered = append(ordered, note)
+		}
+	}
+	return ordered
+}
+
+// GetNote returns one of the user's notes with its notebook and tags
+func (s *NoteService) GetNote(id string, userID uint) (*models.Note, error) {
+	return findOwnedNote(database.DB.Preload("Notebook").Preload("Tags"), id, userID)
 }
 
 func (s *NoteService) DeleteNote(id string, userID uint) error {
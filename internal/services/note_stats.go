@@ -0,0 +1,96 @@
+package services
+
+import (
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	ExcerptLength  = 200 // Characters in a derived excerpt
+	WordsPerMinute = 200 // Reading speed behind ReadingTime
+)
+
+// setExcerpt records an excerpt sent by the client. An empty one hands the excerpt back
+// to applyContentStats; resending the current value keeps it in whichever mode it was.
+func setExcerpt(note *models.Note, excerpt string) {
+	if excerpt == note.Excerpt {
+		return
+	}
+	note.Excerpt = excerpt
+	note.AutoExcerpt = false
+}
+
+// deriveExcerpt is the plain-text excerpt used when the author didn't write one
+func deriveExcerpt(content string) string {
+	return utils.Truncate(utils.StripHTML(content), ExcerptLength)
+}
+
+// applyContentStats derives the word count, reading time and, unless the author wrote one, the excerpt from Content
+func applyContentStats(note *models.Note) {
+	text := utils.StripHTML(note.Content)
+
+	if note.Excerpt == "" || note.AutoExcerpt {
+		note.Excerpt = utils.Truncate(text, ExcerptLength)
+		note.AutoExcerpt = note.Excerpt != ""
+	}
+
+	note.WordCount = utils.CountWords(text)
+	note.ReadingTime = 0
+	if note.WordCount > 0 {
+		note.ReadingTime = (note.WordCount + WordsPerMinute - 1) / WordsPerMinute
+	}
+}
+
+// contentStatsColumns lists the columns applyContentStats changes, for column-level updates
+func contentStatsColumns(note *models.Note) map[string]interface{} {
+	return map[string]interface{}{
+		"excerpt":      note.Excerpt,
+		"auto_excerpt": note.AutoExcerpt,
+		"word_count":   note.WordCount,
+		"reading_time": note.ReadingTime,
+	}
+}
+
+type StatsService struct{}
+
+func NewStatsService() *StatsService {
+	return &StatsService{}
+}
+
+// Backfill recomputes excerpts, word counts and reading times for every note, including trashed ones.
+// Hand-written excerpts are kept. It returns the number of notes that changed.
+func (s *StatsService) Backfill() (int, error) {
+	updated := 0
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var notes []models.Note
+		return tx.Unscoped().Select("id, content, excerpt, auto_excerpt, word_count, reading_time").
+			FindInBatches(&notes, 200, func(batch *gorm.DB, _ int) error {
+				for i := range notes {
+					before := notes[i]
+					applyContentStats(&notes[i])
+					if notes[i].Excerpt == before.Excerpt && notes[i].AutoExcerpt == before.AutoExcerpt &&
+						notes[i].WordCount == before.WordCount && notes[i].ReadingTime == before.ReadingTime {
+						continue
+					}
+
+					columns := contentStatsColumns(&notes[i])
+					if notes[i].Excerpt != before.Excerpt {
+						columns["version"] = gorm.Expr("version + 1")
+					}
+					if err := tx.Unscoped().Model(&models.Note{}).Where("id = ?", notes[i].ID).
+						UpdateColumns(columns).Error; err != nil {
+						return err
+					}
+					updated++
+				}
+				return nil
+			}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,579 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotebookNotFound       = errors.New("notebook not found")
+	ErrNotebookExists         = errors.New("a notebook with this name already exists here")
+	ErrParentNotebookNotFound = errors.New("parent notebook not found")
+	ErrNotebookCycle          = errors.New("a notebook cannot be moved into itself or a notebook below it")
+	ErrInvalidChildrenMode    = errors.New("children must be reparent or cascade")
+	ErrInvalidNotesMode       = errors.New("notes must be detach, move or trash")
+	ErrInvalidTargetNotebook  = errors.New("target_id must be another live notebook of yours")
+)
+
+// What DeleteNotebook does with the notebooks below the deleted one
+const (
+	NotebookChildrenReparent = "reparent" // Move them up to the deleted notebook's parent
+	NotebookChildrenCascade  = "cascade"  // Trash them along with it
+)
+
+// What DeleteNotebook does with the notes of the deleted notebooks
+const (
+	NotebookNotesDetach = "detach" // Keep them without a notebook
+	NotebookNotesMove   = "move"   // Move them to the target notebook
+	NotebookNotesTrash  = "trash"  // Trash them along with the notebook
+)
+
+type NotebookService struct {
+	validate *validator.Validate
+}
+
+func NewNotebookService() *NotebookService {
+	return &NotebookService{validate: validator.New()}
+}
+
+// notebookSortKeys are the sort keys accepted by ListNotebooks
+var notebookSortKeys = map[string]sortKey[models.Notebook]{
+	"name":       {expr: "notebooks.name COLLATE NOCASE", kind: sortString, value: func(n *models.Notebook) interface{} { return n.Name }},
+	"updated_at": {expr: "notebooks.updated_at", kind: sortTime, value: func(n *models.Notebook) interface{} { return n.UpdatedAt }},
+	"created_at": {expr: "notebooks.created_at", kind: sortTime, value: func(n *models.Notebook) interface{} { return n.CreatedAt }},
+	sortManual:   {expr: "notebooks.position", kind: sortFloat, value: func(n *models.Notebook) interface{} { return n.Position }},
+}
+
+// ListNotebooks returns a page of the user's notebooks with their live note counts, in manual order by default
+func (s *NotebookService) ListNotebooks(userID uint, page PageRequest) ([]models.Notebook, PageInfo, error) {
+	page, err := page.normalize(sortManual, "asc", func(key string) bool {
+		_, ok := notebookSortKeys[key]
+		return ok
+	})
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	db := database.DB.Model(&models.Notebook{}).Where("notebooks.user_id = ?", userID)
+	notebooks, info, err := paginate(db, page, notebookSortKeys[page.Sort], "notebooks.id", func(n *models.Notebook) uint { return n.ID })
+	if err != nil {
+		return nil, info, err
+	}
+
+	if err := fillNotesCounts(notebooks); err != nil {
+		return nil, info, err
+	}
+	return notebooks, info, fillNotebookMarks(userID, notebooks)
+}
+
+// fillNotesCounts sets NotesCount to the number of live notes in each notebook
+func fillNotesCounts(notebooks []models.Notebook) error {
+	return countNotes(notebooks, database.DB.Model(&models.Note{}))
+}
+
+// FillPublishedNotesCounts sets NotesCount to the number of published notes in each notebook
+// and TotalNotesCount to that of the notebook and the notebooks below it, for the public pages
+func FillPublishedNotesCounts(notebooks []models.Notebook) error {
+	if err := countNotes(notebooks, database.DB.Model(&models.Note{}).Where("status = ?", models.NoteStatusPublished)); err != nil {
+		return err
+	}
+	FillTotalNotesCounts(notebooks)
+	return nil
+}
+
+// countNotes sets NotesCount to the number of notes of the query in each notebook
+func countNotes(notebooks []models.Notebook, notes *gorm.DB) error {
+	if len(notebooks) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(notebooks))
+	for i, notebook := range notebooks {
+		ids[i] = notebook.ID
+	}
+
+	var counts []struct {
+		NotebookID uint
+		Count      int64
+	}
+	if err := notes.
+		Select("notebook_id, count(*) as count").
+		Where("notebook_id IN ?", ids).
+		Group("notebook_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byID[c.NotebookID] = c.Count
+	}
+	for i := range notebooks {
+		notebooks[i].NotesCount = byID[notebooks[i].ID]
+	}
+	return nil
+}
+
+type CreateNotebookRequest struct {
+	UserID      uint   `validate:"required"`
+	ParentID    *uint  // Nil creates a top-level notebook
+	Name        string `validate:"max=255"`
+	Slug        string `validate:"max=255"` // Defaults to the slugified name
+	Description string
+}
+
+func (s *NotebookService) CreateNotebook(req CreateNotebookRequest) (*models.Notebook, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	notebook := models.Notebook{
+		UserID:      req.UserID,
+		ParentID:    req.ParentID,
+		Name:        req.Name,
+		Description: req.Description,
+		Version:     1,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.ParentID != nil {
+			if err := checkNotebookParent(tx, 0, *req.ParentID, req.UserID); err != nil {
+				return err
+			}
+		}
+		if err := checkNotebookName(tx, 0, req.UserID, req.ParentID, req.Name); err != nil {
+			return err
+		}
+		notebook.Slug = uniqueNotebookSlug(tx, notebookSlug(req.Slug, req.Name), 0)
+		// New notebooks come last among their siblings
+		notebook.Position = notebookRanking.edgePosition(tx, req.UserID, req.ParentID, false)
+		return tx.Create(&notebook).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &notebook, nil
+}
+
+// PatchNotebookRequest changes only the fields that are set.
+// PUT sends every field; an empty slug is regenerated from the name.
+type PatchNotebookRequest struct {
+	ID          string  `validate:"required"`
+	UserID      uint    `validate:"required"`
+	Version     *uint   // Expected version (If-Match); nil skips the check
+	Name        *string `validate:"omitnil,max=255"`
+	Slug        *string `validate:"omitnil,max=255"`
+	Description *string
+}
+
+// PatchNotebook applies a partial update. On ErrVersionConflict the current server copy is returned.
+func (s *NotebookService) PatchNotebook(req PatchNotebookRequest) (*models.Notebook, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var notebook models.Notebook
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", req.ID, req.UserID).First(&notebook).Error; err != nil {
+			return ErrNotebookNotFound
+		}
+		if req.Version != nil && *req.Version != notebook.Version {
+			return ErrVersionConflict
+		}
+		if err := claimVersion(tx, &models.Notebook{}, notebook.ID, notebook.Version); err != nil {
+			return err
+		}
+		notebook.Version++
+
+		if req.Name != nil && *req.Name != notebook.Name {
+			if err := checkNotebookName(tx, notebook.ID, req.UserID, notebook.ParentID, *req.Name); err != nil {
+				return err
+			}
+			notebook.Name = *req.Name
+		}
+		if req.Description != nil {
+			notebook.Description = *req.Description
+		}
+		if req.Slug != nil {
+			notebook.Slug = uniqueNotebookSlug(tx, notebookSlug(*req.Slug, notebook.Name), notebook.ID)
+		}
+
+		return tx.Save(&notebook).Error
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		var current models.Notebook
+		if findErr := database.DB.Where("id = ? AND user_id = ?", req.ID, req.UserID).First(&current).Error; findErr != nil {
+			return nil, ErrNotebookNotFound
+		}
+		return &current, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notebook, nil
+}
+
+// MoveNotebookRequest puts a notebook under another one, or at the top level when ParentID is nil
+type MoveNotebookRequest struct {
+	ID       string `validate:"required"`
+	UserID   uint   `validate:"required"`
+	Version  *uint  // Expected version (If-Match); nil skips the check
+	ParentID *uint
+}
+
+// MoveNotebook changes the parent of a notebook, refusing moves that would create a cycle.
+// On ErrVersionConflict the current server copy is returned.
+func (s *NotebookService) MoveNotebook(req MoveNotebookRequest) (*models.Notebook, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var notebook models.Notebook
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", req.ID, req.UserID).First(&notebook).Error; err != nil {
+			return ErrNotebookNotFound
+		}
+		if req.Version != nil && *req.Version != notebook.Version {
+			return ErrVersionConflict
+		}
+		if req.ParentID != nil {
+			if err := checkNotebookParent(tx, notebook.ID, *req.ParentID, req.UserID); err != nil {
+				return err
+			}
+		}
+		if !sameContainer(notebook.ParentID, req.ParentID) {
+			if err := checkNotebookName(tx, notebook.ID, req.UserID, req.ParentID, notebook.Name); err != nil {
+				return err
+			}
+		}
+		if err := claimVersion(tx, &models.Notebook{}, notebook.ID, notebook.Version); err != nil {
+			return err
+		}
+		notebook.Version++
+
+		if !sameContainer(notebook.ParentID, req.ParentID) {
+			notebook.ParentID = req.ParentID
+			notebook.Position = notebookRanking.edgePosition(tx, req.UserID, req.ParentID, false)
+		}
+		return tx.Save(&notebook).Error
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		var current models.Notebook
+		if findErr := database.DB.Where("id = ? AND user_id = ?", req.ID, req.UserID).First(&current).Error; findErr != nil {
+			return nil, ErrNotebookNotFound
+		}
+		return &current, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notebook, nil
+}
+
+// checkNotebookParent makes sure parentID is a live notebook of the user that is neither
+// notebook id itself nor below it. Pass 0 as id for a notebook that does not exist yet.
+func checkNotebookParent(tx *gorm.DB, id, parentID, userID uint) error {
+	seen := map[uint]bool{}
+	for current := &parentID; current != nil; {
+		if *current == id || seen[*current] {
+			return ErrNotebookCycle
+		}
+		seen[*current] = true
+
+		var ancestor models.Notebook
+		if err := tx.Select("id, parent_id").Where("id = ? AND user_id = ?", *current, userID).First(&ancestor).Error; err != nil {
+			if *current == parentID {
+				return ErrParentNotebookNotFound
+			}
+			return nil // The chain ends at a trashed notebook
+		}
+		current = ancestor.ParentID
+	}
+	return nil
+}
+
+// checkNotebookName makes sure no other live notebook of the user under parentID (nil for the top
+// level) has the name, ignoring case. Pass 0 as id for a notebook that does not exist yet.
+func checkNotebookName(tx *gorm.DB, id, userID uint, parentID *uint, name string) error {
+	siblings := tx.Model(&models.Notebook{}).Where("user_id = ? AND id != ? AND name = ? COLLATE NOCASE", userID, id, name)
+	if parentID == nil {
+		siblings = siblings.Where("parent_id IS NULL")
+	} else {
+		siblings = siblings.Where("parent_id = ?", *parentID)
+	}
+	var count int64
+	if err := siblings.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrNotebookExists
+	}
+	return nil
+}
+
+// DeleteNotebookRequest trashes a notebook. Children says what happens to the notebooks below it
+// and Notes what happens to the notes in the notebooks being deleted.
+type DeleteNotebookRequest struct {
+	ID       string `validate:"required"`
+	UserID   uint   `validate:"required"`
+	Children string // NotebookChildrenReparent (default) or NotebookChildrenCascade
+	Notes    string // NotebookNotesDetach (default), NotebookNotesMove or NotebookNotesTrash
+	TargetID *uint  // Notebook receiving the notes with NotebookNotesMove
+}
+
+// DeleteNotebookResult counts what a deletion changed
+type DeleteNotebookResult struct {
+	Notebooks int64 `json:"deleted_notebooks"`
+	Notes     int64 `json:"affected_notes"`
+}
+
+// DeleteNotebook moves a notebook to the trash. Its children either move up to its parent
+// or, with NotebookChildrenCascade, go to the trash with it so restoring it brings them back.
+// Its notes are detached, moved to another notebook or trashed with it, in the same transaction.
+func (s *NotebookService) DeleteNotebook(req DeleteNotebookRequest) (*DeleteNotebookResult, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+	if req.Children == "" {
+		req.Children = NotebookChildrenReparent
+	}
+	if req.Children != NotebookChildrenReparent && req.Children != NotebookChildrenCascade {
+		return nil, ErrInvalidChildrenMode
+	}
+	if req.Notes == "" {
+		req.Notes = NotebookNotesDetach
+	}
+	switch req.Notes {
+	case NotebookNotesDetach, NotebookNotesTrash:
+	case NotebookNotesMove:
+		if req.TargetID == nil {
+			return nil, ErrInvalidTargetNotebook
+		}
+	default:
+		return nil, ErrInvalidNotesMode
+	}
+
+	result := &DeleteNotebookResult{}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var notebook models.Notebook
+		if err := tx.Where("id = ? AND user_id = ?", req.ID, req.UserID).First(&notebook).Error; err != nil {
+			return ErrNotebookNotFound
+		}
+
+		ids := []uint{notebook.ID}
+		if req.Children == NotebookChildrenCascade {
+			descendants, err := notebookDescendants(tx, notebook.ID)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, descendants...)
+		} else if err := tx.Model(&models.Notebook{}).Where("parent_id = ?", notebook.ID).
+			Updates(map[string]interface{}{"parent_id": notebook.ParentID, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+
+		if req.Notes == NotebookNotesMove {
+			if slices.Contains(ids, *req.TargetID) {
+				return ErrInvalidTargetNotebook
+			}
+			var count int64
+			if err := tx.Model(&models.Notebook{}).Where("id = ? AND user_id = ?", *req.TargetID, req.UserID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrInvalidTargetNotebook
+			}
+		}
+
+		// Notes moving to another notebook, or out of any, go first there like new notes
+		if req.Notes != NotebookNotesTrash {
+			target := req.TargetID
+			if req.Notes != NotebookNotesMove {
+				target = nil
+			}
+			var noteIDs []uint
+			if err := tx.Model(&models.Note{}).Where("notebook_id IN ?", ids).Pluck("id", &noteIDs).Error; err != nil {
+				return err
+			}
+			if err := noteRanking.placeFirst(tx, req.UserID, noteIDs, target); err != nil {
+				return err
+			}
+		}
+
+		// One timestamp for everything trashed here, so restoring the notebook brings it all back
+		now := time.Now()
+		notes := tx.Model(&models.Note{}).Where("notebook_id IN ?", ids)
+		var moved *gorm.DB
+		switch req.Notes {
+		case NotebookNotesTrash:
+			moved = notes.Update("deleted_at", now)
+		case NotebookNotesMove:
+			moved = notes.Updates(map[string]interface{}{"notebook_id": *req.TargetID, "version": gorm.Expr("version + 1")})
+		default:
+			moved = notes.Updates(map[string]interface{}{"notebook_id": nil, "version": gorm.Expr("version + 1")})
+		}
+		if moved.Error != nil {
+			return moved.Error
+		}
+		result.Notes = moved.RowsAffected
+
+		deleted := tx.Model(&models.Notebook{}).Where("id IN ?", ids).Update("deleted_at", now)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		result.Notebooks = deleted.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// notebookDescendants returns the IDs of the live notebooks below a notebook
+func notebookDescendants(tx *gorm.DB, id uint) ([]uint, error) {
+	var all []uint
+	seen := map[uint]bool{id: true}
+	for level := []uint{id}; len(level) > 0; {
+		var children []uint
+		if err := tx.Model(&models.Notebook{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, child := range children {
+			if !seen[child] {
+				seen[child] = true
+				level = append(level, child)
+				all = append(all, child)
+			}
+		}
+	}
+	return all, nil
+}
+
+// NotebookTree returns the user's notebooks nested under their parents in manual order
+// (then by name), with note counts
+func (s *NotebookService) NotebookTree(userID uint) ([]models.Notebook, error) {
+	var notebooks []models.Notebook
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("position asc").Order("name COLLATE NOCASE asc").Order("id asc").
+		Find(&notebooks).Error; err != nil {
+		return nil, err
+	}
+	if err := fillNotesCounts(notebooks); err != nil {
+		return nil, err
+	}
+	if err := fillNotebookMarks(userID, notebooks); err != nil {
+		return nil, err
+	}
+	return NestNotebooks(notebooks), nil
+}
+
+// NotebookBreadcrumbs returns the path from the top-level notebook down to the given one
+func (s *NotebookService) NotebookBreadcrumbs(notebook *models.Notebook) []models.Notebook {
+	crumbs := []models.Notebook{*notebook}
+	seen := map[uint]bool{notebook.ID: true}
+	for parentID := notebook.ParentID; parentID != nil && !seen[*parentID]; {
+		var parent models.Notebook
+		if err := database.DB.Select("id, parent_id, name, slug").First(&parent, *parentID).Error; err != nil {
+			break
+		}
+		seen[parent.ID] = true
+		crumbs = append([]models.Notebook{parent}, crumbs...)
+		parentID = parent.ParentID
+	}
+	return crumbs
+}
+
+// FillTotalNotesCounts sets TotalNotesCount to the NotesCount of each notebook plus those of the notebooks below it
+func FillTotalNotesCounts(notebooks []models.Notebook) {
+	children := notebookChildren(notebooks)
+	done := make([]bool, len(notebooks))
+	var total func(i int) int64
+	total = func(i int) int64 {
+		if done[i] {
+			return notebooks[i].TotalNotesCount
+		}
+		done[i] = true // Set before recursing so corrupt cycles terminate
+		sum := notebooks[i].NotesCount
+		for _, child := range children[notebooks[i].ID] {
+			sum += total(child)
+		}
+		notebooks[i].TotalNotesCount = sum
+		return sum
+	}
+	for i := range notebooks {
+		total(i)
+	}
+}
+
+// NestNotebooks arranges a flat list of notebooks into a tree, keeping the list order among siblings.
+// Notebooks whose parent is not in the list (such as a trashed one) become roots.
+func NestNotebooks(notebooks []models.Notebook) []models.Notebook {
+	FillTotalNotesCounts(notebooks)
+	children := notebookChildren(notebooks)
+
+	var nest func(i int) models.Notebook
+	nest = func(i int) models.Notebook {
+		notebook := notebooks[i]
+		notebook.Children = []models.Notebook{}
+		for _, child := range children[notebook.ID] {
+			notebook.Children = append(notebook.Children, nest(child))
+		}
+		return notebook
+	}
+
+	roots := []models.Notebook{}
+	for _, i := range children[0] {
+		roots = append(roots, nest(i))
+	}
+	return roots
+}
+
+// notebookChildren indexes the list by parent ID; roots are listed under 0
+func notebookChildren(notebooks []models.Notebook) map[uint][]int {
+	present := make(map[uint]bool, len(notebooks))
+	for i := range notebooks {
+		present[notebooks[i].ID] = true
+	}
+	children := map[uint][]int{}
+	for i := range notebooks {
+		parent := uint(0)
+		if p := notebooks[i].ParentID; p != nil && present[*p] && *p != notebooks[i].ID {
+			parent = *p
+		}
+		children[parent] = append(children[parent], i)
+	}
+	return children
+}
+
+// notebookSlug normalizes a requested slug, falling back to the name
+func notebookSlug(slug, name string) string {
+	if slug == "" {
+		return utils.GenerateSlug(name)
+	}
+	return utils.GenerateSlug(slug)
+}
+
+// uniqueNotebookSlug appends -1, -2, ... until no other notebook (including trashed ones) uses the slug
+func uniqueNotebookSlug(tx *gorm.DB, base string, excludeID uint) string {
+	slug := base
+	counter := 1
+	for {
+		var count int64
+		tx.Unscoped().Model(&models.Notebook{}).Where("slug = ? AND id != ?", slug, excludeID).Count(&count)
+		if count == 0 {
+			return slug
+		}
+		slug = fmt.Sprintf("%s-%d", base, counter)
+		counter++
+	}
+}
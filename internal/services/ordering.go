@@ -0,0 +1,260 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidReorder      = errors.New("send ids, or id with before or after")
+	ErrReorderItemNotFound = errors.New("item to reorder not found")
+	ErrReorderContainer    = errors.New("items must be siblings to be reordered")
+)
+
+// sortManual orders notes and notebooks by their position
+const sortManual = "manual"
+
+// ReorderRequest either lists sibling IDs in their new order,
+// or moves one item (ID) directly before or after a sibling.
+type ReorderRequest struct {
+	UserID uint
+	IDs    []uint
+	ID     uint
+	Before *uint
+	After  *uint
+}
+
+// rankedItem is a row of a manually ordered table
+type rankedItem struct {
+	ID        uint
+	Position  float64
+	Container *uint // Parent notebook of a notebook, notebook of a note
+}
+
+// ranking describes a manually ordered table: its model and the column grouping siblings
+type ranking struct {
+	model     interface{}
+	container string
+}
+
+var (
+	notebookRanking = ranking{model: &models.Notebook{}, container: "parent_id"}
+	noteRanking     = ranking{model: &models.Note{}, container: "notebook_id"}
+)
+
+type OrderingService struct{}
+
+func NewOrderingService() *OrderingService {
+	return &OrderingService{}
+}
+
+// ReorderNotebooks changes the manual order of notebooks sharing a parent
+func (s *OrderingService) ReorderNotebooks(req ReorderRequest) error {
+	return reorder(notebookRanking, req)
+}
+
+// ReorderNotes changes the manual order of notes sharing a notebook
+func (s *OrderingService) ReorderNotes(req ReorderRequest) error {
+	return reorder(noteRanking, req)
+}
+
+func reorder(r ranking, req ReorderRequest) error {
+	if len(req.IDs) > 0 {
+		if req.ID != 0 || req.Before != nil || req.After != nil {
+			return ErrInvalidReorder
+		}
+		return database.DB.Transaction(func(tx *gorm.DB) error {
+			return r.reorderList(tx, req.UserID, req.IDs)
+		})
+	}
+	if req.ID == 0 || (req.Before == nil) == (req.After == nil) {
+		return ErrInvalidReorder
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return r.moveNextTo(tx, req)
+	})
+}
+
+// find loads the ranked rows of the user with the given IDs
+func (r ranking) find(tx *gorm.DB, userID uint, ids []uint) ([]rankedItem, error) {
+	var items []rankedItem
+	err := tx.Model(r.model).
+		Select(fmt.Sprintf("id, position, %s AS container", r.container)).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Scan(&items).Error
+	return items, err
+}
+
+// siblings lists the rows of a container in manual order
+func (r ranking) siblings(tx *gorm.DB, userID uint, container *uint) ([]rankedItem, error) {
+	db := tx.Model(r.model).Select(fmt.Sprintf("id, position, %s AS container", r.container)).Where("user_id = ?", userID)
+	if container == nil {
+		db = db.Where(r.container + " IS NULL")
+	} else {
+		db = db.Where(r.container+" = ?", *container)
+	}
+	var items []rankedItem
+	err := db.Order("position asc").Order("id asc").Scan(&items).Error
+	return items, err
+}
+
+// setPosition writes a position without touching updated_at or the version: ordering is not an edit
+func (r ranking) setPosition(tx *gorm.DB, id uint, position float64) error {
+	return tx.Model(r.model).Where("id = ?", id).UpdateColumn("position", position).Error
+}
+
+// reorderList numbers the listed siblings 1, 2, 3, ... in the given order. Siblings left out of
+// the list follow them, in their current order.
+func (r ranking) reorderList(tx *gorm.DB, userID uint, ids []uint) error {
+	unique := slices.Compact(slices.Sorted(slices.Values(ids)))
+	if len(unique) != len(ids) {
+		return ErrInvalidReorder
+	}
+	items, err := r.find(tx, userID, ids)
+	if err != nil {
+		return err
+	}
+	if len(items) != len(ids) {
+		return ErrReorderItemNotFound
+	}
+	for _, item := range items[1:] {
+		if !sameContainer(item.Container, items[0].Container) {
+			return ErrReorderContainer
+		}
+	}
+
+	siblings, err := r.siblings(tx, userID, items[0].Container)
+	if err != nil {
+		return err
+	}
+	ordered := slices.Clone(ids)
+	for _, sibling := range siblings {
+		if !slices.Contains(ids, sibling.ID) {
+			ordered = append(ordered, sibling.ID)
+		}
+	}
+
+	for i, id := range ordered {
+		if err := r.setPosition(tx, id, float64(i+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveNextTo puts an item right before or after a sibling. The new position is the midpoint
+// of its neighbours, so only the moved row changes; the siblings are renumbered only when
+// the neighbours share a position or the gap between them has run out of precision.
+func (r ranking) moveNextTo(tx *gorm.DB, req ReorderRequest) error {
+	anchorID := req.Before
+	if anchorID == nil {
+		anchorID = req.After
+	}
+	if *anchorID == req.ID {
+		return ErrInvalidReorder
+	}
+
+	items, err := r.find(tx, req.UserID, []uint{req.ID, *anchorID})
+	if err != nil {
+		return err
+	}
+	if len(items) != 2 {
+		return ErrReorderItemNotFound
+	}
+	if !sameContainer(items[0].Container, items[1].Container) {
+		return ErrReorderContainer
+	}
+
+	siblings, err := r.siblings(tx, req.UserID, items[0].Container)
+	if err != nil {
+		return err
+	}
+	siblings = slices.DeleteFunc(siblings, func(item rankedItem) bool { return item.ID == req.ID })
+	index := slices.IndexFunc(siblings, func(item rankedItem) bool { return item.ID == *anchorID })
+	if req.After != nil {
+		index++
+	}
+
+	// The moved item goes between siblings[index-1] and siblings[index]
+	var lower, upper float64
+	switch {
+	case len(siblings) == 0:
+		lower, upper = 0, 2
+	case index == 0:
+		lower, upper = siblings[0].Position-2, siblings[0].Position
+	case index == len(siblings):
+		lower, upper = siblings[index-1].Position, siblings[index-1].Position+2
+	default:
+		lower, upper = siblings[index-1].Position, siblings[index].Position
+	}
+	if mid := lower + (upper-lower)/2; mid > lower && mid < upper {
+		return r.setPosition(tx, req.ID, mid)
+	}
+
+	ordered := slices.Insert(siblings, index, rankedItem{ID: req.ID})
+	for i, item := range ordered {
+		if err := r.setPosition(tx, item.ID, float64(i+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// edgePosition is a position that sorts a new row of the container first (or last) among its siblings
+func (r ranking) edgePosition(tx *gorm.DB, userID uint, container *uint, first bool) float64 {
+	db := tx.Model(r.model).Where("user_id = ?", userID)
+	if container == nil {
+		db = db.Where(r.container + " IS NULL")
+	} else {
+		db = db.Where(r.container+" = ?", *container)
+	}
+
+	var edge struct {
+		Position *float64
+	}
+	if first {
+		db.Select("MIN(position) AS position").Scan(&edge)
+	} else {
+		db.Select("MAX(position) AS position").Scan(&edge)
+	}
+	switch {
+	case edge.Position == nil:
+		return 1
+	case first:
+		return *edge.Position - 1
+	default:
+		return *edge.Position + 1
+	}
+}
+
+// placeFirst gives the rows with the given IDs positions that sort them first in container, keeping
+// their current order. It is used when rows move there, before their container is changed.
+func (r ranking) placeFirst(tx *gorm.DB, userID uint, ids []uint, container *uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var items []rankedItem
+	if err := tx.Model(r.model).Select("id, position").Where("id IN ?", ids).
+		Order("position asc").Order("id asc").Scan(&items).Error; err != nil {
+		return err
+	}
+	edge := r.edgePosition(tx, userID, container, true)
+	for i, item := range items {
+		if err := r.setPosition(tx, item.ID, edge-float64(len(items)-1-i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameContainer(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Page size defaults for list endpoints
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort key")
+)
+
+// PageRequest selects one page of a keyset-paginated listing.
+// Cursor is the NextCursor of the previous page; it is only valid with the same sort and direction.
+type PageRequest struct {
+	Limit     int    // Defaults to DefaultPageLimit, capped at MaxPageLimit
+	Cursor    string // Empty for the first page
+	Sort      string // Sort key; each listing has its own default
+	Direction string // "asc" or "desc"; each listing has its own default
+	WithTotal bool   // Also count every matching row
+}
+
+// PageInfo describes where a page sits in the full listing
+type PageInfo struct {
+	NextCursor *string `json:"next_cursor"`     // nil on the last page
+	Total      *int64  `json:"total,omitempty"` // Only set when requested
+}
+
+type sortKind int
+
+const (
+	sortString sortKind = iota
+	sortInt
+	sortTime
+	sortFloat
+)
+
+// sortKey maps an API sort key to an SQL expression and reads the same value back from a row
+type sortKey[T any] struct {
+	expr  string
+	kind  sortKind
+	value func(row *T) interface{} // nil for a NULL column
+}
+
+// pageCursor is the position after the last row of a page
+type pageCursor struct {
+	Sort      string `json:"s"`
+	Direction string `json:"d"`
+	Value     string `json:"v,omitempty"`
+	Null      bool   `json:"n,omitempty"`
+	ID        uint   `json:"id,omitempty"`
+	Offset    int    `json:"o,omitempty"` // Used by relevance-ordered search results
+	Rest      bool   `json:"r,omitempty"` // Past the pinned notes of a pinned-first listing
+}
+
+func (c pageCursor) encode() *string {
+	data, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return &encoded
+}
+
+// restCursor marks a cursor of the non-pinned notes as such
+func restCursor(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	// Cursors made by paginate always decode
+	data, _ := base64.RawURLEncoding.DecodeString(*raw)
+	var cursor pageCursor
+	json.Unmarshal(data, &cursor)
+	cursor.Rest = true
+	return cursor.encode()
+}
+
+// decodeCursor parses a cursor and checks it belongs to the requested ordering
+func decodeCursor(raw, sort, direction string) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.Direction != direction {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// normalize fills in defaults and validates the sort key against the allowed ones
+func (p PageRequest) normalize(defaultSort, defaultDirection string, allowed func(string) bool) (PageRequest, error) {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	if p.Sort == "" {
+		p.Sort = defaultSort
+	}
+	if !allowed(p.Sort) {
+		return p, fmt.Errorf("%w: %s", ErrInvalidSort, p.Sort)
+	}
+	if p.Direction == "" {
+		p.Direction = defaultDirection
+	}
+	if p.Direction != "asc" && p.Direction != "desc" {
+		return p, fmt.Errorf("%w: direction must be asc or desc", ErrInvalidSort)
+	}
+	return p, nil
+}
+
+func encodeSortValue(kind sortKind, value interface{}) string {
+	switch kind {
+	case sortTime:
+		return value.(time.Time).Format(time.RFC3339Nano)
+	case sortInt:
+		return strconv.FormatInt(value.(int64), 10)
+	case sortFloat:
+		return strconv.FormatFloat(value.(float64), 'g', -1, 64)
+	default:
+		return value.(string)
+	}
+}
+
+func decodeSortValue(kind sortKind, raw string) (interface{}, error) {
+	switch kind {
+	case sortTime:
+		// Times keep their original offset so they bind back to the exact stored text
+		return time.Parse(time.RFC3339Nano, raw)
+	case sortInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case sortFloat:
+		return strconv.ParseFloat(raw, 64)
+	default:
+		return raw, nil
+	}
+}
+
+// paginate runs a keyset-paginated query ordered by key then id.
+// db must already be scoped to the model and filters; idColumn is the qualified primary key.
+func paginate[T any](db *gorm.DB, page PageRequest, key sortKey[T], idColumn string, idOf func(row *T) uint) ([]T, PageInfo, error) {
+	info := PageInfo{}
+
+	if page.WithTotal {
+		var total int64
+		if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, info, err
+		}
+		info.Total = &total
+	}
+
+	cursor, err := decodeCursor(page.Cursor, page.Sort, page.Direction)
+	if err != nil {
+		return nil, info, err
+	}
+
+	cmp := "<"
+	if page.Direction == "asc" {
+		cmp = ">"
+	}
+
+	if cursor != nil {
+		// NULL sort values compare as '' so they stay in one block at the end (desc) or start (asc)
+		var value interface{} = ""
+		if !cursor.Null {
+			if value, err = decodeSortValue(key.kind, cursor.Value); err != nil {
+				return nil, info, ErrInvalidCursor
+			}
+		}
+		db = db.Where(fmt.Sprintf("((%s %s ?) OR (%s = ? AND %s %s ?))", key.expr, cmp, key.expr, idColumn, cmp),
+			value, value, cursor.ID)
+	}
+
+	var rows []T
+	if err := db.Order(key.expr + " " + page.Direction).
+		Order(idColumn + " " + page.Direction).
+		Limit(page.Limit + 1).
+		Find(&rows).Error; err != nil {
+		return nil, info, err
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := &rows[len(rows)-1]
+		next := pageCursor{Sort: page.Sort, Direction: page.Direction, ID: idOf(last)}
+		if value := key.value(last); value == nil {
+			next.Null = true
+		} else {
+			next.Value = encodeSortValue(key.kind, value)
+		}
+		info.NextCursor = next.encode()
+	}
+
+	return rows, info, nil
+}
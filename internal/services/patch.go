@@ -0,0 +1,51 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict means the record changed since the client loaded it
+var ErrVersionConflict = errors.New("the record was modified by someone else")
+
+// Optional is a PATCH field that may be explicitly cleared.
+// Set reports whether the client sent the field at all; Value is nil for an explicit null.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// Some returns a set Optional holding value (nil clears the field)
+func Some[T any](value *T) Optional[T] {
+	return Optional[T]{Set: true, Value: value}
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	o.Value = nil
+	if string(data) == "null" {
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
+
+// claimVersion bumps a row's version only if it still matches the given one,
+// so the second of two concurrent writers gets ErrVersionConflict
+func claimVersion(tx *gorm.DB, model interface{}, id uint, version uint) error {
+	result := tx.Model(model).Where("id = ? AND version = ?", id, version).UpdateColumn("version", version+1)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
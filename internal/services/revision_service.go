@@ -0,0 +1,293 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/markdown"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoteNotFound     = errors.New("note not found or unauthorized")
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// Revision retention defaults, overridable via REVISION_KEEP_LAST and REVISION_COALESCE_WINDOW
+const (
+	defaultRevisionKeepLast       = 50
+	defaultRevisionCoalesceWindow = 2 * time.Minute
+)
+
+// RevisionPolicy controls how many revisions are kept per note.
+// KeepLast <= 0 keeps every revision; CoalesceWindow <= 0 disables coalescing.
+type RevisionPolicy struct {
+	KeepLast       int
+	CoalesceWindow time.Duration
+}
+
+// RevisionPolicyFromEnv reads the retention policy from the environment.
+// It is resolved on each save so values loaded from .env after startup are honoured.
+func RevisionPolicyFromEnv() RevisionPolicy {
+	policy := RevisionPolicy{
+		KeepLast:       defaultRevisionKeepLast,
+		CoalesceWindow: defaultRevisionCoalesceWindow,
+	}
+
+	if v := os.Getenv("REVISION_KEEP_LAST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			policy.KeepLast = n
+		}
+	}
+
+	if v := os.Getenv("REVISION_COALESCE_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			policy.CoalesceWindow = d
+		}
+	}
+
+	return policy
+}
+
+type RevisionService struct {
+	clock Clock
+}
+
+func NewRevisionService(clock Clock) *RevisionService {
+	return &RevisionService{clock: clock}
+}
+
+// Snapshot records the current state of a note inside the given transaction.
+// When coalesce is true, a save landing within the coalesce window of the latest
+// revision by the same user overwrites that revision instead of adding a new one.
+// Restore revisions are never overwritten.
+func (s *RevisionService) Snapshot(tx *gorm.DB, note *models.Note, coalesce bool) error {
+	return s.snapshot(tx, note, coalesce, models.RevisionKindEdit)
+}
+
+func (s *RevisionService) snapshot(tx *gorm.DB, note *models.Note, coalesce bool, kind string) error {
+	policy := RevisionPolicyFromEnv()
+	now := s.clock.Now().UTC()
+
+	var latest models.NoteRevision
+	err := tx.Where("note_id = ?", note.ID).Order("id desc").First(&latest).Error
+	hasLatest := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Nothing changed since the last snapshot
+	if hasLatest && latest.Title == note.Title && latest.Content == note.Content && latest.Excerpt == note.Excerpt {
+		return nil
+	}
+
+	if coalesce && hasLatest && policy.CoalesceWindow > 0 && latest.Kind != models.RevisionKindRestore &&
+		latest.UserID == note.UserID && now.Sub(latest.CreatedAt) < policy.CoalesceWindow {
+		latest.Title = note.Title
+		latest.Content = note.Content
+		latest.Excerpt = note.Excerpt
+		return tx.Save(&latest).Error
+	}
+
+	revision := models.NoteRevision{
+		NoteID:    note.ID,
+		UserID:    note.UserID,
+		Title:     note.Title,
+		Content:   note.Content,
+		Excerpt:   note.Excerpt,
+		Kind:      kind,
+		CreatedAt: now,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return err
+	}
+
+	return s.prune(tx, note.ID, policy.KeepLast)
+}
+
+// prune deletes all but the newest keepLast revisions of a note
+func (s *RevisionService) prune(tx *gorm.DB, noteID uint, keepLast int) error {
+	if keepLast <= 0 {
+		return nil
+	}
+
+	var cutoff models.NoteRevision
+	err := tx.Where("note_id = ?", noteID).Order("id desc").Offset(keepLast - 1).First(&cutoff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Where("note_id = ? AND id < ?", noteID, cutoff.ID).Delete(&models.NoteRevision{}).Error
+}
+
+// findOwnedNote loads a note belonging to the user or returns ErrNoteNotFound
+func findOwnedNote(db *gorm.DB, noteID string, userID uint) (*models.Note, error) {
+	var note models.Note
+	if err := db.Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+		return nil, ErrNoteNotFound
+	}
+	return &note, nil
+}
+
+// ListRevisions returns revision metadata for a note, newest first.
+// Content is omitted to keep the listing light; fetch a single revision for the body.
+func (s *RevisionService) ListRevisions(noteID string, userID uint) ([]models.NoteRevision, error) {
+	note, err := findOwnedNote(database.DB, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []models.NoteRevision
+	if err := database.DB.Select("id, note_id, user_id, title, excerpt, kind, created_at, updated_at").
+		Where("note_id = ?", note.ID).
+		Order("id desc").
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *RevisionService) GetRevision(noteID, revisionID string, userID uint) (*models.NoteRevision, error) {
+	note, err := findOwnedNote(database.DB, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return findRevision(database.DB, note.ID, revisionID)
+}
+
+func findRevision(db *gorm.DB, noteID uint, revisionID string) (*models.NoteRevision, error) {
+	var revision models.NoteRevision
+	if err := db.Where("id = ? AND note_id = ?", revisionID, noteID).First(&revision).Error; err != nil {
+		return nil, ErrRevisionNotFound
+	}
+	return &revision, nil
+}
+
+// RevisionDiff describes the changes between two revisions of a note
+type RevisionDiff struct {
+	From    uint           `json:"from"`
+	To      uint           `json:"to"`
+	Mode    string         `json:"mode"`
+	Title   []utils.DiffOp `json:"title"`
+	Excerpt []utils.DiffOp `json:"excerpt"`
+	Content []utils.DiffOp `json:"content"`
+}
+
+// DiffRevisions compares two revisions; mode is "line" (default) or "word"
+func (s *RevisionService) DiffRevisions(noteID, fromID, toID string, userID uint, mode string) (*RevisionDiff, error) {
+	note, err := findOwnedNote(database.DB, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := findRevision(database.DB, note.ID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := findRevision(database.DB, note.ID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := utils.DiffLines
+	if mode == "word" {
+		diff = utils.DiffWords
+	} else {
+		mode = "line"
+	}
+
+	return &RevisionDiff{
+		From:    from.ID,
+		To:      to.ID,
+		Mode:    mode,
+		Title:   utils.DiffWords(from.Title, to.Title),
+		Excerpt: utils.DiffWords(from.Excerpt, to.Excerpt),
+		Content: diff(from.Content, to.Content),
+	}, nil
+}
+
+// RestoreRevisionRequest copies a revision back onto a note
+type RestoreRevisionRequest struct {
+	NoteID     string
+	RevisionID string
+	UserID     uint
+	Version    *uint // Expected version of the note (If-Match); nil skips the check
+}
+
+// RestoreRevision copies a revision back onto the note and records the restore as a new revision.
+// On ErrVersionConflict the current server copy is returned.
+func (s *RevisionService) RestoreRevision(req RestoreRevisionRequest) (*models.Note, error) {
+	var restored *models.Note
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		note, err := findOwnedNote(tx, req.NoteID, req.UserID)
+		if err != nil {
+			return err
+		}
+		if req.Version != nil && *req.Version != note.Version {
+			return ErrVersionConflict
+		}
+
+		revision, err := findRevision(tx, note.ID, req.RevisionID)
+		if err != nil {
+			return err
+		}
+
+		note.Title = revision.Title
+		note.Content = SanitizeHTML(revision.Content) // Revisions may predate the current policy
+		if note.ContentFormat == models.ContentFormatMarkdown {
+			// Revisions only keep HTML, so the source is rebuilt from it
+			if note.Markdown, err = markdown.FromHTML(note.Content); err != nil {
+				return err
+			}
+		}
+		note.Excerpt = revision.Excerpt
+		note.AutoExcerpt = revision.Excerpt == "" || revision.Excerpt == deriveExcerpt(revision.Content)
+		applyContentStats(note)
+		if err := claimVersion(tx, &models.Note{}, note.ID, note.Version); err != nil {
+			return err
+		}
+		note.Version++
+
+		if err := tx.Save(note).Error; err != nil {
+			return err
+		}
+		if err := indexNote(tx, note); err != nil {
+			return err
+		}
+		if err := syncNoteLinks(tx, note); err != nil {
+			return err
+		}
+		if err := refreshLinksTo(tx, note); err != nil {
+			return err
+		}
+
+		// A restore is never coalesced, nor overwritten by later saves, so it stays in the history
+		if err := s.snapshot(tx, note, false, models.RevisionKindRestore); err != nil {
+			return err
+		}
+
+		restored = note
+		return nil
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		current, findErr := findOwnedNote(database.DB, req.NoteID, req.UserID)
+		if findErr != nil {
+			return nil, findErr
+		}
+		return current, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
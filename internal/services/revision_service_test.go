@@ -0,0 +1,68 @@
+package services_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func TestRevisionService_Coalesce(t *testing.T) {
+	t.Setenv("REVISION_COALESCE_WINDOW", "2m")
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	revisions := services.NewRevisionService(clock)
+
+	note := models.Note{UserID: 1, Title: "Autosaved", Slug: "autosaved", Content: "<p>v1</p>"}
+	database.DB.Create(&note)
+	snapshot := func(content string) {
+		note.Content = content
+		assert.NoError(t, revisions.Snapshot(database.DB, &note, true))
+	}
+	contents := func() []string {
+		var stored []models.NoteRevision
+		database.DB.Where("note_id = ?", note.ID).Order("id asc").Find(&stored)
+		var out []string
+		for _, r := range stored {
+			out = append(out, r.Content)
+		}
+		return out
+	}
+
+	// 1. Saves within the window collapse; the window is measured on the service clock
+	snapshot("<p>v1</p>")
+	clock.Advance(time.Minute)
+	snapshot("<p>v2</p>")
+	assert.Equal(t, []string{"<p>v2</p>"}, contents())
+
+	clock.Advance(3 * time.Minute)
+	snapshot("<p>v3</p>")
+	assert.Equal(t, []string{"<p>v2</p>", "<p>v3</p>"}, contents())
+
+	// 2. A restore is never overwritten by the next save
+	var first models.NoteRevision
+	database.DB.Where("note_id = ?", note.ID).Order("id asc").First(&first)
+	restored, err := revisions.RestoreRevision(services.RestoreRevisionRequest{
+		NoteID: fmt.Sprint(note.ID), RevisionID: fmt.Sprint(first.ID), UserID: note.UserID,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "<p>v2</p>", restored.Content)
+
+	clock.Advance(time.Second)
+	note = *restored
+	snapshot("<p>v4</p>")
+	assert.Equal(t, []string{"<p>v2</p>", "<p>v3</p>", "<p>v2</p>", "<p>v4</p>"}, contents())
+
+	var kinds []string
+	database.DB.Model(&models.NoteRevision{}).Where("note_id = ?", note.ID).Order("id asc").Pluck("kind", &kinds)
+	assert.Equal(t, []string{"edit", "edit", "restore", "edit"}, kinds)
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+// SanitizePolicy tunes the HTML allowlist applied to note content.
+// The base allowlist covers what the Tiptap editor produces; these options widen it.
+type SanitizePolicy struct {
+	AllowDataImages bool     // Keep <img src="data:image/..."> (pasted images)
+	EmbedHosts      []string // Hosts allowed in <iframe src>; empty strips iframes
+	ExtraElements   []string // Additional elements allowed without attributes
+}
+
+// SanitizePolicyFromEnv reads HTML_ALLOW_DATA_IMAGES, HTML_EMBED_HOSTS and HTML_EXTRA_ELEMENTS
+// (comma separated). It is resolved on each save so values loaded from .env after startup are honoured.
+func SanitizePolicyFromEnv() SanitizePolicy {
+	return SanitizePolicy{
+		AllowDataImages: os.Getenv("HTML_ALLOW_DATA_IMAGES") == "true",
+		EmbedHosts:      splitList(os.Getenv("HTML_EMBED_HOSTS")),
+		ExtraElements:   splitList(os.Getenv("HTML_EXTRA_ELEMENTS")),
+	}
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, strings.ToLower(item))
+		}
+	}
+	return items
+}
+
+func (p SanitizePolicy) key() string {
+	return strings.Join(p.EmbedHosts, ",") + "|" + strings.Join(p.ExtraElements, ",") + "|" + strconv.FormatBool(p.AllowDataImages)
+}
+
+var (
+	// Tailwind-style class lists used by the custom layout nodes
+	classPattern     = regexp.MustCompile(`^[\w\s\-:/\[\]\.#%]+$`)
+	languagePattern  = regexp.MustCompile(`^language-[\w+#-]+$`)
+	dataTypePattern  = regexp.MustCompile(`^[a-zA-Z]+$`)
+	dimensionPattern = regexp.MustCompile(`^\d{1,4}(px|%)?$`)
+)
+
+// buildPolicy turns a SanitizePolicy into a bluemonday allowlist
+func buildPolicy(cfg SanitizePolicy) *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowStandardURLs()
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.AllowImages() // Also re-applies the standard URL rules, so link options are set below
+
+	// Text structure (StarterKit)
+	p.AllowElements("p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+		"strong", "b", "em", "i", "u", "s", "strike", "del", "sub", "sup", "mark",
+		"blockquote", "span", "figure", "figcaption")
+	p.AllowElements("ul", "li")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowElements("ol")
+
+	// Code blocks keep their language class for highlighting
+	p.AllowElements("pre", "code")
+	p.AllowAttrs("class").Matching(languagePattern).OnElements("code")
+
+	// Links: external links open in a new tab with rel="noopener"
+	p.AllowAttrs("href", "title").OnElements("a")
+	p.AllowAttrs("target").Matching(regexp.MustCompile(`^_blank$`)).OnElements("a")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	p.RequireNoFollowOnLinks(false)
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+	p.RequireNoReferrerOnFullyQualifiedLinks(true)
+
+	// Images
+	p.AllowAttrs("title").OnElements("img")
+	if cfg.AllowDataImages {
+		p.AllowDataURIImages()
+	}
+
+	// Tables
+	p.AllowElements("table", "thead", "tbody", "tfoot", "tr", "th", "td", "colgroup", "col", "caption")
+	p.AllowAttrs("colspan", "rowspan").Matching(bluemonday.Integer).OnElements("th", "td")
+
+	// Task lists: <ul data-type="taskList"><li data-type="taskItem" data-checked="true"><label><input type="checkbox">
+	p.AllowAttrs("data-type").Matching(dataTypePattern).Globally()
+	p.AllowAttrs("data-checked").Matching(regexp.MustCompile(`^(true|false)$`)).OnElements("li")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AllowNoAttrs().OnElements("label") // bluemonday drops a bare <label> otherwise
+
+	// Layout and card nodes
+	p.AllowElements("div")
+	p.AllowAttrs("data-layout-type").Matching(regexp.MustCompile(`^[a-z-]+$`)).OnElements("div")
+	p.AllowAttrs("class").Matching(classPattern).OnElements("div", "span", "p", "ul", "ol", "li", "img", "figure")
+	p.AllowStyles("text-align").MatchingEnum("left", "right", "center", "justify").Globally()
+	p.AllowStyles("color", "background-color").MatchingHandler(safeColor).OnElements("div", "span", "mark")
+
+	// Embeds are off unless hosts are configured
+	if len(cfg.EmbedHosts) > 0 {
+		hosts := make([]string, len(cfg.EmbedHosts))
+		for i, host := range cfg.EmbedHosts {
+			hosts[i] = regexp.QuoteMeta(host)
+		}
+		p.AllowAttrs("src").
+			Matching(regexp.MustCompile(`^https://(` + strings.Join(hosts, "|") + `)/`)).
+			OnElements("iframe")
+		p.AllowAttrs("width", "height").Matching(dimensionPattern).OnElements("iframe")
+		p.AllowAttrs("allowfullscreen").OnElements("iframe")
+		p.RequireSandboxOnIFrame(bluemonday.SandboxAllowScripts, bluemonday.SandboxAllowSameOrigin, bluemonday.SandboxAllowPopups)
+	}
+
+	for _, element := range cfg.ExtraElements {
+		p.AllowNoAttrs().OnElements(element)
+	}
+
+	return p
+}
+
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|rgba?\([\d\s.,%]+\)|[a-zA-Z]+)$`)
+
+func safeColor(value string) bool {
+	return colorPattern.MatchString(strings.TrimSpace(value))
+}
+
+// sanitizerCache rebuilds the bluemonday policy only when the configuration changes
+var sanitizerCache struct {
+	sync.Mutex
+	key    string
+	policy *bluemonday.Policy
+}
+
+// SanitizeHTML cleans note content with the policy from the environment
+func SanitizeHTML(content string) string {
+	return SanitizeHTMLWith(SanitizePolicyFromEnv(), content)
+}
+
+// SanitizeHTMLWith cleans note content with an explicit policy
+func SanitizeHTMLWith(cfg SanitizePolicy, content string) string {
+	if content == "" {
+		return ""
+	}
+
+	sanitizerCache.Lock()
+	if sanitizerCache.policy == nil || sanitizerCache.key != cfg.key() {
+		sanitizerCache.key = cfg.key()
+		sanitizerCache.policy = buildPolicy(cfg)
+	}
+	policy := sanitizerCache.policy
+	sanitizerCache.Unlock()
+
+	return policy.Sanitize(content)
+}
+
+// SanitizeReport summarizes a clean-up run over stored content
+type SanitizeReport struct {
+	Scanned   int
+	Notes     []uint // IDs of notes whose content changed
+	Revisions int    // Revisions whose content changed
+}
+
+type SanitizeService struct{}
+
+func NewSanitizeService() *SanitizeService {
+	return &SanitizeService{}
+}
+
+// CleanExisting re-sanitizes every stored note (including trashed ones) and, optionally, their revisions.
+// With dryRun nothing is written and the report lists what would change.
+func (s *SanitizeService) CleanExisting(dryRun, includeRevisions bool) (*SanitizeReport, error) {
+	policy := SanitizePolicyFromEnv()
+	report := &SanitizeReport{Notes: []uint{}}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var notes []models.Note
+		err := tx.Unscoped().Select("id, title, content, version, excerpt, auto_excerpt").FindInBatches(&notes, 200, func(batch *gorm.DB, _ int) error {
+			for i := range notes {
+				report.Scanned++
+				clean := SanitizeHTMLWith(policy, notes[i].Content)
+				if clean == notes[i].Content {
+					continue
+				}
+				report.Notes = append(report.Notes, notes[i].ID)
+				if dryRun {
+					continue
+				}
+
+				notes[i].Content = clean
+				applyContentStats(&notes[i])
+				columns := contentStatsColumns(&notes[i])
+				columns["content"], columns["version"] = clean, gorm.Expr("version + 1")
+				if err := tx.Unscoped().Model(&models.Note{}).Where("id = ?", notes[i].ID).
+					UpdateColumns(columns).Error; err != nil {
+					return err
+				}
+				if err := indexNote(tx, &notes[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil || !includeRevisions {
+			return err
+		}
+
+		var revisions []models.NoteRevision
+		return tx.Select("id, content").FindInBatches(&revisions, 200, func(batch *gorm.DB, _ int) error {
+			for _, revision := range revisions {
+				clean := SanitizeHTMLWith(policy, revision.Content)
+				if clean == revision.Content {
+					continue
+				}
+				report.Revisions++
+				if dryRun {
+					continue
+				}
+				if err := tx.Model(&models.NoteRevision{}).Where("id = ?", revision.ID).
+					UpdateColumn("content", clean).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
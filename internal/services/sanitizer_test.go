@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML_StripsXSSPayloads(t *testing.T) {
+	payloads := []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`<img src="javascript:alert(1)">`,
+		`<a href="javascript:alert(1)">x</a>`,
+		`<a href="JaVaScRiPt:alert(1)">x</a>`,
+		`<a href="&#106;avascript:alert(1)">x</a>`,
+		`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+		`<svg onload=alert(1)><circle r="1"/></svg>`,
+		`<iframe src="https://evil.example/"></iframe>`,
+		`<div style="background:url(javascript:alert(1))">x</div>`,
+		`<p onclick="alert(1)">x</p>`,
+		`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+		`<object data="x.swf"></object><embed src="x.swf">`,
+		`<form action="/logout"><button>x</button></form>`,
+		`<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`,
+		`<base href="https://evil.example/">`,
+		`<input type="text" onfocus="alert(1)" autofocus>`,
+		`<details open ontoggle=alert(1)>`,
+		`<<script>script>alert(1)<</script>/script>`,
+		`<code class="x" onmouseover="alert(1)">x</code>`,
+	}
+
+	for _, payload := range payloads {
+		clean := strings.ToLower(SanitizeHTMLWith(SanitizePolicy{}, payload))
+		for _, bad := range []string{"<script", "javascript:", "onerror", "onload", "onclick", "onfocus", "ontoggle",
+			"onmouseover", "<iframe", "<svg", "<object", "<embed", "<form", "<meta", "<base", "<style", "data:text"} {
+			assert.NotContains(t, clean, bad, payload)
+		}
+	}
+}
+
+func TestSanitizeHTML_KeepsTiptapOutput(t *testing.T) {
+	content := `<h2>Title</h2><p><strong>bold</strong> <em>it</em> <s>gone</s> <code>x</code></p>` +
+		`<ul><li><p>one</p></li></ul><ol start="3"><li><p>three</p></li></ol>` +
+		`<pre><code class="language-go">fmt.Println("hi")</code></pre><blockquote><p>quote</p></blockquote><hr>` +
+		`<img src="/public/uploads/a.png" alt="A">` +
+		`<ul data-type="taskList"><li data-checked="true" data-type="taskItem"><label><input type="checkbox" checked="checked"><span></span></label><div><p>done</p></div></li></ul>` +
+		`<div data-type="layoutWrapper" data-layout-type="two-col" class="grid gap-4 md:grid-cols-2"><div data-type="layoutColumn" class="min-w-0 flex">col</div></div>`
+
+	clean := SanitizeHTMLWith(SanitizePolicy{}, content)
+	for _, keep := range []string{
+		`<h2>Title</h2>`, `<strong>bold</strong>`, `<s>gone</s>`, `<ol start="3">`,
+		`<code class="language-go">`, `<blockquote>`, `<img src="/public/uploads/a.png" alt="A">`,
+		`data-type="taskList"`, `data-checked="true"`, `<label><input type="checkbox" checked="checked"><span></span></label>`,
+		`data-layout-type="two-col"`, `class="grid gap-4 md:grid-cols-2"`,
+	} {
+		assert.Contains(t, clean, keep)
+	}
+}
+
+func TestSanitizeHTML_LinksAndPolicyOptions(t *testing.T) {
+	clean := SanitizeHTMLWith(SanitizePolicy{}, `<a href="https://example.com" rel="opener">x</a> <a href="/articles/a">y</a>`)
+	assert.Contains(t, clean, `href="https://example.com"`)
+	assert.Contains(t, clean, `target="_blank"`)
+	assert.Contains(t, clean, "noopener")
+	assert.NotContains(t, clean, `rel="opener"`)
+	assert.Contains(t, clean, `<a href="/articles/a">y</a>`)
+
+	embed := `<iframe src="https://www.youtube.com/embed/abc"></iframe>`
+	dataImage := `<img src="data:image/png;base64,iVBORw0KGgo=">`
+	assert.NotContains(t, SanitizeHTMLWith(SanitizePolicy{}, embed), "<iframe")
+	assert.NotContains(t, SanitizeHTMLWith(SanitizePolicy{}, dataImage), "data:image")
+
+	policy := SanitizePolicy{AllowDataImages: true, EmbedHosts: []string{"www.youtube.com"}, ExtraElements: []string{"kbd"}}
+	assert.Contains(t, SanitizeHTMLWith(policy, embed), `src="https://www.youtube.com/embed/abc"`)
+	assert.Contains(t, SanitizeHTMLWith(policy, embed), "sandbox=")
+	assert.NotContains(t, SanitizeHTMLWith(policy, `<iframe src="https://www.youtube.com.evil.example/x"></iframe>`), "src=")
+	assert.Contains(t, SanitizeHTMLWith(policy, dataImage), "data:image/png")
+	assert.Contains(t, SanitizeHTMLWith(policy, `<kbd>Ctrl</kbd>`), "<kbd>Ctrl</kbd>")
+}
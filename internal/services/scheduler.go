@@ -0,0 +1,138 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+// Clock abstracts time so scheduling can be tested deterministically
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the wall clock used outside of tests
+var SystemClock Clock = systemClock{}
+
+// Scheduler poll interval default, overridable via SCHEDULER_INTERVAL
+const defaultSchedulerInterval = time.Minute
+
+// SchedulerIntervalFromEnv returns the maximum time between scheduler runs
+func SchedulerIntervalFromEnv() time.Duration {
+	return durationFromEnv("SCHEDULER_INTERVAL", defaultSchedulerInterval)
+}
+
+// PublishScheduler promotes SCHEDULED notes once PublishAt passes
+// and archives PUBLISHED notes once UnpublishAt passes.
+// All state lives in the notes table, so a restart simply picks up where it left off.
+type PublishScheduler struct {
+	clock    Clock
+	interval time.Duration
+}
+
+func NewPublishScheduler(clock Clock, interval time.Duration) *PublishScheduler {
+	return &PublishScheduler{clock: clock, interval: interval}
+}
+
+// RunOnce applies every transition that is due and reports how many notes changed
+func (s *PublishScheduler) RunOnce() (published int64, unpublished int64, err error) {
+	now := s.clock.Now().UTC()
+
+	result := database.DB.Model(&models.Note{}).
+		Where("status = ? AND publish_at <= ?", models.NoteStatusScheduled, now).
+		Updates(map[string]interface{}{
+			"status":       models.NoteStatusPublished,
+			"published_at": gorm.Expr("publish_at"),
+			"publish_at":   nil,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	published = result.RowsAffected
+
+	result = database.DB.Model(&models.Note{}).
+		Where("status = ? AND unpublish_at <= ?", models.NoteStatusPublished, now).
+		Updates(map[string]interface{}{
+			"status":       models.NoteStatusArchived,
+			"unpublish_at": nil,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return published, 0, result.Error
+	}
+	unpublished = result.RowsAffected
+
+	return published, unpublished, nil
+}
+
+// NextDue returns the earliest pending publish or unpublish time, if any
+func (s *PublishScheduler) NextDue() (*time.Time, error) {
+	var next *time.Time
+
+	for _, q := range []struct{ column, status string }{
+		{"publish_at", models.NoteStatusScheduled},
+		{"unpublish_at", models.NoteStatusPublished},
+	} {
+		var note models.Note
+		err := database.DB.Select(q.column).
+			Where("status = ? AND "+q.column+" IS NOT NULL", q.status).
+			Order(q.column + " asc").
+			Limit(1).
+			Find(&note).Error
+		if err != nil {
+			return nil, err
+		}
+
+		due := note.PublishAt
+		if q.column == "unpublish_at" {
+			due = note.UnpublishAt
+		}
+		if due != nil && (next == nil || due.Before(*next)) {
+			next = due
+		}
+	}
+
+	return next, nil
+}
+
+// Start catches up on anything that became due while the server was down,
+// then keeps running in the background until stop is closed.
+// It wakes at the next due time or after the poll interval, whichever is sooner.
+func (s *PublishScheduler) Start(stop <-chan struct{}) {
+	go func() {
+		for {
+			published, unpublished, err := s.RunOnce()
+			if err != nil {
+				log.Printf("Publish scheduler failed: %v", err)
+			} else if published > 0 || unpublished > 0 {
+				log.Printf("Publish scheduler: %d published, %d unpublished", published, unpublished)
+			}
+
+			wait := s.interval
+			if next, err := s.NextDue(); err == nil && next != nil {
+				if until := next.Sub(s.clock.Now()); until < wait {
+					wait = until
+				}
+			}
+			if wait < time.Second {
+				wait = time.Second
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
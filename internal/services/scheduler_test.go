@@ -0,0 +1,109 @@
+package services_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+// fakeClock is a manually advanced clock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestPublishScheduler_RunOnce(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	publishAt := clock.Now().Add(time.Hour)
+	unpublishAt := clock.Now().Add(3 * time.Hour)
+
+	scheduled := models.Note{UserID: 1, Title: "Launch Post", Slug: "launch-post", Status: models.NoteStatusScheduled, PublishAt: &publishAt, UnpublishAt: &unpublishAt}
+	draft := models.Note{UserID: 1, Title: "Draft", Slug: "draft", Status: models.NoteStatusDraft}
+	database.DB.Create(&scheduled)
+	database.DB.Create(&draft)
+
+	scheduler := services.NewPublishScheduler(clock, time.Minute)
+
+	// 1. Nothing is due yet
+	published, unpublished, err := scheduler.RunOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), published)
+	assert.Equal(t, int64(0), unpublished)
+
+	next, err := scheduler.NextDue()
+	assert.NoError(t, err)
+	assert.True(t, next.Equal(publishAt))
+
+	// 2. Publish time arrives
+	clock.Advance(90 * time.Minute)
+	published, _, err = scheduler.RunOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), published)
+
+	var note models.Note
+	database.DB.First(&note, scheduled.ID)
+	assert.Equal(t, models.NoteStatusPublished, note.Status)
+	assert.True(t, note.PublishedAt.Equal(publishAt))
+	assert.Nil(t, note.PublishAt)
+
+	next, _ = scheduler.NextDue()
+	assert.True(t, next.Equal(unpublishAt))
+
+	// 3. Unpublish time arrives
+	clock.Advance(2 * time.Hour)
+	_, unpublished, err = scheduler.RunOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), unpublished)
+
+	database.DB.First(&note, scheduled.ID)
+	assert.Equal(t, models.NoteStatusArchived, note.Status)
+
+	var untouched models.Note
+	database.DB.First(&untouched, draft.ID)
+	assert.Equal(t, models.NoteStatusDraft, untouched.Status)
+
+	next, _ = scheduler.NextDue()
+	assert.Nil(t, next)
+}
+
+func TestPublishScheduler_CatchesUpOnStart(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	// A note that became due while the server was down
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	missed := clock.Now().Add(-time.Hour)
+	note := models.Note{UserID: 1, Title: "Missed", Slug: "missed", Status: models.NoteStatusScheduled, PublishAt: &missed}
+	database.DB.Create(&note)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	services.NewPublishScheduler(clock, time.Hour).Start(stop)
+
+	assert.Eventually(t, func() bool {
+		var current models.Note
+		database.DB.First(&current, note.ID)
+		return current.Status == models.NoteStatusPublished
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"html"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ftsAvailable reports whether the SQLite driver was built with FTS5 (-tags sqlite_fts5).
+// Without it search falls back to LIKE matching.
+var ftsAvailable atomic.Bool
+
+// Snippet markers are control characters so highlighting survives HTML escaping
+const (
+	snippetOpen  = "\x02"
+	snippetClose = "\x03"
+)
+
+// EnsureSearchIndex creates the notes_fts virtual table if the driver supports FTS5.
+// The index holds the title and HTML-stripped content of every note, keyed by note ID.
+func EnsureSearchIndex(db *gorm.DB) {
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(title, body, tokenize = 'unicode61 remove_diacritics 2')").Error
+	if err != nil {
+		log.Printf("Full-text search unavailable, falling back to LIKE search: %v", err)
+		ftsAvailable.Store(false)
+		return
+	}
+	ftsAvailable.Store(true)
+}
+
+// SearchAvailable reports whether the FTS5 index is in use
+func SearchAvailable() bool {
+	return ftsAvailable.Load()
+}
+
+// indexNote writes the note into the search index inside the given transaction
+func indexNote(tx *gorm.DB, note *models.Note) error {
+	if !SearchAvailable() {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM notes_fts WHERE rowid = ?", note.ID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO notes_fts (rowid, title, body) VALUES (?, ?, ?)",
+		note.ID, note.Title, utils.StripHTML(note.Content)).Error
+}
+
+// unindexNotes removes purged notes from the search index
+func unindexNotes(tx *gorm.DB, ids []uint) error {
+	if !SearchAvailable() || len(ids) == 0 {
+		return nil
+	}
+	return tx.Exec("DELETE FROM notes_fts WHERE rowid IN ?", ids).Error
+}
+
+type SearchService struct{}
+
+func NewSearchService() *SearchService {
+	return &SearchService{}
+}
+
+// Reindex rebuilds the search index from every note, including trashed ones
+// so restores need no reindexing. It returns the number of notes indexed.
+func (s *SearchService) Reindex() (int, error) {
+	if !SearchAvailable() {
+		return 0, nil
+	}
+
+	indexed := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM notes_fts").Error; err != nil {
+			return err
+		}
+
+		var notes []models.Note
+		return tx.Unscoped().Select("id, title, content").FindInBatches(&notes, 200, func(batch *gorm.DB, _ int) error {
+			for i := range notes {
+				if err := indexNote(tx, &notes[i]); err != nil {
+					return err
+				}
+			}
+			indexed += len(notes)
+			return nil
+		}).Error
+	})
+
+	return indexed, err
+}
+
+type SearchOptions struct {
+	UserID        uint // 0 searches across all users
+	PublishedOnly bool
+	Limit         int // 0 means no limit
+}
+
+// SearchHit is a ranked match; Snippet is HTML-escaped with matches wrapped in <mark>
+type SearchHit struct {
+	NoteID  uint
+	Rank    float64
+	Snippet string
+}
+
+// Search returns matching live notes, best match first.
+// Quoted text is matched as a phrase and a trailing * matches a prefix ("tiptap edit*").
+func (s *SearchService) Search(query string, opts SearchOptions) ([]SearchHit, error) {
+	if SearchAvailable() {
+		return s.searchFTS(query, opts)
+	}
+	return s.searchLike(query, opts)
+}
+
+func (s *SearchService) searchFTS(query string, opts SearchOptions) ([]SearchHit, error) {
+	match := BuildFTSQuery(query)
+	if match == "" {
+		return []SearchHit{}, nil
+	}
+
+	// Title matches weigh ten times more than body matches
+	db := database.DB.Table("notes_fts").
+		Select("notes_fts.rowid AS note_id, bm25(notes_fts, 10.0, 1.0) AS rank, snippet(notes_fts, -1, char(2), char(3), '…', 16) AS snippet").
+		Joins("JOIN notes ON notes.id = notes_fts.rowid").
+		Where("notes_fts MATCH ?", match).
+		Where("notes.deleted_at IS NULL")
+	db = applySearchOptions(db, opts).Order("rank")
+
+	var hits []SearchHit
+	if err := db.Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range hits {
+		hits[i].Snippet = formatSnippet(hits[i].Snippet)
+	}
+	return hits, nil
+}
+
+// likeEscaper escapes LIKE wildcards so the fallback search matches the query literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *SearchService) searchLike(query string, opts SearchOptions) ([]SearchHit, error) {
+	searchTerm := "%" + likeEscaper.Replace(strings.TrimSpace(query)) + "%"
+
+	var notes []models.Note
+	db := database.DB.Select("id, title, content").
+		Where(`notes.title LIKE ? ESCAPE '\' OR notes.content LIKE ? ESCAPE '\'`, searchTerm, searchTerm)
+	db = applySearchOptions(db, opts).Order("updated_at desc")
+	if err := db.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(notes))
+	for _, note := range notes {
+		hits = append(hits, SearchHit{NoteID: note.ID, Snippet: html.EscapeString(truncateWords(utils.StripHTML(note.Content), 32))})
+	}
+	return hits, nil
+}
+
+func applySearchOptions(db *gorm.DB, opts SearchOptions) *gorm.DB {
+	if opts.UserID != 0 {
+		db = db.Where("notes.user_id = ?", opts.UserID)
+	}
+	if opts.PublishedOnly {
+		db = db.Where("notes.status = ?", "PUBLISHED")
+	}
+	if opts.Limit > 0 {
+		db = db.Limit(opts.Limit)
+	}
+	return db
+}
+
+// formatSnippet escapes snippet text and turns the FTS markers into <mark> tags
+func formatSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, snippetOpen, "<mark>")
+	return strings.ReplaceAll(snippet, snippetClose, "</mark>")
+}
+
+func truncateWords(text string, max int) string {
+	words := strings.Fields(text)
+	if len(words) <= max {
+		return text
+	}
+	return strings.Join(words[:max], " ") + "…"
+}
+
+// BuildFTSQuery turns user input into a safe FTS5 MATCH expression.
+// Every term is quoted so FTS syntax characters are treated literally; "quoted phrases"
+// stay phrases and a trailing * keeps prefix matching. Terms are ANDed together.
+func BuildFTSQuery(input string) string {
+	var parts []string
+	rest := strings.TrimSpace(input)
+
+	for rest != "" {
+		var token string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				token, rest = rest[1:], ""
+			} else {
+				token, rest = rest[1:end+1], rest[end+2:]
+			}
+			prefix := strings.HasPrefix(rest, "*")
+			rest = strings.TrimLeft(rest, "*")
+			if part := quoteFTS(token, prefix); part != "" {
+				parts = append(parts, part)
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\n\"")
+			if end < 0 {
+				token, rest = rest, ""
+			} else {
+				token, rest = rest[:end], rest[end:]
+			}
+			prefix := strings.HasSuffix(token, "*")
+			if part := quoteFTS(strings.TrimRight(token, "*"), prefix); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		rest = strings.TrimSpace(rest)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func quoteFTS(token string, prefix bool) string {
+	token = strings.TrimSpace(strings.ReplaceAll(token, `"`, ""))
+	if token == "" {
+		return ""
+	}
+	quoted := `"` + token + `"`
+	if prefix {
+		quoted += "*"
+	}
+	return quoted
+}
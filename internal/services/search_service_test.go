@@ -0,0 +1,24 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFTSQuery(t *testing.T) {
+	cases := map[string]string{
+		"":                        "",
+		"garden":                  `"garden"`,
+		"garden tools":            `"garden" "tools"`,
+		"gard*":                   `"gard"*`,
+		`"tomato sauce" recipe`:   `"tomato sauce" "recipe"`,
+		`"tomato sa"*`:            `"tomato sa"*`,
+		`unterminated "phrase`:    `"unterminated" "phrase"`,
+		`NEAR(a b) OR title:x -y`: `"NEAR(a" "b)" "OR" "title:x" "-y"`,
+	}
+
+	for input, expected := range cases {
+		assert.Equal(t, expected, BuildFTSQuery(input), input)
+	}
+}
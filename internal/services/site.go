@@ -0,0 +1,95 @@
+package services
+
+import (
+	"os"
+	"strings"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+)
+
+// Settings describing the public site
+const (
+	SettingSiteName        = "site_name"
+	SettingSiteDescription = "site_description"
+	SettingSiteURL         = "site_url"
+	SettingSiteImage       = "site_image"   // Default share image for pages without their own
+	SettingTwitterSite     = "twitter_site" // @handle of the site on Twitter/X
+)
+
+// SiteInfo names the public site and where it is served, for links that leave the app (feeds, sitemaps, static builds)
+type SiteInfo struct {
+	Name        string
+	Description string
+	URL         string // Without a trailing slash
+	Image       string // Absolute, or empty
+	Twitter     string // With the leading @, or empty
+}
+
+// LoadSiteInfo reads the site settings, falling back to APP_NAME and APP_URL
+func LoadSiteInfo() SiteInfo {
+	values := map[string]string{}
+	var settings []models.Setting
+	database.DB.Where("key IN ?", []string{SettingSiteName, SettingSiteDescription, SettingSiteURL, SettingSiteImage, SettingTwitterSite}).Find(&settings)
+	for _, setting := range settings {
+		values[setting.Key] = strings.TrimSpace(setting.Value)
+	}
+
+	site := SiteInfo{
+		Name:        firstNonEmpty(values[SettingSiteName], os.Getenv("APP_NAME"), "TaraNote"),
+		Description: values[SettingSiteDescription],
+		URL:         firstNonEmpty(values[SettingSiteURL], os.Getenv("APP_URL")),
+	}
+	if site.URL == "" {
+		site.URL = "http://localhost:" + firstNonEmpty(os.Getenv("PORT"), "3000")
+	}
+	site.URL = strings.TrimRight(site.URL, "/")
+	if image := values[SettingSiteImage]; image != "" {
+		site.Image = site.ResolveURL(image)
+	}
+	if handle := strings.TrimPrefix(values[SettingTwitterSite], "@"); handle != "" {
+		site.Twitter = "@" + handle
+	}
+	return site
+}
+
+// AbsURL joins a site path such as /articles/x onto the site URL
+func (s SiteInfo) AbsURL(path string) string {
+	return s.URL + "/" + strings.TrimLeft(path, "/")
+}
+
+// ResolveURL makes a reference such as /public/uploads/x.png absolute; absolute URLs are kept
+func (s SiteInfo) ResolveURL(ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "//") {
+		return strings.SplitN(s.URL, ":", 2)[0] + ":" + ref
+	}
+	return s.AbsURL(ref)
+}
+
+// settingValue reads one setting, empty when unset
+func settingValue(key string) string {
+	var setting models.Setting
+	database.DB.Where("key = ?", key).Limit(1).Find(&setting)
+	return strings.TrimSpace(setting.Value)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Paths of public pages
+func ArticlePath(note *models.Note) string {
+	return "/articles/" + note.Slug
+}
+
+func NotebookPath(notebook *models.Notebook) string {
+	return "/notebooks/" + notebook.Slug
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+)
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapMaxURLs is the most URLs a sitemap file may list; larger sitemaps are split behind an index
+const SitemapMaxURLs = 50000
+
+// SettingRobotsTxt holds the robots.txt served at /robots.txt
+const SettingRobotsTxt = "robots_txt"
+
+// DefaultRobotsTxt keeps crawlers out of the app itself
+const DefaultRobotsTxt = `User-agent: *
+Disallow: /api/
+Disallow: /dashboard
+Disallow: /login
+`
+
+// SitemapURL is one page listed in a sitemap
+type SitemapURL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func toSitemapURLs(urls []SitemapURL) []sitemapURL {
+	entries := make([]sitemapURL, 0, len(urls))
+	for _, u := range urls {
+		entry := sitemapURL{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// WriteSitemap writes a sitemaps.org URL set
+func WriteSitemap(w io.Writer, urls []SitemapURL) error {
+	return writeXML(w, sitemapURLSet{Xmlns: sitemapNamespace, URLs: toSitemapURLs(urls)})
+}
+
+// WriteSitemapIndex writes a sitemap index pointing at the given sitemap files
+func WriteSitemapIndex(w io.Writer, sitemaps []SitemapURL) error {
+	return writeXML(w, sitemapIndex{Xmlns: sitemapNamespace, Sitemaps: toSitemapURLs(sitemaps)})
+}
+
+// Sitemap lists the public pages of the site, split into pages of at most PageSize URLs
+type Sitemap struct {
+	Site     SiteInfo
+	URLs     []SitemapURL
+	PageSize int
+}
+
+// Pages is how many sitemap files the URLs need; a single one is served without an index
+func (m *Sitemap) Pages() int {
+	if len(m.URLs) == 0 {
+		return 1
+	}
+	return (len(m.URLs) + m.PageSize - 1) / m.PageSize
+}
+
+// Page returns the URLs of sitemap file n, counting from 1
+func (m *Sitemap) Page(n int) []SitemapURL {
+	start := (n - 1) * m.PageSize
+	if n < 1 || start >= len(m.URLs) {
+		return nil
+	}
+	return m.URLs[start:min(start+m.PageSize, len(m.URLs))]
+}
+
+// SitemapPagePath is where sitemap file n of a split sitemap is served
+func SitemapPagePath(n int) string {
+	return fmt.Sprintf("/sitemap-%d.xml", n)
+}
+
+// Index lists the sitemap files with the newest lastmod of each
+func (m *Sitemap) Index() []SitemapURL {
+	index := make([]SitemapURL, 0, m.Pages())
+	for n := 1; n <= m.Pages(); n++ {
+		entry := SitemapURL{Loc: m.Site.AbsURL(SitemapPagePath(n))}
+		for _, u := range m.Page(n) {
+			if u.LastMod.After(entry.LastMod) {
+				entry.LastMod = u.LastMod
+			}
+		}
+		index = append(index, entry)
+	}
+	return index
+}
+
+// WriteRoot writes what /sitemap.xml serves: the URL set, or an index when the URLs need several files
+func (m *Sitemap) WriteRoot(w io.Writer) error {
+	if m.Pages() > 1 {
+		return WriteSitemapIndex(w, m.Index())
+	}
+	return WriteSitemap(w, m.URLs)
+}
+
+type SitemapService struct {
+	docsDir  string
+	pageSize int
+}
+
+func NewSitemapService(docsDir string, pageSize int) *SitemapService {
+	return &SitemapService{docsDir: docsDir, pageSize: pageSize}
+}
+
+// Build lists the home page, notebooks with published notes, published notes and the docs pages
+func (s *SitemapService) Build(site SiteInfo) (*Sitemap, error) {
+	var notes []models.Note
+	if err := database.DB.Select("id, slug, notebook_id, updated_at").
+		Where("status = ?", models.NoteStatusPublished).
+		Order("id asc").
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	var homeMod time.Time
+	notebookMod := map[uint]time.Time{}
+	for i := range notes {
+		note := &notes[i]
+		if note.UpdatedAt.After(homeMod) {
+			homeMod = note.UpdatedAt
+		}
+		if note.NotebookID != nil && note.UpdatedAt.After(notebookMod[*note.NotebookID]) {
+			notebookMod[*note.NotebookID] = note.UpdatedAt
+		}
+	}
+
+	urls := []SitemapURL{{Loc: site.AbsURL("/"), LastMod: homeMod}}
+
+	var notebooks []models.Notebook
+	if err := database.DB.Select("id, slug").Order("id asc").Find(&notebooks).Error; err != nil {
+		return nil, err
+	}
+	for i := range notebooks {
+		if lastMod, ok := notebookMod[notebooks[i].ID]; ok {
+			urls = append(urls, SitemapURL{Loc: site.AbsURL(NotebookPath(&notebooks[i])), LastMod: lastMod})
+		}
+	}
+
+	for i := range notes {
+		urls = append(urls, SitemapURL{Loc: site.AbsURL(ArticlePath(&notes[i])), LastMod: notes[i].UpdatedAt})
+	}
+
+	docs, err := s.docsURLs(site)
+	if err != nil {
+		return nil, err
+	}
+	urls = append(urls, docs...)
+
+	return &Sitemap{Site: site, URLs: urls, PageSize: s.pageSize}, nil
+}
+
+// docsURLs lists the Markdown pages DocsView serves, dated by their file
+func (s *SitemapService) docsURLs(site SiteInfo) ([]SitemapURL, error) {
+	var urls []SitemapURL
+	err := filepath.WalkDir(s.docsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != s.docsDir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.docsDir, path)
+		if err != nil {
+			return err
+		}
+		page := strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+		switch {
+		case page == "INDEX":
+			page = ""
+		case strings.HasSuffix(page, "/README"):
+			page = strings.TrimSuffix(page, "/README")
+		}
+		loc := "/docs"
+		if page != "" {
+			loc += "/" + page
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		urls = append(urls, SitemapURL{Loc: site.AbsURL(loc), LastMod: info.ModTime()})
+		return nil
+	})
+	return urls, err
+}
+
+// RobotsTxt returns the robots.txt from the robots_txt setting (or the default), pointing crawlers at the sitemap
+func RobotsTxt(site SiteInfo) string {
+	robots := settingValue(SettingRobotsTxt)
+	if robots == "" {
+		robots = DefaultRobotsTxt
+	}
+	robots = strings.TrimRight(strings.ReplaceAll(robots, "\r\n", "\n"), "\n") + "\n"
+	if !strings.Contains(strings.ToLower(robots), "sitemap:") {
+		robots += "\nSitemap: " + site.AbsURL("/sitemap.xml") + "\n"
+	}
+	return robots
+}
@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+type sitemapDoc struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+}
+
+type sitemapIndexDoc struct {
+	XMLName  xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+func TestSitemapService_Build(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	docs := t.TempDir()
+	os.MkdirAll(filepath.Join(docs, "guides"), 0o755)
+	os.MkdirAll(filepath.Join(docs, ".drafts"), 0o755)
+	for _, name := range []string{"INDEX.md", "FAQ.md", "guides/README.md", "guides/setup.md", ".drafts/wip.md", "logo.png"} {
+		os.WriteFile(filepath.Join(docs, name), []byte("# doc"), 0o644)
+	}
+
+	notebook := models.Notebook{UserID: 1, Name: "Recipes", Slug: "recipes"}
+	empty := models.Notebook{UserID: 1, Name: "Empty", Slug: "empty"}
+	database.DB.Create(&notebook)
+	database.DB.Create(&empty)
+	updated := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	database.DB.Create(&models.Note{UserID: 1, NotebookID: &notebook.ID, Title: "Soup", Slug: "soup", Status: models.NoteStatusPublished, UpdatedAt: updated})
+	database.DB.Create(&models.Note{UserID: 1, NotebookID: &empty.ID, Title: "Draft", Slug: "draft", Status: models.NoteStatusDraft})
+
+	site := services.SiteInfo{Name: "Blog", URL: "https://blog.example.com"}
+	sitemap, err := services.NewSitemapService(docs, services.SitemapMaxURLs).Build(site)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sitemap.Pages())
+
+	var buf bytes.Buffer
+	assert.NoError(t, sitemap.WriteRoot(&buf))
+	var doc sitemapDoc
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	locs := map[string]string{}
+	for _, u := range doc.URLs {
+		locs[u.Loc] = u.LastMod
+	}
+	assert.Equal(t, map[string]string{
+		"https://blog.example.com/":                  "2024-06-01T12:00:00Z",
+		"https://blog.example.com/notebooks/recipes": "2024-06-01T12:00:00Z",
+		"https://blog.example.com/articles/soup":     "2024-06-01T12:00:00Z",
+		"https://blog.example.com/docs":              locs["https://blog.example.com/docs"],
+		"https://blog.example.com/docs/FAQ":          locs["https://blog.example.com/docs/FAQ"],
+		"https://blog.example.com/docs/guides":       locs["https://blog.example.com/docs/guides"],
+		"https://blog.example.com/docs/guides/setup": locs["https://blog.example.com/docs/guides/setup"],
+	}, locs)
+	assert.NotEmpty(t, locs["https://blog.example.com/docs/FAQ"], "docs pages are dated by their file")
+}
+
+func TestSitemap_SplitsBehindIndex(t *testing.T) {
+	site := services.SiteInfo{URL: "https://blog.example.com"}
+	sitemap := &services.Sitemap{Site: site, PageSize: 2}
+	for i := 1; i <= 5; i++ {
+		sitemap.URLs = append(sitemap.URLs, services.SitemapURL{
+			Loc:     fmt.Sprintf("https://blog.example.com/articles/n%d", i),
+			LastMod: time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	assert.Equal(t, 3, sitemap.Pages())
+	assert.Len(t, sitemap.Page(3), 1)
+	assert.Nil(t, sitemap.Page(4))
+	assert.Nil(t, sitemap.Page(0))
+
+	var buf bytes.Buffer
+	assert.NoError(t, sitemap.WriteRoot(&buf))
+	var index sitemapIndexDoc
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &index))
+	if assert.Len(t, index.Sitemaps, 3) {
+		assert.Equal(t, "https://blog.example.com/sitemap-1.xml", index.Sitemaps[0].Loc)
+		assert.Equal(t, "2024-01-02T00:00:00Z", index.Sitemaps[0].LastMod)
+		assert.Equal(t, "2024-01-05T00:00:00Z", index.Sitemaps[2].LastMod)
+	}
+}
+
+func TestRobotsTxt(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	site := services.SiteInfo{URL: "https://blog.example.com"}
+	robots := services.RobotsTxt(site)
+	assert.Contains(t, robots, "Disallow: /api/\n")
+	assert.Contains(t, robots, "\nSitemap: https://blog.example.com/sitemap.xml\n")
+
+	database.DB.Create(&models.Setting{Key: services.SettingRobotsTxt, Value: "User-agent: *\r\nDisallow: /\r\n"})
+	assert.Equal(t, "User-agent: *\nDisallow: /\n\nSitemap: https://blog.example.com/sitemap.xml\n", services.RobotsTxt(site))
+
+	database.DB.Model(&models.Setting{}).Where("key = ?", services.SettingRobotsTxt).Update("value", "User-agent: *\nAllow: /\nSitemap: https://cdn.example.com/sitemap.xml")
+	assert.Equal(t, "User-agent: *\nAllow: /\nSitemap: https://cdn.example.com/sitemap.xml\n", services.RobotsTxt(site))
+}
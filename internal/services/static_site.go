@@ -0,0 +1,363 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+)
+
+// StaticManifestFile records what the last build wrote, so the next one can skip unchanged notes
+const StaticManifestFile = ".build-manifest.json"
+
+// Page templates of a static build; each is parsed together with layout.tmpl
+var staticPages = []string{"home", "note", "notebook"}
+
+type staticManifest struct {
+	SiteURL   string                        `json:"site_url"`
+	Templates time.Time                     `json:"templates"` // Newest template modification
+	Notes     map[uint]staticManifestNote   `json:"notes"`
+	Uploads   map[string]staticManifestFile `json:"uploads"`
+}
+
+type staticManifestNote struct {
+	Dir       string    `json:"dir"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type staticManifestFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type StaticBuildOptions struct {
+	Full    bool   // Re-render every note even if unchanged
+	SiteURL string // Where the site will be hosted; defaults to the site settings
+}
+
+// StaticBuildReport counts what a build did
+type StaticBuildReport struct {
+	Full      bool `json:"full"`
+	Notes     int  `json:"notes"`    // Published notes on the site
+	Rendered  int  `json:"rendered"` // Note pages written by this build
+	Removed   int  `json:"removed"`  // Note pages of notes no longer published
+	Notebooks int  `json:"notebooks"`
+	Uploads   int  `json:"uploads"` // Uploads copied by this build
+}
+
+// StaticPage is the data the static templates are executed with
+type StaticPage struct {
+	Site      SiteInfo
+	Title     string
+	Path      string // Site path of the page, e.g. /articles/x
+	Note      *models.Note
+	Notebook  *models.Notebook
+	Notes     []models.Note
+	Notebooks []models.Notebook
+	BuiltAt   time.Time
+}
+
+// StaticSiteBuilder renders the published notes as plain files: a home page, a page per note
+// and per notebook, RSS and Atom feeds and a sitemap, with the uploads they reference
+type StaticSiteBuilder struct {
+	viewsDir   string
+	uploadsDir string
+	outDir     string
+}
+
+func NewStaticSiteBuilder(viewsDir, uploadsDir, outDir string) *StaticSiteBuilder {
+	return &StaticSiteBuilder{viewsDir: viewsDir, uploadsDir: uploadsDir, outDir: outDir}
+}
+
+var staticFuncs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("January 2, 2006") },
+	// Note content is sanitized when saved
+	"content":      func(note *models.Note) template.HTML { return template.HTML(note.Content) },
+	"published":    notePublishedAt,
+	"articlePath":  ArticlePath,
+	"notebookPath": NotebookPath,
+}
+
+// Build renders the site into the output directory. Unless opts.Full is set, note pages are only
+// rendered when the note's UpdatedAt changed since the last build; a change to the templates or
+// the site URL rebuilds everything. Pages of notes that are no longer published are removed.
+func (b *StaticSiteBuilder) Build(opts StaticBuildOptions) (*StaticBuildReport, error) {
+	templates, newest, err := b.loadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	site := LoadSiteInfo()
+	if opts.SiteURL != "" {
+		site.URL = strings.TrimRight(opts.SiteURL, "/")
+	}
+
+	var notes []models.Note
+	if err := database.DB.Where("status = ?", models.NoteStatusPublished).
+		Preload("User").Preload("Notebook").Preload("Tags").
+		Order("COALESCE(published_at, created_at) desc").Order("id desc").
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	previous := b.readManifest()
+	full := opts.Full || previous == nil || previous.SiteURL != site.URL || newest.After(previous.Templates)
+	if previous == nil {
+		previous = &staticManifest{}
+	}
+	manifest := &staticManifest{SiteURL: site.URL, Templates: newest, Notes: map[uint]staticManifestNote{}, Uploads: map[string]staticManifestFile{}}
+	report := &StaticBuildReport{Full: full, Notes: len(notes)}
+	builtAt := time.Now().UTC()
+	page := func(title, path string) StaticPage {
+		return StaticPage{Site: site, Title: title, Path: path, BuiltAt: builtAt}
+	}
+
+	uploads := map[string]bool{}
+	notebooks := []models.Notebook{}
+	notebookNotes := map[uint][]models.Note{}
+	sitemap := []SitemapURL{{Loc: site.AbsURL("/")}}
+
+	for i := range notes {
+		note := &notes[i]
+		dir := filepath.Join("articles", exportName(note.Slug, "note"))
+		manifest.Notes[note.ID] = staticManifestNote{Dir: dir, UpdatedAt: note.UpdatedAt}
+		sitemap = append(sitemap, SitemapURL{Loc: site.AbsURL(ArticlePath(note)), LastMod: note.UpdatedAt})
+		for _, match := range uploadRefPattern.FindAllStringSubmatch(note.Content+" "+note.CoverImage, -1) {
+			uploads[match[1]] = true
+		}
+		if note.Notebook != nil {
+			if _, ok := notebookNotes[note.Notebook.ID]; !ok {
+				notebooks = append(notebooks, *note.Notebook)
+			}
+			notebookNotes[note.Notebook.ID] = append(notebookNotes[note.Notebook.ID], *note)
+		}
+
+		last, ok := previous.Notes[note.ID]
+		if !full && ok && last.Dir == dir && last.UpdatedAt.Equal(note.UpdatedAt) && b.exists(filepath.Join(dir, "index.html")) {
+			continue
+		}
+		data := page(note.Title, ArticlePath(note))
+		data.Note = note
+		if err := b.render(templates["note"], filepath.Join(dir, "index.html"), data); err != nil {
+			return nil, err
+		}
+		report.Rendered++
+	}
+
+	// Pages of notes that were unpublished, deleted or renamed
+	for id, last := range previous.Notes {
+		if current, ok := manifest.Notes[id]; ok && current.Dir == last.Dir {
+			continue
+		}
+		if b.dirInUse(manifest, last.Dir) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(b.outDir, last.Dir)); err != nil {
+			return nil, err
+		}
+		report.Removed++
+	}
+
+	home := page(site.Name, "/")
+	home.Notes = notes
+	home.Notebooks = notebooks
+	if err := b.render(templates["home"], "index.html", home); err != nil {
+		return nil, err
+	}
+
+	// Notebook pages are cheap and list notes, so they are always rendered
+	if err := os.RemoveAll(filepath.Join(b.outDir, "notebooks")); err != nil {
+		return nil, err
+	}
+	for i := range notebooks {
+		notebook := &notebooks[i]
+		data := page(notebook.Name, NotebookPath(notebook))
+		data.Notebook = notebook
+		data.Notes = notebookNotes[notebook.ID]
+		data.Notebooks = notebooks
+		if err := b.render(templates["notebook"], filepath.Join("notebooks", exportName(notebook.Slug, "notebook"), "index.html"), data); err != nil {
+			return nil, err
+		}
+		sitemap = append(sitemap, SitemapURL{Loc: site.AbsURL(NotebookPath(notebook))})
+	}
+	report.Notebooks = len(notebooks)
+
+	feed, err := NewFeedService().Feed(site, FeedScope{})
+	if err != nil {
+		return nil, err
+	}
+	feed.Self = site.AbsURL("/feed.xml")
+	if err := b.write("feed.xml", func(w io.Writer) error { return WriteRSS(w, feed) }); err != nil {
+		return nil, err
+	}
+	feed.Self = site.AbsURL("/atom.xml")
+	if err := b.write("atom.xml", func(w io.Writer) error { return WriteAtom(w, feed) }); err != nil {
+		return nil, err
+	}
+	if err := b.writeSitemap(&Sitemap{Site: site, URLs: sitemap, PageSize: SitemapMaxURLs}); err != nil {
+		return nil, err
+	}
+
+	copied, err := b.copyUploads(uploads, previous, manifest)
+	if err != nil {
+		return nil, err
+	}
+	report.Uploads = copied
+
+	return report, b.writeManifest(manifest)
+}
+
+// writeSitemap writes sitemap.xml, and the files it indexes when the site outgrows one
+func (b *StaticSiteBuilder) writeSitemap(sitemap *Sitemap) error {
+	if err := b.write("sitemap.xml", sitemap.WriteRoot); err != nil {
+		return err
+	}
+	for n := 1; sitemap.Pages() > 1 && n <= sitemap.Pages(); n++ {
+		page := sitemap.Page(n)
+		if err := b.write(strings.TrimPrefix(SitemapPagePath(n), "/"), func(w io.Writer) error { return WriteSitemap(w, page) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTemplates parses the page templates and returns the newest modification time among them
+func (b *StaticSiteBuilder) loadTemplates() (map[string]*template.Template, time.Time, error) {
+	var newest time.Time
+	layout := filepath.Join(b.viewsDir, "layout.tmpl")
+	templates := map[string]*template.Template{}
+	for _, name := range staticPages {
+		file := filepath.Join(b.viewsDir, name+".tmpl")
+		for _, f := range []string{layout, file} {
+			info, err := os.Stat(f)
+			if err != nil {
+				return nil, newest, err
+			}
+			if info.ModTime().After(newest) {
+				newest = info.ModTime()
+			}
+		}
+		tmpl, err := template.New(name).Funcs(staticFuncs).ParseFiles(layout, file)
+		if err != nil {
+			return nil, newest, err
+		}
+		templates[name] = tmpl
+	}
+	return templates, newest, nil
+}
+
+func (b *StaticSiteBuilder) render(tmpl *template.Template, name string, data StaticPage) error {
+	return b.write(name, func(w io.Writer) error { return tmpl.ExecuteTemplate(w, "layout", data) })
+}
+
+// write renders a file fully before replacing the old one, so a failed template leaves the previous page
+func (b *StaticSiteBuilder) write(name string, fill func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := fill(&buf); err != nil {
+		return err
+	}
+	dst := filepath.Join(b.outDir, name)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, buf.Bytes(), 0o644)
+}
+
+func (b *StaticSiteBuilder) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(b.outDir, name))
+	return err == nil
+}
+
+func (b *StaticSiteBuilder) dirInUse(manifest *staticManifest, dir string) bool {
+	for _, note := range manifest.Notes {
+		if note.Dir == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// copyUploads copies the referenced uploads that are new or changed and removes the ones no longer referenced
+func (b *StaticSiteBuilder) copyUploads(files map[string]bool, previous, manifest *staticManifest) (int, error) {
+	dstDir := filepath.Join(b.outDir, filepath.FromSlash(UploadsURLPrefix))
+	copied := 0
+	for file := range files {
+		src := filepath.Join(b.uploadsDir, file)
+		info, err := os.Stat(src)
+		if err != nil || !info.Mode().IsRegular() {
+			continue // Missing uploads stay broken links, as on the server
+		}
+		state := staticManifestFile{Size: info.Size(), ModTime: info.ModTime().UTC()}
+		manifest.Uploads[file] = state
+
+		dst := filepath.Join(dstDir, file)
+		if last, ok := previous.Uploads[file]; ok && last == state {
+			if _, err := os.Stat(dst); err == nil {
+				continue
+			}
+		}
+		if err := copyFile(src, dst); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+
+	for file := range previous.Uploads {
+		if _, ok := manifest.Uploads[file]; !ok {
+			if err := os.Remove(filepath.Join(dstDir, file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return copied, err
+			}
+		}
+	}
+	return copied, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readManifest returns the last build's manifest, or nil when there is none to trust
+func (b *StaticSiteBuilder) readManifest() *staticManifest {
+	data, err := os.ReadFile(filepath.Join(b.outDir, StaticManifestFile))
+	if err != nil {
+		return nil
+	}
+	var manifest staticManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+	return &manifest
+}
+
+func (b *StaticSiteBuilder) writeManifest(manifest *staticManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(b.outDir, StaticManifestFile), data, 0o644)
+}
@@ -0,0 +1,96 @@
+package services_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+func readOut(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestStaticSiteBuilder_Build(t *testing.T) {
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	uploads := t.TempDir()
+	os.WriteFile(filepath.Join(uploads, "photo.png"), []byte("png"), 0o644)
+	os.WriteFile(filepath.Join(uploads, "unused.png"), []byte("png"), 0o644)
+
+	published := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	notebook := models.Notebook{UserID: 1, Name: "Travel", Slug: "travel"}
+	database.DB.Create(&notebook)
+	trip := models.Note{UserID: 1, NotebookID: &notebook.ID, Title: "Trip <notes>", Slug: "trip", Status: models.NoteStatusPublished, PublishedAt: &published,
+		Excerpt: "Where we went", Content: `<p>Hello</p><img src="/public/uploads/photo.png">`}
+	other := models.Note{UserID: 1, Title: "Other", Slug: "other", Status: models.NoteStatusPublished, PublishedAt: &published, Content: "<p>Other</p>"}
+	draft := models.Note{UserID: 1, Title: "Secret draft", Slug: "secret", Status: models.NoteStatusDraft, Content: "<p>Draft</p>"}
+	database.DB.Create(&trip)
+	database.DB.Create(&other)
+	database.DB.Create(&draft)
+
+	out := t.TempDir()
+	builder := services.NewStaticSiteBuilder("../../views/static", uploads, out)
+	opts := services.StaticBuildOptions{SiteURL: "https://blog.example.com/"}
+
+	// 1. First build renders everything
+	report, err := builder.Build(opts)
+	assert.NoError(t, err)
+	assert.True(t, report.Full)
+	assert.Equal(t, 2, report.Notes)
+	assert.Equal(t, 2, report.Rendered)
+	assert.Equal(t, 1, report.Notebooks)
+	assert.Equal(t, 1, report.Uploads)
+
+	page := readOut(t, out, "articles/trip/index.html")
+	assert.Contains(t, page, "<h1>Trip &lt;notes&gt;</h1>")
+	assert.Contains(t, page, `<img src="/public/uploads/photo.png">`)
+	assert.Contains(t, page, `<link rel="canonical" href="https://blog.example.com/articles/trip">`)
+	assert.Contains(t, readOut(t, out, "index.html"), `<a href="/articles/other">Other</a>`)
+	assert.Contains(t, readOut(t, out, "notebooks/travel/index.html"), `<a href="/articles/trip">`)
+	assert.Equal(t, "png", readOut(t, out, "public/uploads/photo.png"))
+	assert.NoFileExists(t, filepath.Join(out, "public/uploads/unused.png"))
+	assert.NoDirExists(t, filepath.Join(out, "articles/secret"))
+
+	feed := readOut(t, out, "feed.xml")
+	assert.Contains(t, feed, "<link>https://blog.example.com/articles/trip</link>")
+	assert.Contains(t, feed, "<description>Where we went</description>")
+	assert.NotContains(t, feed, "Secret")
+	sitemap := readOut(t, out, "sitemap.xml")
+	assert.Contains(t, sitemap, "<loc>https://blog.example.com/notebooks/travel</loc>")
+	assert.Contains(t, sitemap, "<loc>https://blog.example.com/articles/other</loc>")
+
+	// 2. Nothing changed: no note is rendered again
+	report, err = builder.Build(opts)
+	assert.NoError(t, err)
+	assert.False(t, report.Full)
+	assert.Equal(t, 0, report.Rendered)
+	assert.Equal(t, 0, report.Uploads)
+
+	// 3. Only the updated note is rendered; unpublished notes are removed
+	database.DB.Model(&trip).Updates(map[string]interface{}{"title": "Trip report", "updated_at": time.Now().Add(time.Minute)})
+	database.DB.Model(&other).Update("status", models.NoteStatusArchived)
+	report, err = builder.Build(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Rendered)
+	assert.Equal(t, 1, report.Removed)
+	assert.Contains(t, readOut(t, out, "articles/trip/index.html"), "<h1>Trip report</h1>")
+	assert.NoDirExists(t, filepath.Join(out, "articles/other"))
+	assert.NotContains(t, readOut(t, out, "index.html"), `href="/articles/other"`)
+
+	// 4. A different site URL rebuilds everything
+	report, err = builder.Build(services.StaticBuildOptions{SiteURL: "https://notes.example.org"})
+	assert.NoError(t, err)
+	assert.True(t, report.Full)
+	assert.Equal(t, 1, report.Rendered)
+}
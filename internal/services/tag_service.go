@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTagNotFound  = errors.New("tag not found")
+	ErrTagExists    = errors.New("a tag with this name already exists")
+	ErrTagMergeSelf = errors.New("cannot merge a tag into itself")
+)
+
+type TagService struct {
+	validate *validator.Validate
+}
+
+func NewTagService() *TagService {
+	return &TagService{
+		validate: validator.New(),
+	}
+}
+
+type TagRequest struct {
+	UserID uint   `validate:"required"`
+	Name   string `validate:"required,min=1,max=100"`
+}
+
+// ListTags returns the user's tags with the number of live notes carrying each tag
+func (s *TagService) ListTags(userID uint) ([]models.Tag, error) {
+	var tags []models.Tag
+	if err := database.DB.Where("user_id = ?", userID).Order("name asc").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	type tagCount struct {
+		TagID uint
+		Count int64
+	}
+	var counts []tagCount
+	if err := database.DB.Table("note_tags").
+		Select("note_tags.tag_id, count(notes.id) as count").
+		Joins("JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL").
+		Where("notes.user_id = ?", userID).
+		Group("note_tags.tag_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	byTag := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byTag[c.TagID] = c.Count
+	}
+	for i := range tags {
+		tags[i].NotesCount = byTag[tags[i].ID]
+	}
+
+	return tags, nil
+}
+
+func (s *TagService) CreateTag(req TagRequest) (*models.Tag, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	tag := models.Tag{
+		UserID: req.UserID,
+		Name:   strings.TrimSpace(req.Name),
+		Slug:   utils.GenerateSlug(req.Name),
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Tag{}).Where("user_id = ? AND slug = ?", tag.UserID, tag.Slug).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrTagExists
+	}
+
+	if err := database.DB.Create(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// RenameTag changes a tag's name and slug. Renaming onto an existing tag is rejected; use MergeTags instead.
+func (s *TagService) RenameTag(id string, req TagRequest) (*models.Tag, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var tag models.Tag
+	if err := database.DB.Where("id = ? AND user_id = ?", id, req.UserID).First(&tag).Error; err != nil {
+		return nil, ErrTagNotFound
+	}
+
+	tag.Name = strings.TrimSpace(req.Name)
+	tag.Slug = utils.GenerateSlug(req.Name)
+
+	var count int64
+	if err := database.DB.Model(&models.Tag{}).Where("user_id = ? AND slug = ? AND id != ?", tag.UserID, tag.Slug, tag.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrTagExists
+	}
+
+	if err := database.DB.Save(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag and detaches it from all notes
+func (s *TagService) DeleteTag(id string, userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+			return ErrTagNotFound
+		}
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+}
+
+// MergeTags moves every note from the source tag onto the target tag and deletes the source
+func (s *TagService) MergeTags(sourceID, targetID string, userID uint) (*models.Tag, error) {
+	var target models.Tag
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var source models.Tag
+		if err := tx.Where("id = ? AND user_id = ?", sourceID, userID).First(&source).Error; err != nil {
+			return ErrTagNotFound
+		}
+		if err := tx.Where("id = ? AND user_id = ?", targetID, userID).First(&target).Error; err != nil {
+			return ErrTagNotFound
+		}
+		if source.ID == target.ID {
+			return ErrTagMergeSelf
+		}
+
+		// Re-point associations, skipping notes that already carry the target tag
+		if err := tx.Exec(
+			"UPDATE note_tags SET tag_id = ? WHERE tag_id = ? AND note_id NOT IN (SELECT note_id FROM note_tags WHERE tag_id = ?)",
+			target.ID, source.ID, target.ID,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
+
+// resolveTags finds or creates the user's tags for the given names, ignoring blanks and duplicates
+func resolveTags(tx *gorm.DB, userID uint, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		slug := utils.GenerateSlug(name)
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		tag := models.Tag{UserID: userID, Slug: slug}
+		if err := tx.Where(models.Tag{UserID: userID, Slug: slug}).
+			Attrs(models.Tag{Name: name}).
+			FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
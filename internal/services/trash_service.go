@@ -0,0 +1,351 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrTrashItemNotFound = errors.New("item not found in trash")
+
+// Trash retention defaults, overridable via TRASH_RETENTION and TRASH_SWEEP_INTERVAL
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashSweepInterval = time.Hour
+)
+
+// TrashRetentionFromEnv returns how long soft-deleted items are kept before being purged.
+// A value <= 0 disables the automatic sweep.
+func TrashRetentionFromEnv() time.Duration {
+	return durationFromEnv("TRASH_RETENTION", defaultTrashRetention)
+}
+
+// TrashSweepIntervalFromEnv returns how often the sweeper checks for expired items
+func TrashSweepIntervalFromEnv() time.Duration {
+	return durationFromEnv("TRASH_SWEEP_INTERVAL", defaultTrashSweepInterval)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+type TrashService struct{}
+
+func NewTrashService() *TrashService {
+	return &TrashService{}
+}
+
+// Trash lists the soft-deleted items of a user
+type Trash struct {
+	Notes     []models.Note     `json:"notes"`
+	Notebooks []models.Notebook `json:"notebooks"`
+}
+
+func (s *TrashService) ListTrash(userID uint) (*Trash, error) {
+	trash := &Trash{Notes: []models.Note{}, Notebooks: []models.Notebook{}}
+
+	if err := database.DB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Find(&trash.Notes).Error; err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Find(&trash.Notebooks).Error; err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+func findTrashedNote(tx *gorm.DB, id string, userID uint) (*models.Note, error) {
+	var note models.Note
+	if err := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&note).Error; err != nil {
+		return nil, ErrTrashItemNotFound
+	}
+	return &note, nil
+}
+
+func findTrashedNotebook(tx *gorm.DB, id string, userID uint) (*models.Notebook, error) {
+	var notebook models.Notebook
+	if err := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&notebook).Error; err != nil {
+		return nil, ErrTrashItemNotFound
+	}
+	return &notebook, nil
+}
+
+// RestoreNote brings a note back from the trash.
+// If its notebook is still in the trash the note is restored without a notebook.
+func (s *TrashService) RestoreNote(id string, userID uint) (*models.Note, error) {
+	var restored *models.Note
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		note, err := findTrashedNote(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"deleted_at": nil}
+		if note.NotebookID != nil {
+			var count int64
+			if err := tx.Model(&models.Notebook{}).Where("id = ?", *note.NotebookID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				updates["notebook_id"] = nil
+			}
+		}
+
+		if err := tx.Unscoped().Model(note).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		restored = note
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var note models.Note
+	if err := database.DB.First(&note, restored.ID).Error; err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// RestoreNotebook brings a notebook back along with the notebooks below it and the notes that were
+// trashed with or after it. If its parent is still in the trash it is restored at the top level.
+// It returns the number of notes restored.
+func (s *TrashService) RestoreNotebook(id string, userID uint) (*models.Notebook, int64, error) {
+	var notebook *models.Notebook
+	var restoredNotes int64
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		notebook, err = findTrashedNotebook(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		// Notebooks below it that were trashed with or after it come back too
+		ids, err := trashedNotebookDescendants(tx, notebook.ID, notebook.DeletedAt.Time)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, notebook.ID)
+
+		result := tx.Unscoped().Model(&models.Note{}).
+			Where("notebook_id IN ? AND user_id = ? AND deleted_at >= ?", ids, userID, notebook.DeletedAt.Time).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		restoredNotes = result.RowsAffected
+
+		updates := map[string]interface{}{"deleted_at": nil}
+		if notebook.ParentID != nil {
+			var count int64
+			if err := tx.Model(&models.Notebook{}).Where("id = ?", *notebook.ParentID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				updates["parent_id"] = nil
+				notebook.ParentID = nil
+			}
+		}
+		if err := checkNotebookName(tx, notebook.ID, userID, notebook.ParentID, notebook.Name); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(notebook).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Notebook{}).Where("id IN ?", ids).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		notebook.DeletedAt = gorm.DeletedAt{}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notebook, restoredNotes, nil
+}
+
+// trashedNotebookDescendants returns the trashed notebooks below a notebook that were trashed at or after since
+func trashedNotebookDescendants(tx *gorm.DB, id uint, since time.Time) ([]uint, error) {
+	var all []uint
+	seen := map[uint]bool{id: true}
+	for level := []uint{id}; len(level) > 0; {
+		var children []uint
+		if err := tx.Unscoped().Model(&models.Notebook{}).
+			Where("parent_id IN ? AND deleted_at >= ?", level, since).
+			Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, child := range children {
+			if !seen[child] {
+				seen[child] = true
+				level = append(level, child)
+				all = append(all, child)
+			}
+		}
+	}
+	return all, nil
+}
+
+// PurgeNote permanently deletes a trashed note
+func (s *TrashService) PurgeNote(id string, userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		note, err := findTrashedNote(tx, id, userID)
+		if err != nil {
+			return err
+		}
+		return purgeNotes(tx, []uint{note.ID})
+	})
+}
+
+// PurgeNotebook permanently deletes a trashed notebook and the trashed notes inside it.
+// Live notes that still reference the notebook are detached.
+func (s *TrashService) PurgeNotebook(id string, userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		notebook, err := findTrashedNotebook(tx, id, userID)
+		if err != nil {
+			return err
+		}
+		return purgeNotebooks(tx, []uint{notebook.ID})
+	})
+}
+
+// EmptyTrash permanently deletes every trashed item of a user
+func (s *TrashService) EmptyTrash(userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return purgeWhere(tx, "user_id = ? AND deleted_at IS NOT NULL", userID)
+	})
+}
+
+// Sweep permanently deletes items that have been in the trash longer than retention
+func (s *TrashService) Sweep(retention time.Duration) error {
+	cutoff := time.Now().Add(-retention)
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return purgeWhere(tx, "deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	})
+}
+
+// StartSweeper runs Sweep periodically in the background until stop is closed
+func (s *TrashService) StartSweeper(interval, retention time.Duration, stop <-chan struct{}) {
+	if interval <= 0 || retention <= 0 {
+		log.Println("Trash sweeper disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Sweep(retention); err != nil {
+				log.Printf("Trash sweep failed: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// purgeWhere purges all trashed notes and notebooks matching the condition
+func purgeWhere(tx *gorm.DB, query string, args ...interface{}) error {
+	var noteIDs []uint
+	if err := tx.Unscoped().Model(&models.Note{}).Where(query, args...).Pluck("id", &noteIDs).Error; err != nil {
+		return err
+	}
+	var notebookIDs []uint
+	if err := tx.Unscoped().Model(&models.Notebook{}).Where(query, args...).Pluck("id", &notebookIDs).Error; err != nil {
+		return err
+	}
+
+	if err := purgeNotes(tx, noteIDs); err != nil {
+		return err
+	}
+	return purgeNotebooks(tx, notebookIDs)
+}
+
+// purgeNotes hard-deletes notes together with the rows that hang off them
+func purgeNotes(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("note_id IN ?", ids).Delete(&models.NoteRevision{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM note_tags WHERE note_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	if err := unindexNotes(tx, ids); err != nil {
+		return err
+	}
+	if err := removeNoteLinks(tx, ids); err != nil {
+		return err
+	}
+	if err := tx.Where("note_id IN ?", ids).Delete(&models.SlugHistory{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("note_id IN ?", ids).Delete(&models.NoteDailyViews{}).Error; err != nil {
+		return err
+	}
+	if err := removeMarks(tx, models.MarkItemNote, ids); err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Note{}).Error
+}
+
+// purgeNotebooks hard-deletes notebooks, their trashed notes, and detaches any live notes and child notebooks
+func purgeNotebooks(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var trashedNoteIDs []uint
+	if err := tx.Unscoped().Model(&models.Note{}).
+		Where("notebook_id IN ? AND deleted_at IS NOT NULL", ids).
+		Pluck("id", &trashedNoteIDs).Error; err != nil {
+		return err
+	}
+	if err := purgeNotes(tx, trashedNoteIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Note{}).Where("notebook_id IN ?", ids).Update("notebook_id", nil).Error; err != nil {
+		return err
+	}
+	// Notebooks below them, live or trashed, move to the top level
+	if err := tx.Unscoped().Model(&models.Notebook{}).Where("parent_id IN ?", ids).Update("parent_id", nil).Error; err != nil {
+		return err
+	}
+
+	if err := removeMarks(tx, models.MarkItemNotebook, ids); err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Notebook{}).Error
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// View counting defaults, overridable via VIEW_DEDUPE_WINDOW and VIEW_FLUSH_INTERVAL
+const (
+	defaultViewDedupeWindow  = 30 * time.Minute
+	defaultViewFlushInterval = 30 * time.Second
+)
+
+// ViewDedupeWindowFromEnv returns how long repeat views by the same visitor are ignored
+func ViewDedupeWindowFromEnv() time.Duration {
+	return durationFromEnv("VIEW_DEDUPE_WINDOW", defaultViewDedupeWindow)
+}
+
+// ViewFlushIntervalFromEnv returns how often buffered views are written to the database
+func ViewFlushIntervalFromEnv() time.Duration {
+	return durationFromEnv("VIEW_FLUSH_INTERVAL", defaultViewFlushInterval)
+}
+
+// Crawlers, link previews, monitors and scripted clients
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|embedly|preview|monitor|uptime|lighthouse|headless|curl|wget|python-requests|go-http-client|httpclient|java/|okhttp|axios|node-fetch`)
+
+// IsBot reports whether a User-Agent belongs to an automated client; an empty one counts as a bot
+func IsBot(userAgent string) bool {
+	return strings.TrimSpace(userAgent) == "" || botPattern.MatchString(userAgent)
+}
+
+// PageView is a single request for a public note
+type PageView struct {
+	NoteID    uint
+	AuthorID  uint
+	ViewerID  uint     // Signed-in user, 0 for guests
+	Visitors  []string // Identities of the visitor (cookie, fingerprint); seen if any of them was counted recently
+	UserAgent string
+}
+
+type viewKey struct {
+	noteID uint
+	day    string
+}
+
+// ViewCounter deduplicates page views in memory and writes them in batches,
+// so a page view never waits on a database write
+type ViewCounter struct {
+	clock Clock
+
+	mu      sync.Mutex
+	seen    map[string]time.Time // identity|note -> time of the last counted view
+	pending map[viewKey]int
+}
+
+func NewViewCounter(clock Clock) *ViewCounter {
+	return &ViewCounter{
+		clock:   clock,
+		seen:    map[string]time.Time{},
+		pending: map[viewKey]int{},
+	}
+}
+
+// Views is the process-wide counter used by the public pages
+var Views = NewViewCounter(SystemClock)
+
+// Record counts a view unless it comes from a bot, the note's author,
+// or a visitor already counted for this note within the dedupe window.
+// The window is resolved on each call so values loaded from .env after startup are honoured.
+func (v *ViewCounter) Record(view PageView) bool {
+	if IsBot(view.UserAgent) || (view.ViewerID != 0 && view.ViewerID == view.AuthorID) {
+		return false
+	}
+
+	now, window := v.clock.Now().UTC(), ViewDedupeWindowFromEnv()
+	keys := make([]string, 0, len(view.Visitors)+1)
+	if view.ViewerID != 0 {
+		keys = append(keys, "user:"+uintString(view.ViewerID))
+	}
+	for _, visitor := range view.Visitors {
+		if visitor != "" {
+			keys = append(keys, visitor)
+		}
+	}
+	if len(keys) == 0 {
+		return false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// A view counts when none of the visitor's identities was counted within the window.
+	// New identities (e.g. a cookie issued on the first visit) inherit the time of the last counted view.
+	counted, since := true, now
+	for _, key := range keys {
+		if last, ok := v.seen[key+"|"+uintString(view.NoteID)]; ok && now.Sub(last) < window {
+			counted, since = false, last
+		}
+	}
+	for _, key := range keys {
+		if last, ok := v.seen[key+"|"+uintString(view.NoteID)]; counted || !ok || now.Sub(last) >= window {
+			v.seen[key+"|"+uintString(view.NoteID)] = since
+		}
+	}
+	if counted {
+		v.pending[viewKey{noteID: view.NoteID, day: now.Format("2006-01-02")}]++
+	}
+	return counted
+}
+
+// Flush writes buffered views to notes.views and the daily table, and forgets visitors
+// outside the dedupe window. It returns the number of views written.
+func (v *ViewCounter) Flush() (int, error) {
+	v.mu.Lock()
+	pending := v.pending
+	v.pending = map[viewKey]int{}
+	cutoff := v.clock.Now().UTC().Add(-ViewDedupeWindowFromEnv())
+	for key, last := range v.seen {
+		if last.Before(cutoff) {
+			delete(v.seen, key)
+		}
+	}
+	v.mu.Unlock()
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	totals := map[uint]int{}
+	written := 0
+	for key, count := range pending {
+		totals[key.noteID] += count
+		written += count
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for noteID, count := range totals {
+			// Views are not edits: no version bump and no updated_at change
+			if err := tx.Unscoped().Model(&models.Note{}).Where("id = ?", noteID).
+				UpdateColumn("views", gorm.Expr("views + ?", count)).Error; err != nil {
+				return err
+			}
+		}
+		for key, count := range pending {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "note_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("note_daily_views.views + excluded.views")}),
+			}).Create(&models.NoteDailyViews{NoteID: key.noteID, Day: key.day, Views: count}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the counts for the next attempt
+		v.mu.Lock()
+		for key, count := range pending {
+			v.pending[key] += count
+		}
+		v.mu.Unlock()
+		return 0, err
+	}
+	return written, nil
+}
+
+// Start flushes every interval until stop is closed, then flushes once more
+func (v *ViewCounter) Start(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := v.Flush(); err != nil {
+					log.Printf("View flush failed: %v", err)
+				}
+			case <-stop:
+				if _, err := v.Flush(); err != nil {
+					log.Printf("View flush failed: %v", err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+func uintString(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
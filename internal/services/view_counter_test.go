@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+	"github.com/tarakreasi/taraNote_go/internal/services"
+	"github.com/tarakreasi/taraNote_go/internal/testutils"
+)
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestIsBot(t *testing.T) {
+	assert.False(t, services.IsBot(browserUA))
+	assert.True(t, services.IsBot(""))
+	assert.True(t, services.IsBot("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"))
+	assert.True(t, services.IsBot("facebookexternalhit/1.1"))
+	assert.True(t, services.IsBot("curl/8.4.0"))
+}
+
+func TestViewCounter_DedupeAndFlush(t *testing.T) {
+	t.Setenv("VIEW_DEDUPE_WINDOW", "30m")
+	testutils.SetupApp()
+	defer testutils.CleanupDB()
+
+	note := models.Note{UserID: 1, Title: "Counted", Slug: "counted", Status: models.NoteStatusPublished}
+	database.DB.Create(&note)
+
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 23, 50, 0, 0, time.UTC)}
+	counter := services.NewViewCounter(clock)
+	view := func(viewerID uint, ua string, visitors ...string) bool {
+		return counter.Record(services.PageView{NoteID: note.ID, AuthorID: 1, ViewerID: viewerID, Visitors: visitors, UserAgent: ua})
+	}
+
+	// 1. Repeat views within the window count once; a new cookie inherits the fingerprint's view
+	assert.True(t, view(0, browserUA, "fp:a", "c:a"))
+	assert.False(t, view(0, browserUA, "c:a"))
+	assert.False(t, view(0, browserUA, "fp:a", "c:a2"))
+	assert.True(t, view(0, browserUA, "c:b"))
+
+	// 2. Bots and the author are never counted; other signed-in users are
+	assert.False(t, view(0, "Googlebot/2.1", "c:bot"))
+	assert.False(t, view(1, browserUA, "c:author"))
+	assert.True(t, view(2, browserUA, "c:reader"))
+	assert.False(t, view(2, browserUA, "c:other-device"))
+
+	// 3. Reloading doesn't extend the window
+	clock.Advance(20 * time.Minute)
+	assert.False(t, view(0, browserUA, "c:a"))
+	clock.Advance(15 * time.Minute)
+	assert.True(t, view(0, browserUA, "c:a"))
+
+	// 4. Flush writes the totals and daily rows without touching the note's version
+	written, err := counter.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, written)
+
+	written, err = counter.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, written)
+
+	var stored models.Note
+	database.DB.First(&stored, note.ID)
+	assert.Equal(t, 4, stored.Views)
+	assert.Equal(t, note.Version, stored.Version)
+
+	var days []models.NoteDailyViews
+	database.DB.Where("note_id = ?", note.ID).Order("day").Find(&days)
+	if assert.Len(t, days, 2) {
+		assert.Equal(t, "2026-03-01", days[0].Day)
+		assert.Equal(t, 3, days[0].Views)
+		assert.Equal(t, "2026-03-02", days[1].Day)
+		assert.Equal(t, 1, days[1].Views)
+	}
+
+	// 5. Later flushes add to the existing day
+	assert.True(t, view(0, browserUA, "c:c"))
+	_, err = counter.Flush()
+	assert.NoError(t, err)
+	database.DB.First(&stored, note.ID)
+	assert.Equal(t, 5, stored.Views)
+	var today models.NoteDailyViews
+	database.DB.Where("note_id = ? AND day = ?", note.ID, "2026-03-02").First(&today)
+	assert.Equal(t, 2, today.Views)
+}
+
+func TestViewCounter_WindowFromEnvAfterStartup(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	counter := services.NewViewCounter(clock)
+
+	// The window is read when views are recorded, not when the counter is built
+	t.Setenv("VIEW_DEDUPE_WINDOW", "5m")
+	view := services.PageView{NoteID: 1, AuthorID: 2, Visitors: []string{"c:a"}, UserAgent: browserUA}
+	assert.True(t, counter.Record(view))
+	clock.Advance(6 * time.Minute)
+	assert.True(t, counter.Record(view))
+
+	t.Setenv("VIEW_DEDUPE_WINDOW", "1h")
+	clock.Advance(30 * time.Minute)
+	assert.False(t, counter.Record(view))
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"time"
+
+	"github.com/tarakreasi/taraNote_go/internal/database"
+	"github.com/tarakreasi/taraNote_go/internal/models"
+)
+
+// MaxStatsDays bounds the period of a views report
+const MaxStatsDays = 366
+
+// DailyViews is the number of views on one UTC day
+type DailyViews struct {
+	Day   string `json:"day"`
+	Views int    `json:"views"`
+}
+
+// NoteViews is a note's all-time and in-period view counts
+type NoteViews struct {
+	ID          uint   `json:"id"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Status      string `json:"status"`
+	Views       int    `json:"views"`
+	PeriodViews int    `json:"period_views"`
+}
+
+// ViewReport is a daily series (oldest first, days without views included) and the notes viewed in the period
+type ViewReport struct {
+	Days  []DailyViews `json:"days"`
+	Notes []NoteViews  `json:"notes"`
+	Total int          `json:"total"`
+}
+
+type ViewStatsService struct {
+	clock Clock
+}
+
+func NewViewStatsService() *ViewStatsService {
+	return &ViewStatsService{clock: SystemClock}
+}
+
+// Report summarizes views of the user's notes over the last days (today included),
+// optionally for a single note. Views still buffered in memory are not included yet.
+func (s *ViewStatsService) Report(userID uint, days int, noteID *uint) (*ViewReport, error) {
+	if days < 1 {
+		days = 30
+	}
+	if days > MaxStatsDays {
+		days = MaxStatsDays
+	}
+
+	today := s.clock.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+
+	notes := database.DB.Model(&models.Note{}).Select("id").Where("user_id = ?", userID)
+	if noteID != nil {
+		notes = notes.Where("id = ?", *noteID)
+	}
+
+	var rows []DailyViews
+	if err := database.DB.Model(&models.NoteDailyViews{}).
+		Select("day, SUM(views) AS views").
+		Where("note_id IN (?) AND day >= ?", notes, from).
+		Group("day").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]int, len(rows))
+	for _, row := range rows {
+		byDay[row.Day] = row.Views
+	}
+
+	report := &ViewReport{Days: make([]DailyViews, 0, days), Notes: []NoteViews{}}
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		report.Days = append(report.Days, DailyViews{Day: day, Views: byDay[day]})
+		report.Total += byDay[day]
+	}
+
+	if err := database.DB.Model(&models.Note{}).
+		Select("notes.id, notes.title, notes.slug, notes.status, notes.views, SUM(note_daily_views.views) AS period_views").
+		Joins("JOIN note_daily_views ON note_daily_views.note_id = notes.id AND note_daily_views.day >= ?", from).
+		Where("notes.id IN (?)", notes).
+		Group("notes.id").
+		Order("period_views desc, notes.id asc").
+		Scan(&report.Notes).Error; err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -16,6 +16,11 @@ import (
 // body: nil or struct (will be JSON encoded)
 // cookies: optional string (for session)
 func MakeRequest(app *fiber.App, method, url string, body interface{}, cookies string) (*http.Response, string, error) {
+	return MakeRequestWithHeaders(app, method, url, body, cookies, nil)
+}
+
+// MakeRequestWithHeaders is MakeRequest with extra request headers (e.g. If-Match)
+func MakeRequestWithHeaders(app *fiber.App, method, url string, body interface{}, cookies string, headers map[string]string) (*http.Response, string, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonBytes, err := json.Marshal(body)
@@ -30,6 +35,9 @@ func MakeRequest(app *fiber.App, method, url string, body interface{}, cookies s
 	if cookies != "" {
 		req.Header.Set("Cookie", cookies)
 	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	resp, err := app.Test(req, -1) // -1 disables timeout
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/tarakreasi/taraNote_go/internal/database"
 	"github.com/tarakreasi/taraNote_go/internal/models"
 	"github.com/tarakreasi/taraNote_go/internal/routes"
+	"github.com/tarakreasi/taraNote_go/internal/services"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -31,8 +32,16 @@ func SetupApp() *fiber.App {
 		&models.Note{},
 		&models.Notebook{},
 		&models.Setting{},
+		&models.NoteRevision{},
+		&models.Tag{},
+		&models.NoteLink{},
+		&models.SlugHistory{},
+		&models.NoteDailyViews{},
+		&models.Mark{},
 	)
 
+	services.EnsureSearchIndex(database.DB)
+
 	// 3. Init Session (Store)
 	config.InitSession()
 
@@ -43,10 +52,13 @@ func SetupApp() *fiber.App {
 	engine := html.New("../../views", ".html") 
 
 	app := fiber.New(fiber.Config{
-		Views: engine,
+		Views:                        engine,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
 	// 5. Setup Routes
+	routes.SetupLimits(app)
 	routes.SetupWeb(app)
 	routes.SetupAPI(app)
 
@@ -59,4 +71,14 @@ func CleanupDB() {
 	database.DB.Exec("DELETE FROM notes")
 	database.DB.Exec("DELETE FROM notebooks")
 	database.DB.Exec("DELETE FROM settings")
+	database.DB.Exec("DELETE FROM note_revisions")
+	database.DB.Exec("DELETE FROM note_tags")
+	database.DB.Exec("DELETE FROM tags")
+	database.DB.Exec("DELETE FROM note_links")
+	database.DB.Exec("DELETE FROM slug_history")
+	database.DB.Exec("DELETE FROM note_daily_views")
+	database.DB.Exec("DELETE FROM marks")
+	if services.SearchAvailable() {
+		database.DB.Exec("DELETE FROM notes_fts")
+	}
 }
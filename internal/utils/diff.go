@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Diff operation kinds
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the LCS table so very large documents cannot exhaust memory.
+// Inputs beyond this size are reported as a full replacement.
+const maxDiffCells = 4_000_000
+
+// DiffOp is a single run of equal, inserted or deleted text
+type DiffOp struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+var blockBoundary = regexp.MustCompile(`(?i)(</(p|h[1-6]|li|blockquote|pre|ul|ol|div|table|tr)>|<br\s*/?>)`)
+
+// DiffLines compares two texts line by line.
+// HTML block boundaries are treated as line breaks so single-line Tiptap output still diffs usefully.
+func DiffLines(a, b string) []DiffOp {
+	return diffTokens(splitLines(a), splitLines(b), "\n")
+}
+
+// DiffWords compares two texts word by word, keeping whitespace attached to the preceding word
+func DiffWords(a, b string) []DiffOp {
+	return diffTokens(splitWords(a), splitWords(b), "")
+}
+
+func splitLines(s string) []string {
+	s = blockBoundary.ReplaceAllString(s, "$1\n")
+	lines := strings.Split(s, "\n")
+	// Drop the empty tail produced by a trailing newline
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+var wordPattern = regexp.MustCompile(`\S+\s*|\s+`)
+
+func splitWords(s string) []string {
+	return wordPattern.FindAllString(s, -1)
+}
+
+// diffTokens computes an LCS based diff and merges adjacent tokens with the same operation
+func diffTokens(a, b []string, sep string) []DiffOp {
+	// Trim common prefix and suffix to keep the table small for typical edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []DiffOp
+	emit := func(op, text string) {
+		if n := len(ops); n > 0 && ops[n-1].Op == op {
+			ops[n-1].Text += sep + text
+			return
+		}
+		ops = append(ops, DiffOp{Op: op, Text: text})
+	}
+
+	for _, t := range a[:prefix] {
+		emit(DiffEqual, t)
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	if len(midA)*len(midB) > maxDiffCells {
+		for _, t := range midA {
+			emit(DiffDelete, t)
+		}
+		for _, t := range midB {
+			emit(DiffInsert, t)
+		}
+	} else {
+		// lcs[i][j] holds the LCS length of midA[i:] and midB[j:]
+		lcs := make([][]int, len(midA)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(midB)+1)
+		}
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(midA) && j < len(midB) {
+			switch {
+			case midA[i] == midB[j]:
+				emit(DiffEqual, midA[i])
+				i++
+				j++
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				emit(DiffDelete, midA[i])
+				i++
+			default:
+				emit(DiffInsert, midB[j])
+				j++
+			}
+		}
+		for ; i < len(midA); i++ {
+			emit(DiffDelete, midA[i])
+		}
+		for ; j < len(midB); j++ {
+			emit(DiffInsert, midB[j])
+		}
+	}
+
+	for _, t := range a[len(a)-suffix:] {
+		emit(DiffEqual, t)
+	}
+
+	return ops
+}
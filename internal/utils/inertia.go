@@ -20,8 +20,38 @@ func CreateInertiaPage(c *fiber.Ctx, component string, props fiber.Map) []byte {
 	return bytes
 }
 
+// HeadMeta is the <head> metadata rendered into the HTML shell, for crawlers and link unfurlers
+// that do not run the frontend. URLs must be absolute.
+type HeadMeta struct {
+	Title       string // Page title; the site name is appended in <title>
+	Description string
+	Canonical   string
+	Image       string
+	Type        string // OpenGraph type: website (default) or article
+	SiteName    string
+	Twitter     string // @handle of the site on Twitter/X
+	Author      string
+	PublishedAt string      // RFC 3339
+	ModifiedAt  string      // RFC 3339
+	JSONLD      interface{} // Structured data, rendered as application/ld+json
+}
+
+// TwitterCard picks the large image card when the page has an image
+func (h HeadMeta) TwitterCard() string {
+	if h.Image != "" {
+		return "summary_large_image"
+	}
+	return "summary"
+}
+
 // RenderInertia handles the Inertia response logic (JSON vs HTML)
 func RenderInertia(c *fiber.Ctx, component string, props fiber.Map) error {
+	return RenderInertiaWithHead(c, component, props, HeadMeta{})
+}
+
+// RenderInertiaWithHead renders like RenderInertia, with head metadata in the HTML shell.
+// Inertia visits only receive the page JSON; the frontend manages the title from there.
+func RenderInertiaWithHead(c *fiber.Ctx, component string, props fiber.Map, head HeadMeta) error {
 	// If X-Inertia header is present, return JSON
 	if c.Get("X-Inertia") == "true" {
 		c.Set("X-Inertia", "true")
@@ -42,5 +72,6 @@ func RenderInertia(c *fiber.Ctx, component string, props fiber.Map) error {
 	return c.Render("app", fiber.Map{
 		"InertiaJSON": string(CreateInertiaPage(c, component, props)),
 		"ViteTags":    template.HTML(viteTags),
+		"Head":        head,
 	})
 }
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Block-level tags become spaces so words from adjacent paragraphs don't merge
+	blockTagPattern = regexp.MustCompile(`(?i)</?(p|div|h[1-6]|li|ul|ol|blockquote|pre|br|hr|tr|td|th|table|figure|figcaption|section|article)\b[^>]*>`)
+	scriptPattern   = regexp.MustCompile(`(?is)<(script|style)\b[^>]*>.*?</(script|style)>`)
+	tagPattern      = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacePattern    = regexp.MustCompile(`\s+`)
+)
+
+// StripHTML converts HTML content into plain text with collapsed whitespace
+func StripHTML(input string) string {
+	text := scriptPattern.ReplaceAllString(input, " ")
+	text = blockTagPattern.ReplaceAllString(text, " ")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = spacePattern.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// CountWords counts whitespace-separated words in plain text
+func CountWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// Truncate shortens plain text to at most max characters, cutting at a word
+// boundary when possible and marking the cut with an ellipsis
+func Truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	cut := string(runes[:max])
+	if i := strings.LastIndexAny(cut, " \t\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \t\n.,;:!?-") + "…"
+}
@@ -2,15 +2,82 @@ import { ref, computed } from 'vue';
 
 export function useNotes(props) {
     const searchQuery = ref('');
-    const selectedNotebookId = ref(null);
+    const selectedNotebookId = ref(props.notebook?.id ?? null);
     const selectedArticle = ref(null);
 
+    const notebooksById = computed(() => new Map((props.notebooks || []).map(n => [n.id, n])));
+
+    // Children of each notebook by parent ID in manual order; top-level notebooks (and orphans) sit under null
+    const childrenByParent = computed(() => {
+        const children = new Map();
+        for (const notebook of props.notebooks || []) {
+            const parent = notebooksById.value.has(notebook.parent_id) ? notebook.parent_id : null;
+            if (!children.has(parent)) children.set(parent, []);
+            children.get(parent).push(notebook);
+        }
+        for (const list of children.values()) {
+            list.sort((a, b) => (a.position ?? 0) - (b.position ?? 0) || a.name.localeCompare(b.name));
+        }
+        return children;
+    });
+
+    // Notebooks in tree order with their depth, for the sidebar
+    const notebookRows = computed(() => {
+        const rows = [];
+        const visit = (parent, depth) => {
+            for (const notebook of childrenByParent.value.get(parent) || []) {
+                rows.push({ notebook, depth });
+                visit(notebook.id, depth + 1);
+            }
+        };
+        visit(null, 0);
+        return rows;
+    });
+
+    // A notebook and every notebook below it
+    const subtreeIds = (id) => {
+        const ids = new Set([id]);
+        const queue = [id];
+        while (queue.length) {
+            for (const child of childrenByParent.value.get(queue.shift()) || []) {
+                if (!ids.has(child.id)) {
+                    ids.add(child.id);
+                    queue.push(child.id);
+                }
+            }
+        }
+        return ids;
+    };
+
+    // Path from the top-level notebook down to the selected one
+    const breadcrumbs = computed(() => {
+        if (!selectedNotebookId.value) return [];
+        if (props.breadcrumbs?.length && props.notebook?.id === selectedNotebookId.value) {
+            return props.breadcrumbs;
+        }
+        const crumbs = [];
+        const seen = new Set();
+        let notebook = notebooksById.value.get(selectedNotebookId.value);
+        while (notebook && !seen.has(notebook.id)) {
+            seen.add(notebook.id);
+            crumbs.unshift(notebook);
+            notebook = notebooksById.value.get(notebook.parent_id);
+        }
+        return crumbs;
+    });
+
     const filteredNotes = computed(() => {
         let filtered = props.notes || [];
 
-        // Filter by notebook
+        // Filter by notebook, including the notebooks below it. The notebook's own notes come
+        // first in their manual order, followed by those of the notebooks below.
         if (selectedNotebookId.value) {
-            filtered = filtered.filter(note => note.notebook_id === selectedNotebookId.value);
+            const id = selectedNotebookId.value;
+            const ids = subtreeIds(id);
+            const rank = note => note.notebook_id === id ? (note.position ?? 0) : Infinity;
+            filtered = filtered
+                .filter(note => ids.has(note.notebook_id))
+                .sort((a, b) => (rank(a) === rank(b) ? 0 : rank(a) < rank(b) ? -1 : 1));
         }
 
         // Filter by search query (Client-side refinement)
@@ -27,7 +94,7 @@ export function useNotes(props) {
 
     const currentNotebookName = computed(() => {
         if (!selectedNotebookId.value) return 'All Notes';
-        return props.notebooks.find(n => n.id === selectedNotebookId.value)?.name || 'All Notes';
+        return notebooksById.value.get(selectedNotebookId.value)?.name || 'All Notes';
     });
 
     const selectNotebook = (id) => {
@@ -44,6 +111,8 @@ export function useNotes(props) {
         selectedNotebookId,
         selectedArticle,
         filteredNotes,
+        notebookRows,
+        breadcrumbs,
         currentNotebookName,
         selectNotebook,
         openArticle